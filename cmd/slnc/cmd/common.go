@@ -27,6 +27,13 @@ import (
 )
 
 func getClient() *rpc.Client {
+	api := rpc.NewWithHeaders(sanitizeAPIURL(viper.GetString("global-rpc-url")), getHTTPHeaders())
+	return api
+}
+
+// getHTTPHeaders returns the headers of the "--http-header" flag, and of the
+// SLNC_GLOBAL_HTTP_HEADER_<n> environment variables.
+func getHTTPHeaders() map[string]string {
 	httpHeaders := viper.GetStringSlice("global-http-header")
 
 	for i := 0; i < 25; i++ {
@@ -43,8 +50,7 @@ func getClient() *rpc.Client {
 		}
 		headers[headerArray[0]] = headerArray[1]
 	}
-	api := rpc.NewWithHeaders(sanitizeAPIURL(viper.GetString("global-rpc-url")), headers)
-	return api
+	return headers
 }
 
 func sanitizeAPIURL(input string) string {
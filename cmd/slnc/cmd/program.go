@@ -0,0 +1,329 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/gagliardetto/solana-go/rpc"
+	blockhashprovider "github.com/gagliardetto/solana-go/rpc/blockhashProvider"
+	confirm "github.com/gagliardetto/solana-go/rpc/sendAndConfirmTransaction"
+	"github.com/gagliardetto/solana-go/vault"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var programCmd = &cobra.Command{
+	Use:   "program",
+	Short: "Deploy, upgrade, show and close programs of the upgradeable BPF loader",
+}
+
+func init() {
+	RootCmd.AddCommand(programCmd)
+}
+
+// addProgramFlags adds the flags shared by the program commands that send transactions;
+// write is set for the ones that write a program into a buffer account.
+func addProgramFlags(cmd *cobra.Command, write bool) {
+	cmd.Flags().String("commitment", string(rpc.CommitmentConfirmed), "Commitment to wait for: processed, confirmed or finalized")
+	if !write {
+		return
+	}
+	cmd.Flags().String("buffer", "", "Existing buffer account to write the program into (e.g. to resume an interrupted deploy)")
+	cmd.Flags().Int("max-in-flight", 16, "Maximum number of write transactions sent in parallel")
+	cmd.Flags().Int("max-retries", 5, "Maximum number of times the chunks that failed to be written are retried")
+}
+
+// programSender sends the transactions of the program commands, signed with the vault keys.
+type programSender struct {
+	client      *rpc.Client
+	vault       *vault.Vault
+	blockhashes *blockhashprovider.BlockhashProvider
+	commitment  rpc.CommitmentType
+}
+
+// newProgramSender reads the flags added by addProgramFlags (prefix is the viper prefix of the command),
+// and starts refreshing the blockhash until the context is done.
+func newProgramSender(ctx context.Context, prefix string, client *rpc.Client, v *vault.Vault) (*programSender, error) {
+	commitment := rpc.CommitmentType(viper.GetString(prefix + "-cmd-commitment"))
+	switch commitment {
+	case rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+	default:
+		return nil, fmt.Errorf("invalid commitment %q", commitment)
+	}
+	blockhashes := blockhashprovider.NewBlockhashProvider(client, nil, blockhashprovider.BlockhashProviderOpts{
+		Commitment: commitment,
+	})
+	if err := blockhashes.Start(ctx); err != nil {
+		return nil, fmt.Errorf("unable to retrieve latest blockhash: %w", err)
+	}
+	return &programSender{
+		client:      client,
+		vault:       v,
+		blockhashes: blockhashes,
+		commitment:  commitment,
+	}, nil
+}
+
+// close stops refreshing the blockhash.
+func (s *programSender) close() {
+	s.blockhashes.Close()
+}
+
+// send builds the transaction with a recent blockhash, signs it, sends it
+// and waits for its confirmation; an error is returned if it failed or expired.
+func (s *programSender) send(ctx context.Context, builder *solana.TransactionBuilder, extraSigners ...solana.PrivateKey) (*confirm.ConfirmationResult, error) {
+	latest, err := s.blockhashes.Latest()
+	if err != nil {
+		return nil, err
+	}
+	trx, err := builder.SetRecentBlockHash(latest.Blockhash).Build()
+	if err != nil {
+		return nil, fmt.Errorf("unable to craft transaction: %w", err)
+	}
+	if _, err := trx.Sign(vaultSigner(s.vault, extraSigners...)); err != nil {
+		return nil, fmt.Errorf("unable to sign transaction: %w", err)
+	}
+	res, err := confirm.SendAndConfirmTransactionWithRebroadcast(ctx, s.client, nil, trx, confirm.RebroadcastOpts{
+		Commitment:           s.commitment,
+		PreflightCommitment:  s.commitment,
+		LastValidBlockHeight: latest.LastValidBlockHeight,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to send transaction: %w", err)
+	}
+	switch res.Outcome {
+	case confirm.OutcomeConfirmed:
+		return res, nil
+	case confirm.OutcomeFailed:
+		return res, fmt.Errorf("transaction %s failed in slot %d: %v", res.Signature, res.Slot, res.Err)
+	default:
+		return res, fmt.Errorf("transaction %s expired before being processed", res.Signature)
+	}
+}
+
+// sendAll sends the transactions with at most maxInFlight of them in parallel,
+// printing the progress, and returns the number of them that failed.
+func (s *programSender) sendAll(ctx context.Context, builders []*solana.TransactionBuilder, maxInFlight int) int {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+	var mu sync.Mutex
+	var done, failed int
+	progress := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "\n%s\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "\r[%d/%d] chunks written", done-failed, len(builders))
+		if done == len(builders) {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+
+	queue := make(chan *solana.TransactionBuilder)
+	var wg sync.WaitGroup
+	for i := 0; i < maxInFlight && i < len(builders); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for builder := range queue {
+				_, err := s.send(ctx, builder)
+				progress(err)
+			}
+		}()
+	}
+	for _, builder := range builders {
+		queue <- builder
+	}
+	close(queue)
+	wg.Wait()
+	return failed
+}
+
+// writeProgramBuffer writes the program into the buffer account, creating it (signed by bufferKey) if needed.
+// The chunks are sent in parallel; the ones that fail are retried, by comparing the content of the buffer
+// with the program, which also allows resuming an interrupted write.
+func (s *programSender) writeProgramBuffer(
+	ctx context.Context,
+	payer solana.PublicKey,
+	authority solana.PublicKey,
+	buffer solana.PublicKey,
+	bufferKey *solana.PrivateKey, // nil if the buffer must exist
+	program []byte,
+	maxInFlight int,
+	maxRetries int,
+) error {
+	rent, err := s.client.GetMinimumBalanceForRentExemption(ctx, uint64(bpfloader.UpgradeableBufferMetadataSize+len(program)), s.commitment)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve rent-exempt reserve: %w", err)
+	}
+
+	for retries := 0; ; {
+		account, err := s.getAccount(ctx, buffer)
+		if err != nil {
+			return err
+		}
+		initial, writes, err := bpfloader.WriteBuffer(payer, account, program, rent, buffer, authority)
+		if err != nil {
+			return fmt.Errorf("unable to write buffer %s: %w", buffer, err)
+		}
+		if initial != nil {
+			if bufferKey == nil {
+				return fmt.Errorf("buffer account %s not found", buffer)
+			}
+			fmt.Printf("Creating buffer account %s (%s SOL)\n", buffer, formatUIAmount(rent, solDecimals))
+			if _, err := s.send(ctx, initial, *bufferKey); err != nil {
+				return fmt.Errorf("unable to create buffer account: %w", err)
+			}
+			continue
+		}
+		if len(writes) == 0 {
+			return nil
+		}
+		if retries > maxRetries {
+			return fmt.Errorf("%d chunks still not written after %d retries; pass --buffer %s to resume", len(writes), maxRetries, buffer)
+		}
+		if retries > 0 {
+			fmt.Printf("Retrying %d chunks\n", len(writes))
+		} else {
+			fmt.Printf("Writing %d chunks (%d bytes) to buffer account %s\n", len(writes), len(program), buffer)
+		}
+		if failed := s.sendAll(ctx, writes, maxInFlight); failed > 0 {
+			fmt.Printf("%d chunks failed to be written\n", failed)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// The next round checks the content of the buffer.
+		retries++
+	}
+}
+
+// getAccount gets an account, or nil if it doesn't exist.
+func (s *programSender) getAccount(ctx context.Context, account solana.PublicKey) (*rpc.Account, error) {
+	res, err := s.client.GetAccountInfoWithOpts(ctx, account, &rpc.GetAccountInfoOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: s.commitment,
+	})
+	if err == rpc.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get account %s: %w", account, err)
+	}
+	return res.Value, nil
+}
+
+// programBuffer returns the buffer account of the "--buffer" flag of the command (prefix is its viper prefix),
+// or else a new one, along with its private key.
+func programBuffer(prefix string) (solana.PublicKey, *solana.PrivateKey, error) {
+	if buffer := viper.GetString(prefix + "-cmd-buffer"); buffer != "" {
+		pubkey, err := solana.PublicKeyFromBase58(buffer)
+		if err != nil {
+			return solana.PublicKey{}, nil, fmt.Errorf("invalid buffer account %q: %w", buffer, err)
+		}
+		return pubkey, nil, nil
+	}
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+	return key.PublicKey(), &key, nil
+}
+
+// readProgram reads a program (ELF) file.
+func readProgram(file string) ([]byte, error) {
+	program, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read program %q: %w", file, err)
+	}
+	if !bytes.HasPrefix(program, []byte("\x7fELF")) {
+		return nil, fmt.Errorf("%q is not an ELF file", file)
+	}
+	return program, nil
+}
+
+// loadOrCreateKeypair loads a keypair file (in the format of solana-keygen),
+// or creates it with a new key if it doesn't exist.
+func loadOrCreateKeypair(file string) (solana.PrivateKey, bool, error) {
+	if _, err := os.Stat(file); err == nil {
+		key, err := solana.PrivateKeyFromSolanaKeygenFile(file)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to read keypair %q: %w", file, err)
+		}
+		return key, false, nil
+	}
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		return nil, false, err
+	}
+	keyBytes := make([]int, len(key))
+	for i, b := range key {
+		keyBytes[i] = int(b)
+	}
+	content, err := json.Marshal(keyBytes)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.WriteFile(file, content, 0600); err != nil {
+		return nil, false, fmt.Errorf("unable to write keypair %q: %w", file, err)
+	}
+	return key, true, nil
+}
+
+// defaultProgramKeypairFile returns the keypair file of a program, next to it,
+// as built by cargo build-sbf (e.g. target/deploy/foo-keypair.json for target/deploy/foo.so).
+func defaultProgramKeypairFile(programFile string) string {
+	return strings.TrimSuffix(programFile, ".so") + "-keypair.json"
+}
+
+// getUpgradeableProgram gets a program of the upgradeable loader, and the state of its program data account.
+func getUpgradeableProgram(ctx context.Context, client *rpc.Client, program solana.PublicKey) (*rpc.Account, *bpfloader.UpgradeableLoaderState, error) {
+	res, err := client.GetAccountInfo(ctx, program)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get program %s: %w", program, err)
+	}
+	if !res.Value.Owner.Equals(solana.BPFLoaderUpgradeableProgramID) {
+		return nil, nil, fmt.Errorf("%s is not an upgradeable program (owner: %s)", program, res.Value.Owner)
+	}
+	state, err := bpfloader.DecodeUpgradeableLoaderState(res.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode program %s: %w", program, err)
+	}
+	if state.Type != bpfloader.UpgradeableLoaderStateProgram {
+		return nil, nil, fmt.Errorf("%s is a %s account, not a program", program, state.Type)
+	}
+	res, err = client.GetAccountInfo(ctx, state.ProgramData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get program data %s: %w", state.ProgramData, err)
+	}
+	programData, err := bpfloader.DecodeUpgradeableLoaderState(res.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode program data %s: %w", state.ProgramData, err)
+	}
+	return res.Value, programData, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var programCloseCmd = &cobra.Command{
+	Use:   "close [buffer...]",
+	Short: "Close buffer accounts of the upgradeable BPF loader, to reclaim their rent",
+	Long: `Close buffer accounts of the upgradeable BPF loader, to reclaim their rent.
+
+With --all, all the buffer accounts of the authority are closed.
+The authority of the buffers must be in the vault.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		var authority *solana.PublicKey
+		if value := viper.GetString("program-close-cmd-authority"); value != "" {
+			pubkey, err := solana.PublicKeyFromBase58(value)
+			if err != nil {
+				return fmt.Errorf("invalid authority %q: %w", value, err)
+			}
+			authority = &pubkey
+		}
+		var recipient *solana.PublicKey
+		if value := viper.GetString("program-close-cmd-recipient"); value != "" {
+			pubkey, err := solana.PublicKeyFromBase58(value)
+			if err != nil {
+				return fmt.Errorf("invalid recipient %q: %w", value, err)
+			}
+			recipient = &pubkey
+		}
+
+		client := getClient()
+		buffers := make(map[solana.PublicKey]*rpc.Account)
+		var order []solana.PublicKey
+		if viper.GetBool("program-close-cmd-all") {
+			if authority == nil {
+				return fmt.Errorf("--all requires the --authority flag")
+			}
+			metadataSize := uint64(bpfloader.UpgradeableBufferMetadataSize)
+			accounts, err := client.GetProgramAccountsWithOpts(ctx, solana.BPFLoaderUpgradeableProgramID, &rpc.GetProgramAccountsOpts{
+				Encoding: solana.EncodingBase64,
+				// Only the metadata of the buffers is needed.
+				DataSlice: &rpc.DataSlice{Offset: new(uint64), Length: &metadataSize},
+				Filters: []rpc.RPCFilter{
+					{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: solana.Base58{byte(bpfloader.UpgradeableLoaderStateBuffer), 0, 0, 0, 1}}},
+					{Memcmp: &rpc.RPCFilterMemcmp{Offset: 5, Bytes: solana.Base58(authority[:])}},
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("unable to get the buffers of %s: %w", authority, err)
+			}
+			for _, account := range accounts {
+				buffers[account.Pubkey] = account.Account
+				order = append(order, account.Pubkey)
+			}
+		}
+		for _, arg := range args {
+			buffer, err := solana.PublicKeyFromBase58(arg)
+			if err != nil {
+				return fmt.Errorf("invalid buffer account %q: %w", arg, err)
+			}
+			if _, ok := buffers[buffer]; ok {
+				continue
+			}
+			res, err := client.GetAccountInfo(ctx, buffer)
+			if err != nil {
+				return fmt.Errorf("couldn't get buffer account %s: %w", buffer, err)
+			}
+			buffers[buffer] = res.Value
+			order = append(order, buffer)
+		}
+		if len(order) == 0 {
+			fmt.Println("No buffer accounts to close")
+			return nil
+		}
+
+		v := mustGetWallet()
+		sender, err := newProgramSender(ctx, "program-close", client, v)
+		if err != nil {
+			return err
+		}
+		defer sender.close()
+
+		var reclaimed uint64
+		for _, buffer := range order {
+			account := buffers[buffer]
+			if !account.Owner.Equals(solana.BPFLoaderUpgradeableProgramID) {
+				return fmt.Errorf("%s is not a buffer account (owner: %s)", buffer, account.Owner)
+			}
+			state, err := bpfloader.DecodeUpgradeableLoaderState(account.Data.GetBinary())
+			if err != nil {
+				return fmt.Errorf("unable to decode buffer account %s: %w", buffer, err)
+			}
+			if state.Type != bpfloader.UpgradeableLoaderStateBuffer {
+				return fmt.Errorf("%s is a %s account, not a buffer", buffer, state.Type)
+			}
+			bufferAuthority := state.Authority
+			if authority != nil {
+				bufferAuthority = authority
+			}
+			if bufferAuthority == nil {
+				return fmt.Errorf("buffer account %s has no authority", buffer)
+			}
+			if err := requireVaultKey(v, *bufferAuthority, "buffer authority"); err != nil {
+				return err
+			}
+			to := *bufferAuthority
+			if recipient != nil {
+				to = *recipient
+			}
+
+			fmt.Printf("Closing buffer account %s (%s SOL to %s)\n", buffer, formatUIAmount(account.Lamports, solDecimals), to)
+			res, err := sender.send(ctx, solana.NewTransactionBuilder().
+				AddInstruction(bpfloader.NewCloseInstruction(buffer, to, *bufferAuthority)).
+				SetFeePayer(*bufferAuthority))
+			if err != nil {
+				return fmt.Errorf("unable to close buffer account %s: %w", buffer, err)
+			}
+			fmt.Println("Transaction signature:", res.Signature)
+			reclaimed += account.Lamports
+		}
+		fmt.Printf("Reclaimed %s SOL from %d buffer accounts\n", formatUIAmount(reclaimed, solDecimals), len(order))
+		return nil
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programCloseCmd)
+	addProgramFlags(programCloseCmd, false)
+	programCloseCmd.Flags().String("authority", "", "Authority of the buffers; defaults to the one of each buffer")
+	programCloseCmd.Flags().String("recipient", "", "Recipient of the reclaimed lamports; defaults to the authority")
+	programCloseCmd.Flags().Bool("all", false, "Close all the buffer accounts of the authority")
+}
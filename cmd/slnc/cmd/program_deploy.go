@@ -0,0 +1,139 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var programDeployCmd = &cobra.Command{
+	Use:   "deploy {payer} {program.so}",
+	Short: "Deploy a program with the upgradeable BPF loader",
+	Long: `Deploy a program with the upgradeable BPF loader.
+
+The program is written into a buffer account in parallel chunks, and then deployed
+from it. The address of the program is the one of its keypair file (--program-keypair),
+which defaults to the one next to the program (e.g. foo-keypair.json for foo.so),
+and is created if it doesn't exist.
+
+If the deploy is interrupted, it can be resumed by passing the buffer account (--buffer):
+only the missing chunks are written again. The payer and the upgrade authority must be in the vault.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		payer, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid payer address %q: %w", args[0], err)
+		}
+		program, err := readProgram(args[1])
+		if err != nil {
+			return err
+		}
+		authority := payer
+		if value := viper.GetString("program-deploy-cmd-upgrade-authority"); value != "" {
+			if authority, err = solana.PublicKeyFromBase58(value); err != nil {
+				return fmt.Errorf("invalid upgrade authority %q: %w", value, err)
+			}
+		}
+		maxLen := viper.GetUint64("program-deploy-cmd-max-len")
+		if maxLen == 0 {
+			maxLen = 2 * uint64(len(program))
+		} else if maxLen < uint64(len(program)) {
+			return fmt.Errorf("--max-len (%d) is smaller than the program (%d bytes)", maxLen, len(program))
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, payer, "payer"); err != nil {
+			return err
+		}
+		if err := requireVaultKey(v, authority, "upgrade authority"); err != nil {
+			return err
+		}
+
+		keypairFile := viper.GetString("program-deploy-cmd-program-keypair")
+		if keypairFile == "" {
+			keypairFile = defaultProgramKeypairFile(args[1])
+		}
+		programKey, created, err := loadOrCreateKeypair(keypairFile)
+		if err != nil {
+			return err
+		}
+		if created {
+			fmt.Printf("Created program keypair %s\n", keypairFile)
+		}
+		programID := programKey.PublicKey()
+		fmt.Println("Program ID:", programID)
+
+		client := getClient()
+		sender, err := newProgramSender(ctx, "program-deploy", client, v)
+		if err != nil {
+			return err
+		}
+		defer sender.close()
+
+		if account, err := sender.getAccount(ctx, programID); err != nil {
+			return err
+		} else if account != nil {
+			return fmt.Errorf("program %s already exists; use the upgrade command to upgrade it", programID)
+		}
+
+		buffer, bufferKey, err := programBuffer("program-deploy")
+		if err != nil {
+			return err
+		}
+		if err := sender.writeProgramBuffer(
+			ctx,
+			payer,
+			authority,
+			buffer,
+			bufferKey,
+			program,
+			viper.GetInt("program-deploy-cmd-max-in-flight"),
+			viper.GetInt("program-deploy-cmd-max-retries"),
+		); err != nil {
+			return err
+		}
+
+		programRent, err := client.GetMinimumBalanceForRentExemption(ctx, bpfloader.UpgradeableProgramSize, sender.commitment)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve rent-exempt reserve: %w", err)
+		}
+		deploy, err := bpfloader.DeployUpgradeable(payer, programID, programRent, buffer, authority, maxLen)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deploying program %s (max %d bytes)\n", programID, maxLen)
+		res, err := sender.send(ctx, deploy, programKey)
+		if err != nil {
+			return fmt.Errorf("unable to deploy program (the buffer %s can be reused with --buffer): %w", buffer, err)
+		}
+		fmt.Println("Transaction signature:", res.Signature)
+		fmt.Printf("Program %s deployed in slot %d\n", programID, res.Slot)
+		return nil
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programDeployCmd)
+	addProgramFlags(programDeployCmd, true)
+	programDeployCmd.Flags().String("program-keypair", "", "Keypair file of the program; defaults to the one next to the program")
+	programDeployCmd.Flags().String("upgrade-authority", "", "Upgrade authority of the program; defaults to the payer")
+	programDeployCmd.Flags().Uint64("max-len", 0, "Maximum size of the program, for future upgrades; defaults to twice its size")
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/spf13/cobra"
+)
+
+var programShowCmd = &cobra.Command{
+	Use:   "show {address}",
+	Short: "Show a program, program data or buffer account of the BPF loaders",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		address, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", args[0], err)
+		}
+
+		client := getClient()
+		res, err := client.GetAccountInfo(ctx, address)
+		if err != nil {
+			return fmt.Errorf("couldn't get account %s: %w", address, err)
+		}
+		account := res.Value
+		data := account.Data.GetBinary()
+
+		switch {
+		case account.Owner.Equals(solana.BPFLoaderProgramID), account.Owner.Equals(solana.BPFLoaderDeprecatedProgramID):
+			fmt.Println("Program ID:", address)
+			fmt.Println("Owner:", account.Owner, "(not upgradeable)")
+			fmt.Println("Executable:", account.Executable)
+			fmt.Println("Data length:", len(data), "bytes")
+			fmt.Println("Balance:", formatUIAmount(account.Lamports, solDecimals), "SOL")
+			return nil
+		case !account.Owner.Equals(solana.BPFLoaderUpgradeableProgramID):
+			return fmt.Errorf("%s is not an account of the BPF loaders (owner: %s)", address, account.Owner)
+		}
+
+		state, err := bpfloader.DecodeUpgradeableLoaderState(data)
+		if err != nil {
+			return fmt.Errorf("unable to decode account %s: %w", address, err)
+		}
+		switch state.Type {
+		case bpfloader.UpgradeableLoaderStateProgram:
+			fmt.Println("Program ID:", address)
+			fmt.Println("Owner:", account.Owner)
+			fmt.Println("Program data address:", state.ProgramData)
+			res, err := client.GetAccountInfo(ctx, state.ProgramData)
+			if err != nil {
+				return fmt.Errorf("couldn't get program data %s: %w", state.ProgramData, err)
+			}
+			programData, err := bpfloader.DecodeUpgradeableLoaderState(res.Value.Data.GetBinary())
+			if err != nil {
+				return fmt.Errorf("unable to decode program data %s: %w", state.ProgramData, err)
+			}
+			printProgramData(programData, len(res.Value.Data.GetBinary()))
+			fmt.Println("Balance:", formatUIAmount(account.Lamports+res.Value.Lamports, solDecimals), "SOL")
+		case bpfloader.UpgradeableLoaderStateProgramData:
+			fmt.Println("Program data address:", address)
+			printProgramData(state, len(data))
+			fmt.Println("Balance:", formatUIAmount(account.Lamports, solDecimals), "SOL")
+		case bpfloader.UpgradeableLoaderStateBuffer:
+			fmt.Println("Buffer address:", address)
+			fmt.Println("Authority:", printableAuthority(state.Authority))
+			fmt.Println("Data length:", len(data)-bpfloader.UpgradeableBufferMetadataSize, "bytes")
+			fmt.Println("Balance:", formatUIAmount(account.Lamports, solDecimals), "SOL")
+		default:
+			fmt.Println("Address:", address)
+			fmt.Println("State:", state.Type)
+			fmt.Println("Balance:", formatUIAmount(account.Lamports, solDecimals), "SOL")
+		}
+		return nil
+	},
+}
+
+func printProgramData(state *bpfloader.UpgradeableLoaderState, size int) {
+	fmt.Println("Upgrade authority:", printableAuthority(state.Authority))
+	fmt.Println("Last deployed in slot:", state.Slot)
+	fmt.Println("Data length:", size-bpfloader.UpgradeableProgramDataMetadataSize, "bytes")
+}
+
+func printableAuthority(authority *solana.PublicKey) string {
+	if authority == nil {
+		return "none (immutable)"
+	}
+	return authority.String()
+}
+
+func init() {
+	programCmd.AddCommand(programShowCmd)
+}
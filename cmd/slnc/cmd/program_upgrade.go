@@ -0,0 +1,123 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var programUpgradeCmd = &cobra.Command{
+	Use:   "upgrade {program_id} {program.so}",
+	Short: "Upgrade a program of the upgradeable BPF loader",
+	Long: `Upgrade a program of the upgradeable BPF loader.
+
+The new program is written into a buffer account in parallel chunks, and the program
+is then upgraded from it; the lamports of the buffer account go back to the fee payer.
+If the upgrade is interrupted, it can be resumed by passing the buffer account (--buffer).
+The upgrade authority (and the fee payer) must be in the vault.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		programID, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid program id %q: %w", args[0], err)
+		}
+		program, err := readProgram(args[1])
+		if err != nil {
+			return err
+		}
+
+		client := getClient()
+		programDataAccount, programData, err := getUpgradeableProgram(ctx, client, programID)
+		if err != nil {
+			return err
+		}
+		if programData.Authority == nil {
+			return fmt.Errorf("program %s is immutable", programID)
+		}
+		if capacity := len(programDataAccount.Data.GetBinary()) - bpfloader.UpgradeableProgramDataMetadataSize; len(program) > capacity {
+			return fmt.Errorf("program is too large (%d bytes): program %s can hold at most %d bytes", len(program), programID, capacity)
+		}
+
+		authority := *programData.Authority
+		if value := viper.GetString("program-upgrade-cmd-upgrade-authority"); value != "" {
+			if authority, err = solana.PublicKeyFromBase58(value); err != nil {
+				return fmt.Errorf("invalid upgrade authority %q: %w", value, err)
+			}
+		}
+		payer := authority
+		if value := viper.GetString("program-upgrade-cmd-fee-payer"); value != "" {
+			if payer, err = solana.PublicKeyFromBase58(value); err != nil {
+				return fmt.Errorf("invalid fee payer %q: %w", value, err)
+			}
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, authority, "upgrade authority"); err != nil {
+			return err
+		}
+		if err := requireVaultKey(v, payer, "fee payer"); err != nil {
+			return err
+		}
+
+		sender, err := newProgramSender(ctx, "program-upgrade", client, v)
+		if err != nil {
+			return err
+		}
+		defer sender.close()
+
+		buffer, bufferKey, err := programBuffer("program-upgrade")
+		if err != nil {
+			return err
+		}
+		if err := sender.writeProgramBuffer(
+			ctx,
+			payer,
+			authority,
+			buffer,
+			bufferKey,
+			program,
+			viper.GetInt("program-upgrade-cmd-max-in-flight"),
+			viper.GetInt("program-upgrade-cmd-max-retries"),
+		); err != nil {
+			return err
+		}
+
+		upgrade, err := bpfloader.NewUpgradeInstruction(programID, buffer, authority, payer)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Upgrading program %s\n", programID)
+		res, err := sender.send(ctx, solana.NewTransactionBuilder().AddInstruction(upgrade).SetFeePayer(payer))
+		if err != nil {
+			return fmt.Errorf("unable to upgrade program (the buffer %s can be reused with --buffer): %w", buffer, err)
+		}
+		fmt.Println("Transaction signature:", res.Signature)
+		fmt.Printf("Program %s upgraded in slot %d\n", programID, res.Slot)
+		return nil
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programUpgradeCmd)
+	addProgramFlags(programUpgradeCmd, true)
+	programUpgradeCmd.Flags().String("upgrade-authority", "", "Upgrade authority; defaults to the one of the program")
+	programUpgradeCmd.Flags().String("fee-payer", "", "Fee payer, which also pays for the buffer account; defaults to the upgrade authority")
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var stakeCmd = &cobra.Command{
+	Use:   "stake",
+	Short: "Create, delegate, split and withdraw stake accounts",
+}
+
+func init() {
+	RootCmd.AddCommand(stakeCmd)
+}
+
+// getStakeAccount gets and decodes a stake account.
+func getStakeAccount(ctx context.Context, client *rpc.Client, account solana.PublicKey) (*rpc.Account, *stake.StakeState, error) {
+	res, err := client.GetAccountInfo(ctx, account)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get stake account %s: %w", account, err)
+	}
+	if !res.Value.Owner.Equals(solana.StakeProgramID) {
+		return nil, nil, fmt.Errorf("%s is not a stake account (owner: %s)", account, res.Value.Owner)
+	}
+	state, err := stake.DecodeStakeState(res.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode stake account %s: %w", account, err)
+	}
+	return res.Value, state, nil
+}
+
+// stakeAuthority returns the authority of the "--authority" flag of the command (prefix is its viper prefix),
+// or else the staker (or withdrawer) of the stake account.
+func stakeAuthority(prefix string, state *stake.StakeState, withdrawer bool) (solana.PublicKey, error) {
+	if authority := viper.GetString(prefix + "-cmd-authority"); authority != "" {
+		pubkey, err := solana.PublicKeyFromBase58(authority)
+		if err != nil {
+			return solana.PublicKey{}, fmt.Errorf("invalid authority %q: %w", authority, err)
+		}
+		return pubkey, nil
+	}
+	if state.Meta == nil {
+		return solana.PublicKey{}, fmt.Errorf("the stake account is %s", state.Type)
+	}
+	if withdrawer {
+		return *state.Meta.Authorized.Withdrawer, nil
+	}
+	return *state.Meta.Authorized.Staker, nil
+}
+
+// stakeAccountWithSeed derives the address of a stake account from a base account and a seed.
+func stakeAccountWithSeed(base solana.PublicKey, seed string) (solana.PublicKey, error) {
+	if seed == "" {
+		return solana.PublicKey{}, fmt.Errorf("the seed of the stake account is required (--seed)")
+	}
+	account, err := solana.CreateWithSeed(base, seed, solana.StakeProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("unable to derive stake account with seed %q: %w", seed, err)
+	}
+	return account, nil
+}
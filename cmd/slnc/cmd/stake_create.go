@@ -0,0 +1,120 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var stakeCreateCmd = &cobra.Command{
+	Use:   "create {funder} {amount}",
+	Short: "Create a stake account, derived from the funder and a seed",
+	Long: `Create a stake account, derived from the funder and a seed.
+
+The stake account is funded with the amount (in SOL) plus the rent-exempt reserve,
+and is optionally delegated right away (--vote). The staker and withdrawer
+authorities default to the funder, whose private key must be in the vault.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		opts, err := getTransactionOptions("stake-create")
+		if err != nil {
+			return err
+		}
+
+		funder, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid funder address %q: %w", args[0], err)
+		}
+		amount, err := parseUIAmount(args[1], solDecimals)
+		if err != nil {
+			return err
+		}
+		seed := viper.GetString("stake-create-cmd-seed")
+		stakeAccount, err := stakeAccountWithSeed(funder, seed)
+		if err != nil {
+			return err
+		}
+
+		staker, withdrawer := funder, funder
+		for flag, authority := range map[string]*solana.PublicKey{"staker": &staker, "withdrawer": &withdrawer} {
+			if value := viper.GetString("stake-create-cmd-" + flag); value != "" {
+				if *authority, err = solana.PublicKeyFromBase58(value); err != nil {
+					return fmt.Errorf("invalid %s %q: %w", flag, value, err)
+				}
+			}
+		}
+
+		client := getClient()
+		if _, err := client.GetAccountInfo(ctx, stakeAccount); err == nil {
+			return fmt.Errorf("stake account %s (seed %q) already exists", stakeAccount, seed)
+		} else if !errors.Is(err, rpc.ErrNotFound) {
+			return fmt.Errorf("unable to check stake account %s: %w", stakeAccount, err)
+		}
+		rent, err := client.GetMinimumBalanceForRentExemption(ctx, stake.StakeAccountSize, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve rent-exempt reserve: %w", err)
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, funder, "funder"); err != nil {
+			return err
+		}
+
+		instructions := []solana.Instruction{
+			system.NewCreateAccountWithSeedInstruction(
+				funder,
+				seed,
+				amount+rent,
+				stake.StakeAccountSize,
+				solana.StakeProgramID,
+				funder,
+				stakeAccount,
+				funder,
+			).Build(),
+			stake.NewInitializeInstruction(staker, withdrawer, stakeAccount).Build(),
+		}
+		if value := viper.GetString("stake-create-cmd-vote"); value != "" {
+			voteAccount, err := solana.PublicKeyFromBase58(value)
+			if err != nil {
+				return fmt.Errorf("invalid vote account %q: %w", value, err)
+			}
+			if err := requireVaultKey(v, staker, "staker"); err != nil {
+				return err
+			}
+			instructions = append(instructions, stake.NewDelegateStakeInstruction(voteAccount, staker, stakeAccount).Build())
+		}
+
+		fmt.Printf("Creating stake account %s (seed %q) with %s SOL\n", stakeAccount, seed, formatUIAmount(amount, solDecimals))
+		return signAndSend(ctx, client, v, opts, funder, instructions)
+	},
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeCreateCmd)
+	addTransactionFlags(stakeCreateCmd)
+	stakeCreateCmd.Flags().String("seed", "", "Seed of the stake account address, derived from the funder (required)")
+	stakeCreateCmd.Flags().String("staker", "", "Stake authority; defaults to the funder")
+	stakeCreateCmd.Flags().String("withdrawer", "", "Withdraw authority; defaults to the funder")
+	stakeCreateCmd.Flags().String("vote", "", "Vote account to delegate the stake to")
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/spf13/cobra"
+)
+
+var stakeDeactivateCmd = &cobra.Command{
+	Use:   "deactivate {stake_account}",
+	Short: "Deactivate the delegated stake of a stake account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		opts, err := getTransactionOptions("stake-deactivate")
+		if err != nil {
+			return err
+		}
+
+		stakeAccount, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid stake account %q: %w", args[0], err)
+		}
+
+		client := getClient()
+		_, state, err := getStakeAccount(ctx, client, stakeAccount)
+		if err != nil {
+			return err
+		}
+		if state.Type != stake.StakeStateStake {
+			return fmt.Errorf("stake account %s is not delegated (%s)", stakeAccount, state.Type)
+		}
+		authority, err := stakeAuthority("stake-deactivate", state, false)
+		if err != nil {
+			return err
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, authority, "stake authority"); err != nil {
+			return err
+		}
+
+		fmt.Printf("Deactivating stake account %s\n", stakeAccount)
+		return signAndSend(ctx, client, v, opts, authority, []solana.Instruction{
+			stake.NewDeactivateInstruction(stakeAccount, authority).Build(),
+		})
+	},
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeDeactivateCmd)
+	addTransactionFlags(stakeDeactivateCmd)
+	stakeDeactivateCmd.Flags().String("authority", "", "Stake authority; defaults to the staker of the stake account")
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/spf13/cobra"
+)
+
+var stakeDelegateCmd = &cobra.Command{
+	Use:   "delegate {stake_account} {vote_account}",
+	Short: "Delegate a stake account to a validator",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		opts, err := getTransactionOptions("stake-delegate")
+		if err != nil {
+			return err
+		}
+
+		stakeAccount, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid stake account %q: %w", args[0], err)
+		}
+		voteAccount, err := solana.PublicKeyFromBase58(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid vote account %q: %w", args[1], err)
+		}
+
+		client := getClient()
+		_, state, err := getStakeAccount(ctx, client, stakeAccount)
+		if err != nil {
+			return err
+		}
+		authority, err := stakeAuthority("stake-delegate", state, false)
+		if err != nil {
+			return err
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, authority, "stake authority"); err != nil {
+			return err
+		}
+
+		fmt.Printf("Delegating stake account %s to %s\n", stakeAccount, voteAccount)
+		return signAndSend(ctx, client, v, opts, authority, []solana.Instruction{
+			stake.NewDelegateStakeInstruction(voteAccount, authority, stakeAccount).Build(),
+		})
+	},
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeDelegateCmd)
+	addTransactionFlags(stakeDelegateCmd)
+	stakeDelegateCmd.Flags().String("authority", "", "Stake authority; defaults to the staker of the stake account")
+}
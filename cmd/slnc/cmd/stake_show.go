@@ -0,0 +1,162 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+)
+
+// The feature account of reduce_stake_warmup_cooldown, which lowers the warmup/cooldown rate of the stake.
+var reduceStakeWarmupCooldownFeature = solana.MustPublicKeyFromBase58("GwtDQBghCTBgmX2cpEGNPxTEBUTQRaDMGTr5qychdGMj")
+
+var stakeShowCmd = &cobra.Command{
+	Use:   "show {stake_account}",
+	Short: "Show the state of a stake account, and the activation of its stake",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		stakeAccount, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid stake account %q: %w", args[0], err)
+		}
+
+		client := getClient()
+		account, state, err := getStakeAccount(ctx, client, stakeAccount)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Stake account:", stakeAccount)
+		fmt.Println("State:", state.Type)
+		fmt.Println("Balance:", formatUIAmount(account.Lamports, solDecimals), "SOL")
+		if state.Meta == nil {
+			return nil
+		}
+		meta := state.Meta
+		fmt.Println("Rent-exempt reserve:", formatUIAmount(meta.RentExemptReserve, solDecimals), "SOL")
+		fmt.Println("Staker:", meta.Authorized.Staker)
+		fmt.Println("Withdrawer:", meta.Authorized.Withdrawer)
+		if lockup := meta.Lockup; lockup.UnixTimestamp != nil && *lockup.UnixTimestamp > 0 || lockup.Epoch != nil && *lockup.Epoch > 0 {
+			fmt.Printf("Lockup: until epoch %d, %s (custodian %s)\n", *lockup.Epoch, time.Unix(*lockup.UnixTimestamp, 0).UTC(), lockup.Custodian)
+		}
+		if state.Stake == nil {
+			return nil
+		}
+
+		delegation := state.Stake.Delegation
+		fmt.Println("Delegated to:", delegation.VoterPubkey)
+		fmt.Println("Delegated stake:", formatUIAmount(delegation.Stake, solDecimals), "SOL")
+		if delegation.ActivationEpoch == math.MaxUint64 {
+			fmt.Println("Activation epoch: bootstrap")
+		} else {
+			fmt.Println("Activation epoch:", delegation.ActivationEpoch)
+		}
+		if delegation.DeactivationEpoch != math.MaxUint64 {
+			fmt.Println("Deactivation epoch:", delegation.DeactivationEpoch)
+		}
+		fmt.Println("Credits observed:", state.Stake.CreditsObserved)
+
+		activation, err := client.GetStakeActivation(ctx, stakeAccount, rpc.CommitmentConfirmed, nil)
+		if err != nil {
+			// getStakeActivation was removed from recent nodes: compute it from the stake history.
+			if activation, err = computeStakeActivation(ctx, client, account, state); err != nil {
+				return err
+			}
+		}
+		fmt.Println("Activation state:", activation.State)
+		fmt.Println("Active stake:", formatUIAmount(activation.Active, solDecimals), "SOL")
+		fmt.Println("Inactive stake:", formatUIAmount(activation.Inactive, solDecimals), "SOL")
+		return nil
+	},
+}
+
+// computeStakeActivation computes the activation of a delegated stake account at the current epoch,
+// from the stake history sysvar, like getStakeActivation.
+func computeStakeActivation(ctx context.Context, client *rpc.Client, account *rpc.Account, state *stake.StakeState) (*rpc.GetStakeActivationResult, error) {
+	epochInfo, err := client.GetEpochInfo(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get epoch info: %w", err)
+	}
+	historyAccount, err := client.GetAccountInfo(ctx, solana.SysVarStakeHistoryPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get stake history: %w", err)
+	}
+	history, err := stake.DecodeStakeHistory(historyAccount.Value.Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode stake history: %w", err)
+	}
+	newRateActivationEpoch, err := getNewRateActivationEpoch(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	status := state.Stake.Delegation.ActivationStatus(epochInfo.Epoch, history, newRateActivationEpoch)
+	return &rpc.GetStakeActivationResult{
+		State:    rpc.ActivationStateType(status.State()),
+		Active:   status.Effective,
+		Inactive: status.Inactive(account.Lamports, state.Meta.RentExemptReserve),
+	}, nil
+}
+
+// getNewRateActivationEpoch returns the epoch at which the reduce_stake_warmup_cooldown
+// feature was activated, or nil if it is not active.
+func getNewRateActivationEpoch(ctx context.Context, client *rpc.Client) (*uint64, error) {
+	feature, err := client.GetAccountInfo(ctx, reduceStakeWarmupCooldownFeature)
+	if err == rpc.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get feature %s: %w", reduceStakeWarmupCooldownFeature, err)
+	}
+	// The feature account holds an Option<u64>: the slot at which it was activated.
+	data := feature.Value.Data.GetBinary()
+	if len(data) < 9 || data[0] != 1 {
+		return nil, nil
+	}
+	slot := binary.LittleEndian.Uint64(data[1:9])
+
+	schedule, err := client.GetEpochSchedule(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get epoch schedule: %w", err)
+	}
+	epoch := epochOfSlot(schedule, slot)
+	return &epoch, nil
+}
+
+// The number of slots of the first epoch, when the epochs warm up.
+const minimumSlotsPerEpoch = 32
+
+// epochOfSlot returns the epoch of a slot, according to the epoch schedule.
+func epochOfSlot(schedule *rpc.GetEpochScheduleResult, slot uint64) uint64 {
+	if schedule.Warmup && slot < schedule.FirstNormalSlot {
+		// The epochs double in length until the first normal epoch.
+		return uint64(bits.Len64(slot+minimumSlotsPerEpoch)) - uint64(bits.TrailingZeros64(minimumSlotsPerEpoch)) - 1
+	}
+	return schedule.FirstNormalEpoch + (slot-schedule.FirstNormalSlot)/schedule.SlotsPerEpoch
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeShowCmd)
+}
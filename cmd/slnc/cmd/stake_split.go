@@ -0,0 +1,104 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var stakeSplitCmd = &cobra.Command{
+	Use:   "split {stake_account} {amount}",
+	Short: "Split an amount of stake into a new stake account, derived from the stake authority and a seed",
+	Long: `Split an amount of stake (in SOL) into a new stake account, derived from
+the stake authority and a seed.
+
+The rent-exempt reserve of the new stake account is paid by the fee payer.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		opts, err := getTransactionOptions("stake-split")
+		if err != nil {
+			return err
+		}
+
+		stakeAccount, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid stake account %q: %w", args[0], err)
+		}
+		amount, err := parseUIAmount(args[1], solDecimals)
+		if err != nil {
+			return err
+		}
+
+		client := getClient()
+		_, state, err := getStakeAccount(ctx, client, stakeAccount)
+		if err != nil {
+			return err
+		}
+		authority, err := stakeAuthority("stake-split", state, false)
+		if err != nil {
+			return err
+		}
+		seed := viper.GetString("stake-split-cmd-seed")
+		newStakeAccount, err := stakeAccountWithSeed(authority, seed)
+		if err != nil {
+			return err
+		}
+		if _, err := client.GetAccountInfo(ctx, newStakeAccount); err == nil {
+			return fmt.Errorf("stake account %s (seed %q) already exists", newStakeAccount, seed)
+		} else if !errors.Is(err, rpc.ErrNotFound) {
+			return fmt.Errorf("unable to check stake account %s: %w", newStakeAccount, err)
+		}
+		rent, err := client.GetMinimumBalanceForRentExemption(ctx, stake.StakeAccountSize, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve rent-exempt reserve: %w", err)
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, authority, "stake authority"); err != nil {
+			return err
+		}
+		payer := opts.payer(authority)
+
+		fmt.Printf("Splitting %s SOL from stake account %s into %s (seed %q)\n", formatUIAmount(amount, solDecimals), stakeAccount, newStakeAccount, seed)
+		return signAndSend(ctx, client, v, opts, authority, []solana.Instruction{
+			system.NewTransferInstruction(rent, payer, newStakeAccount).Build(),
+			system.NewAllocateWithSeedInstruction(
+				authority,
+				seed,
+				stake.StakeAccountSize,
+				solana.StakeProgramID,
+				newStakeAccount,
+				authority,
+			).Build(),
+			stake.NewSplitInstruction(amount, stakeAccount, newStakeAccount, authority).Build(),
+		})
+	},
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeSplitCmd)
+	addTransactionFlags(stakeSplitCmd)
+	stakeSplitCmd.Flags().String("seed", "", "Seed of the new stake account address, derived from the stake authority (required)")
+	stakeSplitCmd.Flags().String("authority", "", "Stake authority; defaults to the staker of the stake account")
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/spf13/cobra"
+)
+
+var stakeWithdrawCmd = &cobra.Command{
+	Use:   "withdraw {stake_account} {recipient} {amount|ALL}",
+	Short: "Withdraw unstaked lamports from a stake account",
+	Long: `Withdraw unstaked lamports from a stake account.
+
+The amount is in SOL; "ALL" withdraws the whole balance, which closes the
+stake account (its stake must be inactive).`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		opts, err := getTransactionOptions("stake-withdraw")
+		if err != nil {
+			return err
+		}
+
+		stakeAccount, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid stake account %q: %w", args[0], err)
+		}
+		recipient, err := solana.PublicKeyFromBase58(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", args[1], err)
+		}
+
+		client := getClient()
+		account, state, err := getStakeAccount(ctx, client, stakeAccount)
+		if err != nil {
+			return err
+		}
+		amount := account.Lamports
+		if !strings.EqualFold(args[2], "ALL") {
+			if amount, err = parseUIAmount(args[2], solDecimals); err != nil {
+				return err
+			}
+		}
+		authority, err := stakeAuthority("stake-withdraw", state, true)
+		if err != nil {
+			return err
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, authority, "withdraw authority"); err != nil {
+			return err
+		}
+
+		fmt.Printf("Withdrawing %s SOL from stake account %s to %s\n", formatUIAmount(amount, solDecimals), stakeAccount, recipient)
+		return signAndSend(ctx, client, v, opts, authority, []solana.Instruction{
+			stake.NewWithdrawInstruction(amount, stakeAccount, recipient, authority).Build(),
+		})
+	},
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeWithdrawCmd)
+	addTransactionFlags(stakeWithdrawCmd)
+	stakeWithdrawCmd.Flags().String("authority", "", "Withdraw authority; defaults to the withdrawer of the stake account")
+}
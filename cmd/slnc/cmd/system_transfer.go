@@ -18,34 +18,63 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/spf13/cobra"
 )
 
 var systemTransferCmd = &cobra.Command{
 	Use:   "transfer {from} {to} {amount}",
 	Short: "Create and sign a native SOL token transfer",
-	Args:  cobra.ExactArgs(3),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		client := getClient()
-		// v := mustGetWallet()
-		ctx := context.Background()
+	Long: `Create and sign a native SOL token transfer.
 
-		from := args[0]
-		to := args[1]
-		amount := args[2]
+The amount is in SOL (e.g. 1.5); the private key of the sender
+(and of the fee payer) must be in the vault.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := getTransactionOptions("system-transfer")
+		if err != nil {
+			return err
+		}
 
-		fmt.Println(from, to, amount)
+		from, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid sender address %q: %w", args[0], err)
+		}
+		to, err := solana.PublicKeyFromBase58(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid recipient address %q: %w", args[1], err)
+		}
+		lamports, err := parseUIAmount(args[2], solDecimals)
+		if err != nil {
+			return err
+		}
+		if lamports == 0 {
+			return fmt.Errorf("amount must be greater than zero")
+		}
 
-		_ = client
-		_ = ctx
+		v := mustGetWallet()
+		if err := requireVaultKey(v, from, "sender"); err != nil {
+			return err
+		}
 
-		return nil
+		fmt.Printf("Transferring %s SOL (%d lamports) from %s to %s\n", formatUIAmount(lamports, solDecimals), lamports, from, to)
+		return signAndSend(
+			cmd.Context(),
+			getClient(),
+			v,
+			opts,
+			from,
+			[]solana.Instruction{
+				system.NewTransferInstruction(lamports, from, to).Build(),
+			},
+		)
 	},
 }
 
 func init() {
 	systemCmd.AddCommand(systemTransferCmd)
+	addTransactionFlags(systemTransferCmd)
 }
@@ -19,32 +19,181 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var tokenTransferCmd = &cobra.Command{
 	Use:   "transfer {from} {to} {amount}",
 	Short: "Create and sign a token transfer transaction",
-	Args:  cobra.ExactArgs(3),
+	Long: `Create and sign a token transfer transaction.
+
+The tokens are sent from the associated token account of the sender
+(unless --source is set) to the recipient, which can be a wallet or
+a token account. The associated token account of a recipient wallet is
+created if needed. The amount is in token units (e.g. 1.5), using the
+decimals of the mint; the private key of the sender (and of the fee payer)
+must be in the vault.`,
+	Args: cobra.ExactArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		opts, err := getTransactionOptions("token-transfer")
+		if err != nil {
+			return err
+		}
+
+		mintStr := viper.GetString("token-transfer-cmd-mint")
+		if mintStr == "" {
+			return fmt.Errorf("the token mint is required (--mint)")
+		}
+		mint, err := solana.PublicKeyFromBase58(mintStr)
+		if err != nil {
+			return fmt.Errorf("invalid mint address %q: %w", mintStr, err)
+		}
+		from, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid sender address %q: %w", args[0], err)
+		}
+		to, err := solana.PublicKeyFromBase58(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid recipient address %q: %w", args[1], err)
+		}
+
 		client := getClient()
-		ctx := context.Background()
 
-		from := args[0]
-		to := args[1]
-		amount := args[2]
+		mintAccount, err := client.GetAccountInfo(ctx, mint)
+		if err != nil {
+			return fmt.Errorf("couldn't get mint %s: %w", mint, err)
+		}
+		tokenProgramID := mintAccount.Value.Owner
+		if !tokenProgramID.Equals(solana.TokenProgramID) && !tokenProgramID.Equals(solana.Token2022ProgramID) {
+			return fmt.Errorf("%s is not a token mint (owner: %s)", mint, tokenProgramID)
+		}
+		var mintData token.Mint
+		if err := mintData.Decode(mintAccount.Value.Data.GetBinary()); err != nil {
+			return fmt.Errorf("unable to decode mint %s: %w", mint, err)
+		}
+
+		amount, err := parseUIAmount(args[2], mintData.Decimals)
+		if err != nil {
+			return err
+		}
+		if amount == 0 {
+			return fmt.Errorf("amount must be greater than zero")
+		}
+
+		source, _, err := solana.FindAssociatedTokenAddress(from, mint, tokenProgramID)
+		if err != nil {
+			return fmt.Errorf("unable to derive the sender token account: %w", err)
+		}
+		if sourceStr := viper.GetString("token-transfer-cmd-source"); sourceStr != "" {
+			if source, err = solana.PublicKeyFromBase58(sourceStr); err != nil {
+				return fmt.Errorf("invalid source token account %q: %w", sourceStr, err)
+			}
+		}
+
+		v := mustGetWallet()
+		if err := requireVaultKey(v, from, "sender"); err != nil {
+			return err
+		}
 
-		fmt.Println(from, to, amount)
+		var instructions []solana.Instruction
+		destination, createDestination, err := resolveTokenDestination(ctx, client, to, mint, tokenProgramID)
+		if err != nil {
+			return err
+		}
+		if createDestination {
+			fmt.Printf("Creating associated token account %s for %s\n", destination, to)
+			instructions = append(instructions, associatedtokenaccount.NewCreateIdempotentInstruction(
+				opts.payer(from),
+				to,
+				mint,
+				tokenProgramID,
+			).Build())
+		}
 
-		_ = client
-		_ = ctx
+		transfer, err := withProgramID(tokenProgramID, token.NewTransferCheckedInstruction(
+			amount,
+			mintData.Decimals,
+			source,
+			mint,
+			destination,
+			from,
+			nil,
+		).Build())
+		if err != nil {
+			return fmt.Errorf("unable to craft transfer instruction: %w", err)
+		}
+		instructions = append(instructions, transfer)
 
-		return nil
+		fmt.Printf("Transferring %s tokens of %s from %s to %s\n", formatUIAmount(amount, mintData.Decimals), mint, source, destination)
+		return signAndSend(ctx, client, v, opts, from, instructions)
 	},
 }
 
+// resolveTokenDestination returns the token account to send the tokens to:
+// the recipient itself if it is a token account, or else its associated token account
+// (and whether it must be created).
+func resolveTokenDestination(
+	ctx context.Context,
+	client *rpc.Client,
+	recipient solana.PublicKey,
+	mint solana.PublicKey,
+	tokenProgramID solana.PublicKey,
+) (destination solana.PublicKey, create bool, err error) {
+	recipientAccount, err := client.GetAccountInfo(ctx, recipient)
+	if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+		return destination, false, fmt.Errorf("couldn't get recipient %s: %w", recipient, err)
+	}
+	if err == nil && recipientAccount.Value.Owner.Equals(tokenProgramID) {
+		var tokenAccount token.Account
+		if err := bin.NewBinDecoder(recipientAccount.Value.Data.GetBinary()).Decode(&tokenAccount); err != nil {
+			return destination, false, fmt.Errorf("unable to decode token account %s: %w", recipient, err)
+		}
+		if !tokenAccount.Mint.Equals(mint) {
+			return destination, false, fmt.Errorf("token account %s is for mint %s, not %s", recipient, tokenAccount.Mint, mint)
+		}
+		return recipient, false, nil
+	}
+
+	destination, _, err = solana.FindAssociatedTokenAddress(recipient, mint, tokenProgramID)
+	if err != nil {
+		return destination, false, fmt.Errorf("unable to derive the recipient token account: %w", err)
+	}
+	_, err = client.GetAccountInfo(ctx, destination)
+	switch {
+	case errors.Is(err, rpc.ErrNotFound):
+		return destination, true, nil
+	case err != nil:
+		return destination, false, fmt.Errorf("couldn't get recipient token account %s: %w", destination, err)
+	}
+	return destination, false, nil
+}
+
+// withProgramID returns the instruction for another program with the same interface
+// (e.g. a token instruction for Token-2022).
+func withProgramID(programID solana.PublicKey, inst solana.Instruction) (solana.Instruction, error) {
+	if inst.ProgramID().Equals(programID) {
+		return inst, nil
+	}
+	data, err := inst.Data()
+	if err != nil {
+		return nil, err
+	}
+	return solana.NewInstruction(programID, inst.Accounts(), data), nil
+}
+
 func init() {
 	tokenCmd.AddCommand(tokenTransferCmd)
+	addTransactionFlags(tokenTransferCmd)
+	tokenTransferCmd.Flags().String("mint", "", "Mint of the token to transfer")
+	tokenTransferCmd.Flags().String("source", "", "Token account to send from; defaults to the associated token account of the sender")
 }
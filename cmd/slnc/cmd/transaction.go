@@ -0,0 +1,253 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+	confirm "github.com/gagliardetto/solana-go/rpc/sendAndConfirmTransaction"
+	"github.com/gagliardetto/solana-go/vault"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// The number of decimals of SOL amounts.
+const solDecimals = 9
+
+// addTransactionFlags adds the flags shared by the commands that send a transaction.
+func addTransactionFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Simulate the transaction instead of sending it")
+	cmd.Flags().Bool("sign-only", false, "Sign the transaction and print it (base64), without sending it")
+	cmd.Flags().String("fee-payer", "", "Fee payer (must be in the vault); defaults to the sender")
+	cmd.Flags().Uint64("compute-unit-price", 0, "Priority fee, in micro-lamports per compute unit")
+	cmd.Flags().Uint32("compute-unit-limit", 0, "Compute unit limit of the transaction (0 for the default)")
+	cmd.Flags().String("commitment", string(rpc.CommitmentConfirmed), "Commitment to wait for: processed, confirmed or finalized")
+}
+
+type transactionOptions struct {
+	DryRun           bool
+	SignOnly         bool
+	FeePayer         *solana.PublicKey
+	ComputeUnitPrice uint64
+	ComputeUnitLimit uint32
+	Commitment       rpc.CommitmentType
+}
+
+// getTransactionOptions reads the flags added by addTransactionFlags;
+// prefix is the viper prefix of the command (e.g. "system-transfer").
+func getTransactionOptions(prefix string) (*transactionOptions, error) {
+	opts := &transactionOptions{
+		DryRun:           viper.GetBool(prefix + "-cmd-dry-run"),
+		SignOnly:         viper.GetBool(prefix + "-cmd-sign-only"),
+		ComputeUnitPrice: viper.GetUint64(prefix + "-cmd-compute-unit-price"),
+		ComputeUnitLimit: viper.GetUint32(prefix + "-cmd-compute-unit-limit"),
+		Commitment:       rpc.CommitmentType(viper.GetString(prefix + "-cmd-commitment")),
+	}
+	if opts.DryRun && opts.SignOnly {
+		return nil, fmt.Errorf("--dry-run and --sign-only are mutually exclusive")
+	}
+	switch opts.Commitment {
+	case rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+	default:
+		return nil, fmt.Errorf("invalid commitment %q", opts.Commitment)
+	}
+	if feePayer := viper.GetString(prefix + "-cmd-fee-payer"); feePayer != "" {
+		pubkey, err := solana.PublicKeyFromBase58(feePayer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee payer %q: %w", feePayer, err)
+		}
+		opts.FeePayer = &pubkey
+	}
+	return opts, nil
+}
+
+// payer returns the fee payer: the one of the --fee-payer flag, or the sender.
+func (opts *transactionOptions) payer(sender solana.PublicKey) solana.PublicKey {
+	if opts.FeePayer != nil {
+		return *opts.FeePayer
+	}
+	return sender
+}
+
+// vaultSigner returns a private key getter for the keys of the vault
+// (and the extra keys, e.g. of a new account).
+func vaultSigner(v *vault.Vault, extra ...solana.PrivateKey) func(key solana.PublicKey) *solana.PrivateKey {
+	return func(key solana.PublicKey) *solana.PrivateKey {
+		for _, keys := range [][]solana.PrivateKey{extra, v.KeyBag} {
+			for _, k := range keys {
+				if k.PublicKey() == key {
+					k := k
+					return &k
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// requireVaultKey checks that the private key of the account is in the vault.
+func requireVaultKey(v *vault.Vault, account solana.PublicKey, role string) error {
+	if vaultSigner(v)(account) == nil {
+		return fmt.Errorf("%s %s must be present in the vault", role, account)
+	}
+	return nil
+}
+
+// signAndSend builds a transaction with the instructions (prefixed with the
+// compute budget ones), signs it with the vault keys, and then simulates it,
+// prints it, or sends it and waits for the confirmation, depending on the options.
+func signAndSend(
+	ctx context.Context,
+	client *rpc.Client,
+	v *vault.Vault,
+	opts *transactionOptions,
+	payer solana.PublicKey,
+	instructions []solana.Instruction,
+	extraSigners ...solana.PrivateKey,
+) error {
+	payer = opts.payer(payer)
+	if err := requireVaultKey(v, payer, "fee payer"); err != nil {
+		return err
+	}
+
+	var budget []solana.Instruction
+	if opts.ComputeUnitLimit > 0 {
+		budget = append(budget, computebudget.NewSetComputeUnitLimitInstruction(opts.ComputeUnitLimit).Build())
+	}
+	if opts.ComputeUnitPrice > 0 {
+		budget = append(budget, computebudget.NewSetComputeUnitPriceInstruction(opts.ComputeUnitPrice).Build())
+	}
+	instructions = append(budget, instructions...)
+
+	latest, err := client.GetLatestBlockhash(ctx, opts.Commitment)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve latest blockhash: %w", err)
+	}
+
+	trx, err := solana.NewTransaction(instructions, latest.Value.Blockhash, solana.TransactionPayer(payer))
+	if err != nil {
+		return fmt.Errorf("unable to craft transaction: %w", err)
+	}
+	if _, err := trx.Sign(vaultSigner(v, extraSigners...)); err != nil {
+		return fmt.Errorf("unable to sign transaction: %w", err)
+	}
+
+	switch {
+	case opts.SignOnly:
+		encoded, err := trx.ToBase64()
+		if err != nil {
+			return fmt.Errorf("unable to encode transaction: %w", err)
+		}
+		fmt.Println(encoded)
+		return nil
+	case opts.DryRun:
+		return simulate(ctx, client, trx, &rpc.SimulateTransactionOpts{
+			SigVerify:  true,
+			Commitment: opts.Commitment,
+		})
+	}
+
+	res, err := confirm.SendAndConfirmTransactionWithRebroadcast(ctx, client, nil, trx, confirm.RebroadcastOpts{
+		Commitment:           opts.Commitment,
+		PreflightCommitment:  opts.Commitment,
+		LastValidBlockHeight: latest.Value.LastValidBlockHeight,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to send transaction: %w", err)
+	}
+	return printConfirmation(res, opts.Commitment)
+}
+
+// printConfirmation prints the outcome of a sent transaction,
+// and returns an error if it failed or expired.
+func printConfirmation(res *confirm.ConfirmationResult, commitment rpc.CommitmentType) error {
+	fmt.Println("Transaction signature:", res.Signature)
+	switch res.Outcome {
+	case confirm.OutcomeConfirmed:
+		fmt.Printf("Transaction %s in slot %d\n", commitment, res.Slot)
+		return nil
+	case confirm.OutcomeFailed:
+		return fmt.Errorf("transaction failed in slot %d: %v", res.Slot, res.Err)
+	default:
+		return fmt.Errorf("transaction expired before being processed")
+	}
+}
+
+// simulate simulates the transaction, and prints the logs and the consumed compute units.
+func simulate(ctx context.Context, client *rpc.Client, trx *solana.Transaction, opts *rpc.SimulateTransactionOpts) error {
+	res, err := client.SimulateTransactionWithOpts(ctx, trx, opts)
+	if err != nil {
+		return fmt.Errorf("unable to simulate transaction: %w", err)
+	}
+	for _, log := range res.Value.Logs {
+		fmt.Println(log)
+	}
+	if res.Value.UnitsConsumed != nil {
+		fmt.Println("Compute units consumed:", *res.Value.UnitsConsumed)
+	}
+	if res.Value.Err != nil {
+		return fmt.Errorf("simulation failed: %v", res.Value.Err)
+	}
+	fmt.Println("Simulation succeeded")
+	return nil
+}
+
+// parseUIAmount parses a decimal amount (e.g. "1.5") into base units
+// with the provided number of decimals (e.g. 1500000000 for 9 decimals).
+func parseUIAmount(amount string, decimals uint8) (uint64, error) {
+	whole, frac, hasFrac := strings.Cut(amount, ".")
+	if whole == "" && frac == "" || hasFrac && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", amount)
+		}
+	}
+	if len(frac) > int(decimals) {
+		return 0, fmt.Errorf("invalid amount %q: at most %d decimals", amount, decimals)
+	}
+	units, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", int(decimals)-len(frac)), 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	if !units.IsUint64() {
+		return 0, fmt.Errorf("amount %q is too large", amount)
+	}
+	return units.Uint64(), nil
+}
+
+// formatUIAmount formats an amount in base units as a decimal amount.
+func formatUIAmount(units uint64, decimals uint8) string {
+	s := strconv.FormatUint(units, 10)
+	if decimals == 0 {
+		return s
+	}
+	if len(s) <= int(decimals) {
+		s = strings.Repeat("0", int(decimals)-len(s)+1) + s
+	}
+	whole, frac := s[:len(s)-int(decimals)], strings.TrimRight(s[len(s)-int(decimals):], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	_ "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	_ "github.com/gagliardetto/solana-go/programs/compute-budget"
+	_ "github.com/gagliardetto/solana-go/programs/memo"
+	_ "github.com/gagliardetto/solana-go/programs/serum"
+	_ "github.com/gagliardetto/solana-go/programs/stake"
+	_ "github.com/gagliardetto/solana-go/programs/system"
+	_ "github.com/gagliardetto/solana-go/programs/token"
+	_ "github.com/gagliardetto/solana-go/programs/tokenregistry"
+	_ "github.com/gagliardetto/solana-go/programs/vote"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+)
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Decode, simulate and send transactions",
+}
+
+func init() {
+	RootCmd.AddCommand(txCmd)
+}
+
+// readTransactionInput returns the encoded transaction from the argument,
+// which is either the encoded transaction itself, a file containing it, or "-" for stdin.
+func readTransactionInput(arg string) (string, error) {
+	if arg == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("unable to read stdin: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return "", fmt.Errorf("unable to read %q: %w", arg, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return strings.TrimSpace(arg), nil
+}
+
+// parseTransaction decodes a base64 or base58 encoded transaction.
+func parseTransaction(encoded string) (*solana.Transaction, error) {
+	tx, err := solana.TransactionFromBase64(encoded)
+	if err == nil {
+		return tx, nil
+	}
+	tx, err58 := solana.TransactionFromBase58(encoded)
+	if err58 == nil {
+		return tx, nil
+	}
+	return nil, fmt.Errorf("unable to decode transaction as base64 (%s) or base58 (%s)", err, err58)
+}
+
+// readTransaction reads and decodes the transaction of the argument (see readTransactionInput).
+func readTransaction(arg string) (*solana.Transaction, error) {
+	encoded, err := readTransactionInput(arg)
+	if err != nil {
+		return nil, err
+	}
+	return parseTransaction(encoded)
+}
+
+// resolveAddressTables fetches the address lookup tables used by the transaction,
+// and resolves its accounts.
+func resolveAddressTables(ctx context.Context, client *rpc.Client, tx *solana.Transaction) error {
+	tableIDs := tx.Message.GetAddressTableLookups().GetTableIDs()
+	if len(tableIDs) == 0 {
+		return nil
+	}
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice, len(tableIDs))
+	for _, tableID := range tableIDs {
+		state, err := addresslookuptable.GetAddressLookupTable(ctx, client, tableID)
+		if err != nil {
+			return fmt.Errorf("unable to get address lookup table %s: %w", tableID, err)
+		}
+		tables[tableID] = state.Addresses
+	}
+	if err := tx.Message.SetAddressTables(tables); err != nil {
+		return err
+	}
+	return tx.Message.ResolveLookups()
+}
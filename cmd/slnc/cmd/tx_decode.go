@@ -0,0 +1,232 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txDecodeCmd = &cobra.Command{
+	Use:   "decode {base64|base58|signature|file|-}",
+	Short: "Decode a transaction, and print its instructions",
+	Long: `Decode a transaction, and print its instructions.
+
+The transaction is either encoded (base64 or base58), in a file, read from
+stdin ("-"), or fetched by signature (along with its status and logs).
+The address lookup tables of versioned transactions are resolved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client := getClient()
+
+		encoded, err := readTransactionInput(args[0])
+		if err != nil {
+			return err
+		}
+
+		var tx *solana.Transaction
+		var result *rpc.GetTransactionResult
+		if sig, err := solana.SignatureFromBase58(encoded); err == nil {
+			tx, result, err = fetchTransaction(ctx, client, sig)
+			if err != nil {
+				return err
+			}
+		} else {
+			if tx, err = parseTransaction(encoded); err != nil {
+				return err
+			}
+			if err := resolveAddressTables(ctx, client, tx); err != nil {
+				return err
+			}
+		}
+
+		if viper.GetBool("tx-decode-cmd-json") {
+			out, err := json.MarshalIndent(newDecodedTransaction(tx, result), "", "  ")
+			if err != nil {
+				return fmt.Errorf("unable to marshal transaction: %w", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		if _, err := tx.EncodeTree(text.NewTreeEncoder(os.Stdout, text.Bold("TRANSACTION"))); err != nil {
+			return err
+		}
+		if result != nil {
+			printTransactionMeta(result)
+		}
+		return nil
+	},
+}
+
+// fetchTransaction gets a transaction by signature,
+// with its accounts resolved with the addresses loaded from lookup tables.
+func fetchTransaction(ctx context.Context, client *rpc.Client, sig solana.Signature) (*solana.Transaction, *rpc.GetTransactionResult, error) {
+	maxVersion := uint64(0)
+	result, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get transaction %s: %w", sig, err)
+	}
+	if result.Transaction == nil {
+		return nil, nil, fmt.Errorf("transaction %s not found", sig)
+	}
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode transaction %s: %w", sig, err)
+	}
+	if result.Meta != nil && tx.Message.IsVersioned() {
+		loaded := result.Meta.LoadedAddresses
+		if err := tx.Message.ResolveLookupsWith(loaded.Writable, loaded.ReadOnly); err != nil {
+			return nil, nil, fmt.Errorf("unable to resolve the loaded addresses: %w", err)
+		}
+	}
+	return tx, result, nil
+}
+
+func printTransactionMeta(result *rpc.GetTransactionResult) {
+	fmt.Println()
+	fmt.Println("Slot:", result.Slot)
+	if result.BlockTime != nil {
+		fmt.Println("Block time:", result.BlockTime.Time().UTC())
+	}
+	if result.Meta == nil {
+		return
+	}
+	if result.Meta.Err != nil {
+		fmt.Println("Status:", text.RedBG(fmt.Sprintf("failed: %v", result.Meta.Err)))
+	} else {
+		fmt.Println("Status: success")
+	}
+	fmt.Println("Fee:", result.Meta.Fee, "lamports")
+	if result.Meta.ComputeUnitsConsumed != nil {
+		fmt.Println("Compute units consumed:", *result.Meta.ComputeUnitsConsumed)
+	}
+	if len(result.Meta.LogMessages) > 0 {
+		fmt.Println("Logs:")
+		for _, log := range result.Meta.LogMessages {
+			fmt.Println(" ", log)
+		}
+	}
+}
+
+type decodedTransaction struct {
+	Signatures      []solana.Signature   `json:"signatures"`
+	Version         string               `json:"version"`
+	RecentBlockhash solana.Hash          `json:"recentBlockhash"`
+	AccountKeys     []solana.PublicKey   `json:"accountKeys"`
+	Instructions    []decodedInstruction `json:"instructions"`
+
+	// Only set for the transactions fetched by signature.
+	Slot                 *uint64     `json:"slot,omitempty"`
+	BlockTime            *int64      `json:"blockTime,omitempty"`
+	Err                  interface{} `json:"err,omitempty"`
+	Fee                  *uint64     `json:"fee,omitempty"`
+	ComputeUnitsConsumed *uint64     `json:"computeUnitsConsumed,omitempty"`
+	Logs                 []string    `json:"logs,omitempty"`
+}
+
+type decodedInstruction struct {
+	ProgramID solana.PublicKey      `json:"programId"`
+	Accounts  []*solana.AccountMeta `json:"accounts"`
+	Data      solana.Base58         `json:"data"`
+	// The instruction decoded by the registered decoder of the program, if any.
+	Name        string      `json:"name,omitempty"`
+	Params      interface{} `json:"params,omitempty"`
+	DecodeError string      `json:"decodeError,omitempty"`
+}
+
+func newDecodedTransaction(tx *solana.Transaction, result *rpc.GetTransactionResult) *decodedTransaction {
+	out := &decodedTransaction{
+		Signatures:      tx.Signatures,
+		Version:         "legacy",
+		RecentBlockhash: tx.Message.RecentBlockhash,
+		Instructions:    []decodedInstruction{},
+	}
+	if tx.Message.IsVersioned() {
+		out.Version = "0"
+	}
+	out.AccountKeys, _ = tx.Message.GetAllKeys()
+
+	for _, inst := range tx.Message.Instructions {
+		decoded := decodedInstruction{Data: solana.Base58(inst.Data)}
+		programID, err := tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
+		if err != nil {
+			decoded.DecodeError = err.Error()
+			out.Instructions = append(out.Instructions, decoded)
+			continue
+		}
+		decoded.ProgramID = programID
+		decoded.Accounts, err = inst.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			decoded.DecodeError = err.Error()
+			out.Instructions = append(out.Instructions, decoded)
+			continue
+		}
+		if impl, err := solana.DecodeInstruction(programID, decoded.Accounts, inst.Data); err != nil {
+			decoded.DecodeError = err.Error()
+		} else {
+			decoded.Name, decoded.Params = instructionImpl(impl)
+		}
+		out.Instructions = append(out.Instructions, decoded)
+	}
+
+	if result != nil {
+		out.Slot = &result.Slot
+		if result.BlockTime != nil {
+			blockTime := int64(*result.BlockTime)
+			out.BlockTime = &blockTime
+		}
+		if result.Meta != nil {
+			out.Err = result.Meta.Err
+			out.Fee = &result.Meta.Fee
+			out.ComputeUnitsConsumed = result.Meta.ComputeUnitsConsumed
+			out.Logs = result.Meta.LogMessages
+		}
+	}
+	return out
+}
+
+// instructionImpl returns the name and the parameters of a decoded instruction,
+// which is a variant of the instruction type of its program.
+func instructionImpl(decoded interface{}) (string, interface{}) {
+	v := reflect.Indirect(reflect.ValueOf(decoded))
+	if v.Kind() != reflect.Struct {
+		return "", decoded
+	}
+	impl := v.FieldByName("Impl")
+	if !impl.IsValid() || impl.IsNil() {
+		return "", decoded
+	}
+	return reflect.Indirect(impl.Elem()).Type().Name(), impl.Interface()
+}
+
+func init() {
+	txCmd.AddCommand(txDecodeCmd)
+	txDecodeCmd.Flags().Bool("json", false, "Print the decoded transaction as JSON")
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	confirm "github.com/gagliardetto/solana-go/rpc/sendAndConfirmTransaction"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txSendCmd = &cobra.Command{
+	Use:   "send {base64|base58|file|-}",
+	Short: "Send a signed transaction, and wait for its confirmation",
+	Long: `Send a signed transaction, and wait for its confirmation.
+
+The transaction is typically the output of a command run with --sign-only.
+It is re-sent until it is confirmed, or its blockhash expires.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tx, err := readTransaction(args[0])
+		if err != nil {
+			return err
+		}
+		if err := tx.VerifySignatures(); err != nil {
+			return fmt.Errorf("invalid signatures: %w", err)
+		}
+
+		commitment := rpc.CommitmentType(viper.GetString("tx-send-cmd-commitment"))
+		switch commitment {
+		case rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+		default:
+			return fmt.Errorf("invalid commitment %q", commitment)
+		}
+
+		res, err := confirm.SendAndConfirmTransactionWithRebroadcast(cmd.Context(), getClient(), nil, tx, confirm.RebroadcastOpts{
+			Commitment:          commitment,
+			SkipPreflight:       viper.GetBool("tx-send-cmd-skip-preflight"),
+			PreflightCommitment: commitment,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+		return printConfirmation(res, commitment)
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txSendCmd)
+	txSendCmd.Flags().Bool("skip-preflight", false, "Skip the preflight checks")
+	txSendCmd.Flags().String("commitment", string(rpc.CommitmentConfirmed), "Commitment to wait for: processed, confirmed or finalized")
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txSimulateCmd = &cobra.Command{
+	Use:   "simulate {base64|base58|file|-}",
+	Short: "Simulate a transaction, and print its logs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tx, err := readTransaction(args[0])
+		if err != nil {
+			return err
+		}
+		return simulate(cmd.Context(), getClient(), tx, &rpc.SimulateTransactionOpts{
+			SigVerify:              viper.GetBool("tx-simulate-cmd-sig-verify"),
+			ReplaceRecentBlockhash: viper.GetBool("tx-simulate-cmd-replace-blockhash"),
+			Commitment:             rpc.CommitmentType(viper.GetString("tx-simulate-cmd-commitment")),
+		})
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txSimulateCmd)
+	txSimulateCmd.Flags().Bool("sig-verify", false, "Verify the signatures of the transaction")
+	txSimulateCmd.Flags().Bool("replace-blockhash", false, "Replace the blockhash of the transaction with the latest one (incompatible with --sig-verify)")
+	txSimulateCmd.Flags().String("commitment", string(rpc.CommitmentConfirmed), "Commitment to simulate the transaction at")
+}
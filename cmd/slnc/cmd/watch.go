@@ -0,0 +1,324 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// The delay before reconnecting after the connection is lost;
+	// it doubles at each failed attempt, up to watchMaxReconnectDelay.
+	watchReconnectDelay    = time.Second
+	watchMaxReconnectDelay = 30 * time.Second
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream the notifications of websocket subscriptions",
+	Long: `Stream the notifications of websocket subscriptions, as text or as
+newline-delimited JSON (--json). The connection is re-established automatically
+when it is lost; the notifications sent in the meantime are missed.`,
+}
+
+func init() {
+	RootCmd.AddCommand(watchCmd)
+	watchCmd.PersistentFlags().String("ws-url", "", "Websocket endpoint; defaults to the one of the RPC endpoint")
+	watchCmd.PersistentFlags().Bool("json", false, "Print the notifications as newline-delimited JSON")
+	watchCmd.PersistentFlags().String("commitment", string(rpc.CommitmentConfirmed), "Commitment of the notifications: processed, confirmed or finalized")
+}
+
+// getWSURL returns the websocket endpoint: the one of the "--ws-url" flag,
+// or else the one of the RPC endpoint (as for a local validator, the port of ws is the RPC one plus one).
+func getWSURL() (string, error) {
+	if wsURL := viper.GetString("watch-global-ws-url"); wsURL != "" {
+		return sanitizeAPIURL(wsURL), nil
+	}
+	rpcURL := sanitizeAPIURL(viper.GetString("global-rpc-url"))
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid RPC endpoint %q: %w", rpcURL, err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	if u.Port() == "8899" {
+		u.Host = u.Hostname() + ":8900"
+	}
+	return u.String(), nil
+}
+
+func getWatchCommitment() (rpc.CommitmentType, error) {
+	commitment := rpc.CommitmentType(viper.GetString("watch-global-commitment"))
+	switch commitment {
+	case rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+		return commitment, nil
+	default:
+		return "", fmt.Errorf("invalid commitment %q", commitment)
+	}
+}
+
+// watchSubscription is implemented by the subscriptions of the ws client.
+type watchSubscription[T any] interface {
+	Recv(ctx context.Context) (T, error)
+	Unsubscribe()
+}
+
+// watch subscribes on a websocket connection, and passes the notifications to handle
+// until it returns done (or an error), or the context is done. When the connection is lost,
+// it reconnects and subscribes again, with an exponential backoff; the other errors
+// (e.g. the subscription is rejected by the node) are returned.
+func watch[T any](
+	ctx context.Context,
+	subscribe func(client *ws.Client) (watchSubscription[T], error),
+	handle func(notification T) (done bool, err error),
+) error {
+	wsURL, err := getWSURL()
+	if err != nil {
+		return err
+	}
+	header := make(http.Header)
+	for key, value := range getHTTPHeaders() {
+		header.Set(key, value)
+	}
+
+	delay := watchReconnectDelay
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			fmt.Fprintf(os.Stderr, "Reconnecting in %s\n", delay)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > watchMaxReconnectDelay {
+				delay = watchMaxReconnectDelay
+			}
+		}
+
+		client, err := ws.ConnectWithOptions(ctx, wsURL, &ws.Options{HttpHeader: header})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Unable to connect to %s: %s\n", wsURL, err)
+			continue
+		}
+		sub, err := subscribe(client)
+		if err != nil {
+			client.Close()
+			if !isConnectionError(err) {
+				return fmt.Errorf("unable to subscribe: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Unable to subscribe: %s\n", err)
+			continue
+		}
+		if attempt > 0 {
+			fmt.Fprintln(os.Stderr, "Reconnected")
+		}
+		delay = watchReconnectDelay
+
+		done, err := receive(ctx, sub, handle)
+		sub.Unsubscribe()
+		client.Close()
+		switch {
+		case done:
+			return err
+		case ctx.Err() != nil:
+			return nil
+		case !isConnectionError(err):
+			return fmt.Errorf("subscription failed: %w", err)
+		default:
+			fmt.Fprintf(os.Stderr, "Subscription lost: %s\n", err)
+		}
+	}
+}
+
+// receive passes the notifications of the subscription to handle;
+// handle errors are returned as is, with done set.
+func receive[T any](ctx context.Context, sub watchSubscription[T], handle func(T) (bool, error)) (bool, error) {
+	for {
+		notification, err := sub.Recv(ctx)
+		if err != nil {
+			return false, err
+		}
+		if done, err := handle(notification); done || err != nil {
+			return true, err
+		}
+	}
+}
+
+// isConnectionError tells whether a subscription error is caused by the websocket connection,
+// in which case subscribing again on a new connection can succeed.
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	var closeErr *websocket.CloseError
+	return errors.As(err, &netErr) ||
+		errors.As(err, &closeErr) ||
+		errors.Is(err, websocket.ErrCloseSent) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// printNotification prints a notification: the value as a JSON line with --json,
+// or else the text, prefixed with the time it was received.
+func printNotification(value interface{}, text string) error {
+	if viper.GetBool("watch-global-json") {
+		return json.NewEncoder(os.Stdout).Encode(value)
+	}
+	fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05.000"), text)
+	return nil
+}
+
+// watchedAccount is the JSON representation of an account notification.
+type watchedAccount struct {
+	Slot       uint64           `json:"slot"`
+	Pubkey     solana.PublicKey `json:"pubkey"`
+	Lamports   uint64           `json:"lamports"`
+	Owner      solana.PublicKey `json:"owner"`
+	Executable bool             `json:"executable"`
+	RentEpoch  uint64           `json:"rentEpoch"`
+	Space      int              `json:"space"`
+	Data       []byte           `json:"data"`
+	// The data decoded by a known decoder, if any.
+	Decoded     interface{} `json:"decoded,omitempty"`
+	DecodeError string      `json:"decodeError,omitempty"`
+}
+
+// printAccount prints an account notification, with its data decoded if possible.
+func printAccount(ctx context.Context, slot uint64, pubkey solana.PublicKey, account *rpc.Account) error {
+	if account == nil {
+		// The account was closed.
+		return printNotification(&watchedAccount{Slot: slot, Pubkey: pubkey}, fmt.Sprintf("slot %d: %s closed", slot, pubkey))
+	}
+	data := account.Data.GetBinary()
+	out := &watchedAccount{
+		Slot:       slot,
+		Pubkey:     pubkey,
+		Lamports:   account.Lamports,
+		Owner:      account.Owner,
+		Executable: account.Executable,
+		Space:      len(data),
+		Data:       data,
+	}
+	if account.RentEpoch != nil {
+		out.RentEpoch = account.RentEpoch.Uint64()
+	}
+	var err error
+	if out.Decoded, err = decodeAccountData(ctx, account.Owner, data); err != nil {
+		out.DecodeError = err.Error()
+	}
+
+	text := fmt.Sprintf(
+		"slot %d: %s: %s SOL, owner %s, %d bytes",
+		slot, pubkey, formatUIAmount(account.Lamports, solDecimals), account.Owner, len(data),
+	)
+	if out.Decoded != nil {
+		decoded, err := json.MarshalIndent(out.Decoded, "", "  ")
+		if err != nil {
+			return err
+		}
+		text += "\n" + string(decoded)
+	} else if out.DecodeError != "" {
+		text += "\nunable to decode data: " + out.DecodeError
+	}
+	return printNotification(out, text)
+}
+
+// decodeAccountData decodes the data of an account with the account parser registered
+// for its owner (see rpc.RegisterAccountParser), or with the decoders of the stake
+// and upgradeable loader accounts; it returns nil for the accounts of the other programs.
+func decodeAccountData(ctx context.Context, owner solana.PublicKey, data []byte) (interface{}, error) {
+	switch {
+	case owner.Equals(solana.StakeProgramID):
+		return stake.DecodeStakeState(data)
+	case owner.Equals(solana.BPFLoaderUpgradeableProgramID):
+		return bpfloader.DecodeUpgradeableLoaderState(data)
+	}
+	parsed, err := rpc.ParseAccountDataWithOpts(owner, data, getParseAccountOpts(ctx, owner, data))
+	if errors.Is(err, rpc.ErrNoAccountParser) {
+		return nil, nil
+	}
+	return parsed, err
+}
+
+var (
+	mintDecimalsMu sync.Mutex
+	// The decimals of the mints of the watched token accounts,
+	// nil for the mints that could not be fetched.
+	mintDecimals = make(map[solana.PublicKey]*uint8)
+)
+
+// getParseAccountOpts returns the decimals of the mint of a token account,
+// which are needed to render its amounts; each mint is fetched once.
+func getParseAccountOpts(ctx context.Context, owner solana.PublicKey, data []byte) *rpc.ParseAccountOpts {
+	if (!owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID)) || len(data) < token.ACCOUNT_SIZE {
+		return nil
+	}
+	mint := solana.PublicKeyFromBytes(data[:32])
+
+	mintDecimalsMu.Lock()
+	decimals, ok := mintDecimals[mint]
+	mintDecimalsMu.Unlock()
+	if !ok {
+		decimals = fetchMintDecimals(ctx, mint)
+		// A lookup interrupted by the context did not fail: it is not cached.
+		if ctx.Err() == nil {
+			mintDecimalsMu.Lock()
+			mintDecimals[mint] = decimals
+			mintDecimalsMu.Unlock()
+		}
+	}
+	return &rpc.ParseAccountOpts{TokenDecimals: decimals}
+}
+
+// fetchMintDecimals returns the decimals of a mint, or nil if it cannot be fetched.
+func fetchMintDecimals(ctx context.Context, mint solana.PublicKey) *uint8 {
+	account, err := getClient().GetAccountInfo(ctx, mint)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to get mint %s: %s\n", mint, err)
+		return nil
+	}
+	var mintData token.Mint
+	if err := bin.NewBinDecoder(account.Value.Data.GetBinary()).Decode(&mintData); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to decode mint %s: %s\n", mint, err)
+		return nil
+	}
+	return &mintData.Decimals
+}
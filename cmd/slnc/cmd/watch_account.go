@@ -0,0 +1,52 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/spf13/cobra"
+)
+
+var watchAccountCmd = &cobra.Command{
+	Use:   "account {address}",
+	Short: "Stream the changes of an account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account %q: %w", args[0], err)
+		}
+		commitment, err := getWatchCommitment()
+		if err != nil {
+			return err
+		}
+		return watch(
+			cmd.Context(),
+			func(client *ws.Client) (watchSubscription[*ws.AccountResult], error) {
+				return client.AccountSubscribeWithOpts(account, commitment, solana.EncodingBase64)
+			},
+			func(res *ws.AccountResult) (bool, error) {
+				return false, printAccount(cmd.Context(), res.Context.Slot, account, res.Value)
+			},
+		)
+	},
+}
+
+func init() {
+	watchCmd.AddCommand(watchAccountCmd)
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var watchBlockCmd = &cobra.Command{
+	Use:   "block",
+	Short: "Stream the blocks (that mention an address)",
+	Long: `Stream the blocks, or the ones that mention an address (--mentions).
+
+The block subscription is only available on the nodes started with
+--rpc-pubsub-enable-block-subscription.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commitment, err := getWatchCommitment()
+		if err != nil {
+			return err
+		}
+		if commitment == rpc.CommitmentProcessed {
+			return fmt.Errorf("the block subscription requires the confirmed or finalized commitment")
+		}
+		filter := ws.NewBlockSubscribeFilterAll()
+		if value := viper.GetString("watch-block-cmd-mentions"); value != "" {
+			pubkey, err := solana.PublicKeyFromBase58(value)
+			if err != nil {
+				return fmt.Errorf("invalid address %q: %w", value, err)
+			}
+			filter = ws.NewBlockSubscribeFilterMentionsAccountOrProgram(pubkey)
+		}
+		details := rpc.TransactionDetailsType(viper.GetString("watch-block-cmd-transaction-details"))
+		switch details {
+		case rpc.TransactionDetailsFull, rpc.TransactionDetailsSignatures, rpc.TransactionDetailsAccounts, rpc.TransactionDetailsNone:
+		default:
+			return fmt.Errorf("invalid transaction details %q", details)
+		}
+		rewards := viper.GetBool("watch-block-cmd-rewards")
+		maxVersion := uint64(0)
+		opts := &ws.BlockSubscribeOpts{
+			Commitment:                     commitment,
+			Encoding:                       solana.EncodingBase64,
+			TransactionDetails:             details,
+			Rewards:                        &rewards,
+			MaxSupportedTransactionVersion: &maxVersion,
+		}
+
+		return watch(
+			cmd.Context(),
+			func(client *ws.Client) (watchSubscription[*ws.BlockResult], error) {
+				return client.BlockSubscribe(filter, opts)
+			},
+			func(res *ws.BlockResult) (bool, error) {
+				block := res.Value.Block
+				if res.Value.Err != nil || block == nil {
+					return false, printNotification(&res.Value, fmt.Sprintf("slot %d: error: %v", res.Value.Slot, res.Value.Err))
+				}
+				text := fmt.Sprintf("slot %d: block %s (parent slot %d)", res.Value.Slot, block.Blockhash, block.ParentSlot)
+				if block.BlockTime != nil {
+					text += fmt.Sprintf(", time %s", block.BlockTime.Time().UTC())
+				}
+				switch details {
+				case rpc.TransactionDetailsSignatures:
+					text += fmt.Sprintf(", %d transactions", len(block.Signatures))
+				case rpc.TransactionDetailsFull, rpc.TransactionDetailsAccounts:
+					text += fmt.Sprintf(", %d transactions", len(block.Transactions))
+				}
+				return false, printNotification(&res.Value, text)
+			},
+		)
+	},
+}
+
+func init() {
+	watchCmd.AddCommand(watchBlockCmd)
+	watchBlockCmd.Flags().String("mentions", "", "Only the blocks with transactions that mention this address")
+	watchBlockCmd.Flags().String("transaction-details", string(rpc.TransactionDetailsSignatures), "Transaction details: full, accounts, signatures or none")
+	watchBlockCmd.Flags().Bool("rewards", false, "Include the rewards")
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var watchLogsCmd = &cobra.Command{
+	Use:   "logs [address]",
+	Short: "Stream the logs of the transactions (that mention an address)",
+	Long: `Stream the logs of the transactions that mention an address (e.g. a program),
+or of all the transactions if no address is provided (the vote transactions
+are only included with --votes).`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commitment, err := getWatchCommitment()
+		if err != nil {
+			return err
+		}
+		var mentions *solana.PublicKey
+		if len(args) > 0 {
+			pubkey, err := solana.PublicKeyFromBase58(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid address %q: %w", args[0], err)
+			}
+			mentions = &pubkey
+		}
+		filter := ws.LogsSubscribeFilterAll
+		if viper.GetBool("watch-logs-cmd-votes") {
+			filter = ws.LogsSubscribeFilterAllWithVotes
+		}
+
+		return watch(
+			cmd.Context(),
+			func(client *ws.Client) (watchSubscription[*ws.LogResult], error) {
+				if mentions != nil {
+					return client.LogsSubscribeMentions(*mentions, commitment)
+				}
+				return client.LogsSubscribe(filter, commitment)
+			},
+			func(res *ws.LogResult) (bool, error) {
+				out := &watchedLogs{
+					Slot:      res.Context.Slot,
+					Signature: res.Value.Signature,
+					Err:       res.Value.Err,
+					Logs:      res.Value.Logs,
+				}
+				status := "success"
+				if res.Value.Err != nil {
+					status = fmt.Sprintf("failed: %v", res.Value.Err)
+				}
+				var text strings.Builder
+				fmt.Fprintf(&text, "slot %d: %s (%s)", res.Context.Slot, res.Value.Signature, status)
+				for _, log := range res.Value.Logs {
+					text.WriteString("\n  " + log)
+				}
+				return false, printNotification(out, text.String())
+			},
+		)
+	},
+}
+
+type watchedLogs struct {
+	Slot      uint64           `json:"slot"`
+	Signature solana.Signature `json:"signature"`
+	Err       interface{}      `json:"err"`
+	Logs      []string         `json:"logs"`
+}
+
+func init() {
+	watchCmd.AddCommand(watchLogsCmd)
+	watchLogsCmd.Flags().Bool("votes", false, "Include the vote transactions (without address)")
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var watchProgramCmd = &cobra.Command{
+	Use:   "program {program_id}",
+	Short: "Stream the changes of the accounts owned by a program",
+	Long: `Stream the changes of the accounts owned by a program.
+
+The accounts can be filtered by size (--data-size), and by content
+(--memcmp {offset}:{base58 bytes}, which can be repeated).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		programID, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid program id %q: %w", args[0], err)
+		}
+		commitment, err := getWatchCommitment()
+		if err != nil {
+			return err
+		}
+
+		var filters []rpc.RPCFilter
+		if size := viper.GetUint64("watch-program-cmd-data-size"); size > 0 {
+			filters = append(filters, rpc.RPCFilter{DataSize: size})
+		}
+		for _, value := range viper.GetStringSlice("watch-program-cmd-memcmp") {
+			memcmp, err := parseMemcmpFilter(value)
+			if err != nil {
+				return err
+			}
+			filters = append(filters, rpc.RPCFilter{Memcmp: memcmp})
+		}
+
+		return watch(
+			cmd.Context(),
+			func(client *ws.Client) (watchSubscription[*ws.ProgramResult], error) {
+				return client.ProgramSubscribeWithOpts(programID, commitment, solana.EncodingBase64, filters)
+			},
+			func(res *ws.ProgramResult) (bool, error) {
+				return false, printAccount(cmd.Context(), res.Context.Slot, res.Value.Pubkey, res.Value.Account)
+			},
+		)
+	},
+}
+
+// parseMemcmpFilter parses a memcmp filter, as "{offset}:{base58 bytes}".
+func parseMemcmpFilter(value string) (*rpc.RPCFilterMemcmp, error) {
+	offset, encoded, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid memcmp filter %q: expected {offset}:{base58 bytes}", value)
+	}
+	off, err := strconv.ParseUint(offset, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memcmp filter offset %q: %w", offset, err)
+	}
+	data, err := base58.Decode(encoded)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid memcmp filter bytes %q: expected base58", encoded)
+	}
+	return &rpc.RPCFilterMemcmp{Offset: off, Bytes: data}, nil
+}
+
+func init() {
+	watchCmd.AddCommand(watchProgramCmd)
+	watchProgramCmd.Flags().Uint64("data-size", 0, "Only the accounts of this size")
+	watchProgramCmd.Flags().StringSlice("memcmp", nil, "Only the accounts whose data matches {offset}:{base58 bytes}")
+}
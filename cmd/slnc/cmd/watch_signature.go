@@ -0,0 +1,79 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/spf13/cobra"
+)
+
+var watchSignatureCmd = &cobra.Command{
+	Use:   "signature {signature}",
+	Short: "Wait for a transaction to reach the commitment, and print its status",
+	Long: `Wait for a transaction to reach the commitment, and print its status.
+
+The command fails if the transaction failed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sig, err := solana.SignatureFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid signature %q: %w", args[0], err)
+		}
+		commitment, err := getWatchCommitment()
+		if err != nil {
+			return err
+		}
+
+		var txErr interface{}
+		err = watch(
+			cmd.Context(),
+			func(client *ws.Client) (watchSubscription[*ws.SignatureResult], error) {
+				return client.SignatureSubscribe(sig, commitment)
+			},
+			func(res *ws.SignatureResult) (bool, error) {
+				txErr = res.Value.Err
+				status := string(commitment)
+				if txErr != nil {
+					status = fmt.Sprintf("failed: %v", txErr)
+				}
+				return true, printNotification(&watchedSignature{
+					Slot:      res.Context.Slot,
+					Signature: sig,
+					Err:       txErr,
+				}, fmt.Sprintf("slot %d: %s %s", res.Context.Slot, sig, status))
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if txErr != nil {
+			return fmt.Errorf("transaction %s failed: %v", sig, txErr)
+		}
+		return nil
+	},
+}
+
+type watchedSignature struct {
+	Slot      uint64           `json:"slot"`
+	Signature solana.Signature `json:"signature"`
+	Err       interface{}      `json:"err"`
+}
+
+func init() {
+	watchCmd.AddCommand(watchSignatureCmd)
+}
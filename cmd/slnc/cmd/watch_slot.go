@@ -0,0 +1,43 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/spf13/cobra"
+)
+
+var watchSlotCmd = &cobra.Command{
+	Use:   "slot",
+	Short: "Stream the slots processed by the node",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return watch(
+			cmd.Context(),
+			func(client *ws.Client) (watchSubscription[*ws.SlotResult], error) {
+				return client.SlotSubscribe()
+			},
+			func(res *ws.SlotResult) (bool, error) {
+				return false, printNotification(res, fmt.Sprintf("slot %d (parent %d, root %d)", res.Slot, res.Parent, res.Root))
+			},
+		)
+	},
+}
+
+func init() {
+	watchCmd.AddCommand(watchSlotCmd)
+}
@@ -11,7 +11,7 @@ import (
 )
 
 const (
-	PACKET_DATA_SIZE int = 1280 - 40 - 8
+	PACKET_DATA_SIZE int = solana.PACKET_DATA_SIZE
 )
 
 // https://github.com/solana-labs/solana/blob/v1.7.15/cli/src/program.rs#L1683
@@ -0,0 +1,336 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Sizes of the accounts of the upgradeable loader, without the program data.
+const (
+	UpgradeableBufferMetadataSize      = 37 // 4 (type) + 1 + 32 (authority)
+	UpgradeableProgramSize             = 36 // 4 (type) + 32 (programdata address)
+	UpgradeableProgramDataMetadataSize = 45 // 4 (type) + 8 (slot) + 1 + 32 (upgrade authority)
+)
+
+// Instructions of the upgradeable loader.
+const (
+	upgradeableInitializeBuffer uint32 = iota
+	upgradeableWrite
+	upgradeableDeployWithMaxDataLen
+	upgradeableUpgrade
+	upgradeableSetAuthority
+	upgradeableClose
+)
+
+// https://github.com/solana-labs/solana/blob/v1.16.0/sdk/program/src/loader_upgradeable_instruction.rs
+
+// NewInitializeBufferInstruction initializes a buffer account (of
+// UpgradeableBufferMetadataSize plus the program size), owned by the upgradeable loader.
+func NewInitializeBufferInstruction(buffer, authority solana.PublicKey) solana.Instruction {
+	return newUpgradeableInstruction(
+		upgradeableInitializeBuffer,
+		nil,
+		solana.NewAccountMeta(buffer, true, false),
+		solana.NewAccountMeta(authority, false, false),
+	)
+}
+
+// NewWriteInstruction writes program data into a buffer account, at the offset of the program data.
+func NewWriteInstruction(buffer, authority solana.PublicKey, offset uint32, data []byte) solana.Instruction {
+	params := make([]byte, 12+len(data))
+	binary.LittleEndian.PutUint32(params[0:], offset)
+	binary.LittleEndian.PutUint64(params[4:], uint64(len(data)))
+	copy(params[12:], data)
+	return newUpgradeableInstruction(
+		upgradeableWrite,
+		params,
+		solana.NewAccountMeta(buffer, true, false),
+		solana.NewAccountMeta(authority, false, true),
+	)
+}
+
+// NewDeployWithMaxDataLenInstruction deploys a program from a buffer account;
+// the program account must have been created (of UpgradeableProgramSize) in the same transaction,
+// and the program data account is funded by the payer.
+func NewDeployWithMaxDataLenInstruction(
+	payer solana.PublicKey,
+	program solana.PublicKey,
+	buffer solana.PublicKey,
+	upgradeAuthority solana.PublicKey,
+	maxDataLen uint64,
+) (solana.Instruction, error) {
+	programData, err := GetProgramDataAddress(program)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]byte, 8)
+	binary.LittleEndian.PutUint64(params, maxDataLen)
+	return newUpgradeableInstruction(
+		upgradeableDeployWithMaxDataLen,
+		params,
+		solana.NewAccountMeta(payer, true, true),
+		solana.NewAccountMeta(programData, true, false),
+		solana.NewAccountMeta(program, true, false),
+		solana.NewAccountMeta(buffer, true, false),
+		solana.NewAccountMeta(solana.SysVarRentPubkey, false, false),
+		solana.NewAccountMeta(solana.SysVarClockPubkey, false, false),
+		solana.NewAccountMeta(solana.SystemProgramID, false, false),
+		solana.NewAccountMeta(upgradeAuthority, false, true),
+	), nil
+}
+
+// NewUpgradeInstruction upgrades a program with the program data of a buffer account;
+// the lamports of the buffer account are sent to the spill account.
+func NewUpgradeInstruction(
+	program solana.PublicKey,
+	buffer solana.PublicKey,
+	upgradeAuthority solana.PublicKey,
+	spill solana.PublicKey,
+) (solana.Instruction, error) {
+	programData, err := GetProgramDataAddress(program)
+	if err != nil {
+		return nil, err
+	}
+	return newUpgradeableInstruction(
+		upgradeableUpgrade,
+		nil,
+		solana.NewAccountMeta(programData, true, false),
+		solana.NewAccountMeta(program, true, false),
+		solana.NewAccountMeta(buffer, true, false),
+		solana.NewAccountMeta(spill, true, false),
+		solana.NewAccountMeta(solana.SysVarRentPubkey, false, false),
+		solana.NewAccountMeta(solana.SysVarClockPubkey, false, false),
+		solana.NewAccountMeta(upgradeAuthority, false, true),
+	), nil
+}
+
+// NewCloseInstruction closes a buffer account, and sends its lamports to the recipient.
+func NewCloseInstruction(buffer, recipient, authority solana.PublicKey) solana.Instruction {
+	return newUpgradeableInstruction(
+		upgradeableClose,
+		nil,
+		solana.NewAccountMeta(buffer, true, false),
+		solana.NewAccountMeta(recipient, true, false),
+		solana.NewAccountMeta(authority, false, true),
+	)
+}
+
+func newUpgradeableInstruction(typ uint32, params []byte, accounts ...*solana.AccountMeta) solana.Instruction {
+	data := make([]byte, 4+len(params))
+	binary.LittleEndian.PutUint32(data, typ)
+	copy(data[4:], params)
+	return solana.NewInstruction(solana.BPFLoaderUpgradeableProgramID, accounts, data)
+}
+
+// GetProgramDataAddress returns the address of the program data account of an upgradeable program.
+func GetProgramDataAddress(program solana.PublicKey) (solana.PublicKey, error) {
+	address, _, err := solana.FindProgramAddress([][]byte{program[:]}, solana.BPFLoaderUpgradeableProgramID)
+	return address, err
+}
+
+type UpgradeableLoaderStateType uint32
+
+const (
+	UpgradeableLoaderStateUninitialized UpgradeableLoaderStateType = iota
+	UpgradeableLoaderStateBuffer
+	UpgradeableLoaderStateProgram
+	UpgradeableLoaderStateProgramData
+)
+
+func (t UpgradeableLoaderStateType) String() string {
+	switch t {
+	case UpgradeableLoaderStateUninitialized:
+		return "uninitialized"
+	case UpgradeableLoaderStateBuffer:
+		return "buffer"
+	case UpgradeableLoaderStateProgram:
+		return "program"
+	case UpgradeableLoaderStateProgramData:
+		return "programdata"
+	default:
+		return fmt.Sprintf("unknown (%d)", uint32(t))
+	}
+}
+
+// UpgradeableLoaderState is the state of an account owned by the upgradeable loader.
+type UpgradeableLoaderState struct {
+	Type UpgradeableLoaderStateType
+	// The authority of a buffer, or the upgrade authority of a program data account;
+	// nil if the program is immutable.
+	Authority *solana.PublicKey
+	// The program data account of a program.
+	ProgramData solana.PublicKey
+	// The slot a program data account was last deployed at.
+	Slot uint64
+}
+
+// DecodeUpgradeableLoaderState decodes the state of an account owned by the upgradeable loader.
+func DecodeUpgradeableLoaderState(data []byte) (*UpgradeableLoaderState, error) {
+	state := new(UpgradeableLoaderState)
+	if err := bin.NewBinDecoder(data).Decode(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (state *UpgradeableLoaderState) UnmarshalWithDecoder(dec *bin.Decoder) (err error) {
+	typ, err := dec.ReadUint32(bin.LE)
+	if err != nil {
+		return err
+	}
+	state.Type = UpgradeableLoaderStateType(typ)
+	switch state.Type {
+	case UpgradeableLoaderStateUninitialized:
+		return nil
+	case UpgradeableLoaderStateBuffer:
+		return decodeOptionalPubkey(dec, &state.Authority)
+	case UpgradeableLoaderStateProgram:
+		return dec.Decode(&state.ProgramData)
+	case UpgradeableLoaderStateProgramData:
+		if state.Slot, err = dec.ReadUint64(bin.LE); err != nil {
+			return err
+		}
+		return decodeOptionalPubkey(dec, &state.Authority)
+	default:
+		return fmt.Errorf("unknown upgradeable loader state type: %d", typ)
+	}
+}
+
+func decodeOptionalPubkey(dec *bin.Decoder, pubkey **solana.PublicKey) error {
+	ok, err := dec.ReadBool()
+	if err != nil || !ok {
+		return err
+	}
+	*pubkey = new(solana.PublicKey)
+	return dec.Decode(*pubkey)
+}
+
+// WriteBuffer returns the transactions that write the program data into a buffer account
+// of the upgradeable loader: the initial one creates and initializes the buffer account
+// (nil if it already exists), and the write ones each write a chunk of the program data.
+//
+// To resume an interrupted write, pass the existing buffer account:
+// the chunks it already contains are not written again.
+func WriteBuffer(
+	payerPubkey solana.PublicKey,
+	account *rpc.Account,
+	programData []byte,
+	minimumBalance uint64,
+	bufferPubkey solana.PublicKey,
+	authorityPubkey solana.PublicKey,
+) (
+	initialBuilder *solana.TransactionBuilder,
+	writeBuilders []*solana.TransactionBuilder,
+	err error,
+) {
+	bufferLen := UpgradeableBufferMetadataSize + len(programData)
+	var written []byte
+	if account != nil {
+		if !account.Owner.Equals(solana.BPFLoaderUpgradeableProgramID) {
+			err = errors.New("buffer account passed is already in use by another program")
+			return
+		}
+		data := account.Data.GetBinary()
+		var state *UpgradeableLoaderState
+		if state, err = DecodeUpgradeableLoaderState(data); err != nil {
+			return
+		}
+		if state.Type != UpgradeableLoaderStateBuffer {
+			err = fmt.Errorf("account passed is a %s account, not a buffer", state.Type)
+			return
+		}
+		if state.Authority == nil || !state.Authority.Equals(authorityPubkey) {
+			err = fmt.Errorf("buffer authority mismatch: %v", state.Authority)
+			return
+		}
+		if len(data) != bufferLen {
+			err = fmt.Errorf(
+				"buffer account size mismatch: %d bytes instead of %d; it may have been for a different program",
+				len(data), bufferLen,
+			)
+			return
+		}
+		written = data[UpgradeableBufferMetadataSize:]
+	} else {
+		initialBuilder = solana.NewTransactionBuilder().
+			AddInstruction(system.NewCreateAccountInstruction(
+				minimumBalance,
+				uint64(bufferLen),
+				solana.BPFLoaderUpgradeableProgramID,
+				payerPubkey,
+				bufferPubkey,
+			).Build()).
+			AddInstruction(NewInitializeBufferInstruction(bufferPubkey, authorityPubkey)).
+			SetFeePayer(payerPubkey)
+	}
+
+	createBuilder := func(offset int, chunk []byte) *solana.TransactionBuilder {
+		return solana.NewTransactionBuilder().
+			AddInstruction(NewWriteInstruction(bufferPubkey, authorityPubkey, uint32(offset), chunk)).
+			SetFeePayer(payerPubkey)
+	}
+	chunkSize, err := calculateMaxChunkSize(createBuilder)
+	if err != nil {
+		return
+	}
+	writeBuilders = []*solana.TransactionBuilder{}
+	for i := 0; i < len(programData); i += chunkSize {
+		end := i + chunkSize
+		if end > len(programData) {
+			end = len(programData)
+		}
+		if written != nil && bytes.Equal(written[i:end], programData[i:end]) {
+			continue
+		}
+		writeBuilders = append(writeBuilders, createBuilder(i, programData[i:end]))
+	}
+	return
+}
+
+// DeployUpgradeable returns the transaction that deploys a program from a buffer account
+// written with WriteBuffer: it creates the program account (which must sign),
+// and the program data account, which can hold up to maxDataLen bytes of program data.
+func DeployUpgradeable(
+	payerPubkey solana.PublicKey,
+	programPubkey solana.PublicKey,
+	programMinimumBalance uint64,
+	bufferPubkey solana.PublicKey,
+	upgradeAuthorityPubkey solana.PublicKey,
+	maxDataLen uint64,
+) (*solana.TransactionBuilder, error) {
+	deploy, err := NewDeployWithMaxDataLenInstruction(payerPubkey, programPubkey, bufferPubkey, upgradeAuthorityPubkey, maxDataLen)
+	if err != nil {
+		return nil, err
+	}
+	return solana.NewTransactionBuilder().
+		AddInstruction(system.NewCreateAccountInstruction(
+			programMinimumBalance,
+			UpgradeableProgramSize,
+			solana.BPFLoaderUpgradeableProgramID,
+			payerPubkey,
+			programPubkey,
+		).Build()).
+		AddInstruction(deploy).
+		SetFeePayer(payerPubkey), nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeUpgradeableLoaderState(t *testing.T) {
+	authority := solana.NewWallet().PublicKey()
+
+	data := make([]byte, UpgradeableProgramDataMetadataSize)
+	binary.LittleEndian.PutUint32(data, uint32(UpgradeableLoaderStateProgramData))
+	binary.LittleEndian.PutUint64(data[4:], 1234)
+	data[12] = 1
+	copy(data[13:], authority[:])
+	state, err := DecodeUpgradeableLoaderState(data)
+	require.NoError(t, err)
+	require.Equal(t, &UpgradeableLoaderState{
+		Type:      UpgradeableLoaderStateProgramData,
+		Slot:      1234,
+		Authority: &authority,
+	}, state)
+
+	// Immutable program.
+	data[12] = 0
+	state, err = DecodeUpgradeableLoaderState(data)
+	require.NoError(t, err)
+	require.Nil(t, state.Authority)
+
+	programData := solana.NewWallet().PublicKey()
+	data = make([]byte, UpgradeableProgramSize)
+	binary.LittleEndian.PutUint32(data, uint32(UpgradeableLoaderStateProgram))
+	copy(data[4:], programData[:])
+	state, err = DecodeUpgradeableLoaderState(data)
+	require.NoError(t, err)
+	require.Equal(t, UpgradeableLoaderStateProgram, state.Type)
+	require.Equal(t, programData, state.ProgramData)
+}
+
+func TestWriteBuffer(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	buffer := solana.NewWallet().PublicKey()
+	authority := solana.NewWallet().PublicKey()
+	programData := bytes.Repeat([]byte{1, 2, 3, 4, 5}, 1000)
+
+	initial, writes, err := WriteBuffer(payer, nil, programData, 1000, buffer, authority)
+	require.NoError(t, err)
+	require.NotNil(t, initial)
+	require.Greater(t, len(writes), 1)
+
+	// Every chunk fits in a transaction, signed by the payer and the authority.
+	var chunkSize int
+	for i, builder := range writes {
+		tx, err := builder.SetRecentBlockHash(solana.Hash{1}).Build()
+		require.NoError(t, err)
+		require.Equal(t, uint8(2), tx.Message.Header.NumRequiredSignatures)
+		tx.Signatures = make([]solana.Signature, 2)
+		raw, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(raw), solana.PACKET_DATA_SIZE)
+
+		data := tx.Message.Instructions[0].Data
+		require.Equal(t, upgradeableWrite, binary.LittleEndian.Uint32(data))
+		require.Equal(t, uint32(i*chunkSize), binary.LittleEndian.Uint32(data[4:]))
+		if i == 0 {
+			chunkSize = len(data) - 16
+		}
+	}
+
+	// Resume with a buffer that already holds all the chunks but the second one.
+	accountData := make([]byte, UpgradeableBufferMetadataSize+len(programData))
+	binary.LittleEndian.PutUint32(accountData, uint32(UpgradeableLoaderStateBuffer))
+	accountData[4] = 1
+	copy(accountData[5:], authority[:])
+	copy(accountData[UpgradeableBufferMetadataSize:], programData)
+	accountData[UpgradeableBufferMetadataSize+chunkSize] = 0
+	account := &rpc.Account{
+		Owner: solana.BPFLoaderUpgradeableProgramID,
+		Data:  rpc.DataBytesOrJSONFromBytes(accountData),
+	}
+	initial, writes, err = WriteBuffer(payer, account, programData, 1000, buffer, authority)
+	require.NoError(t, err)
+	require.Nil(t, initial)
+	require.Len(t, writes, 1)
+	tx, err := writes[0].SetRecentBlockHash(solana.Hash{1}).Build()
+	require.NoError(t, err)
+	require.Equal(t, uint32(chunkSize), binary.LittleEndian.Uint32(tx.Message.Instructions[0].Data[4:]))
+
+	// Another authority.
+	_, _, err = WriteBuffer(payer, account, programData, 1000, buffer, payer)
+	require.Error(t, err)
+}
@@ -0,0 +1,330 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"fmt"
+	"math"
+
+	bin "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+// The size of a stake account.
+const StakeAccountSize = 200
+
+type StakeStateType uint32
+
+const (
+	StakeStateUninitialized StakeStateType = iota
+	StakeStateInitialized
+	StakeStateStake
+	StakeStateRewardsPool
+)
+
+func (t StakeStateType) String() string {
+	switch t {
+	case StakeStateUninitialized:
+		return "uninitialized"
+	case StakeStateInitialized:
+		return "initialized"
+	case StakeStateStake:
+		return "delegated"
+	case StakeStateRewardsPool:
+		return "rewards pool"
+	default:
+		return fmt.Sprintf("unknown (%d)", uint32(t))
+	}
+}
+
+// StakeState is the state of a stake account.
+type StakeState struct {
+	Type StakeStateType
+	// Set for the initialized and delegated stake accounts.
+	Meta *Meta
+	// Set for the delegated stake accounts.
+	Stake *Stake
+}
+
+type Meta struct {
+	RentExemptReserve uint64
+	Authorized        Authorized
+	Lockup            Lockup
+}
+
+type Stake struct {
+	Delegation      Delegation
+	CreditsObserved uint64
+}
+
+type Delegation struct {
+	// The vote account the stake is delegated to.
+	VoterPubkey ag_solanago.PublicKey
+	// The amount of stake delegated.
+	Stake uint64
+	// The epoch at which the stake was activated; math.MaxUint64 for bootstrap stake.
+	ActivationEpoch uint64
+	// The epoch at which the stake was deactivated; math.MaxUint64 if not deactivated.
+	DeactivationEpoch uint64
+	// Deprecated.
+	WarmupCooldownRate float64
+}
+
+// DecodeStakeState decodes the data of a stake account.
+func DecodeStakeState(data []byte) (*StakeState, error) {
+	state := new(StakeState)
+	if err := bin.NewBinDecoder(data).Decode(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (state *StakeState) UnmarshalWithDecoder(dec *bin.Decoder) (err error) {
+	typ, err := dec.ReadUint32(bin.LE)
+	if err != nil {
+		return err
+	}
+	state.Type = StakeStateType(typ)
+	switch state.Type {
+	case StakeStateUninitialized, StakeStateRewardsPool:
+		return nil
+	case StakeStateInitialized, StakeStateStake:
+	default:
+		return fmt.Errorf("unknown stake state type: %d", typ)
+	}
+
+	state.Meta = new(Meta)
+	if state.Meta.RentExemptReserve, err = dec.ReadUint64(bin.LE); err != nil {
+		return err
+	}
+	if err = dec.Decode(&state.Meta.Authorized); err != nil {
+		return err
+	}
+	if err = dec.Decode(&state.Meta.Lockup); err != nil {
+		return err
+	}
+	if state.Type == StakeStateInitialized {
+		return nil
+	}
+
+	state.Stake = new(Stake)
+	delegation := &state.Stake.Delegation
+	if err = dec.Decode(&delegation.VoterPubkey); err != nil {
+		return err
+	}
+	if delegation.Stake, err = dec.ReadUint64(bin.LE); err != nil {
+		return err
+	}
+	if delegation.ActivationEpoch, err = dec.ReadUint64(bin.LE); err != nil {
+		return err
+	}
+	if delegation.DeactivationEpoch, err = dec.ReadUint64(bin.LE); err != nil {
+		return err
+	}
+	if delegation.WarmupCooldownRate, err = dec.ReadFloat64(bin.LE); err != nil {
+		return err
+	}
+	state.Stake.CreditsObserved, err = dec.ReadUint64(bin.LE)
+	return err
+}
+
+// StakeHistoryEntry is the stake of the cluster in an epoch.
+type StakeHistoryEntry struct {
+	Effective    uint64
+	Activating   uint64
+	Deactivating uint64
+}
+
+// StakeHistory is the content of the stake history sysvar
+// (solana.SysVarStakeHistoryPubkey), by epoch.
+type StakeHistory map[uint64]StakeHistoryEntry
+
+// DecodeStakeHistory decodes the data of the stake history sysvar.
+func DecodeStakeHistory(data []byte) (StakeHistory, error) {
+	dec := bin.NewBinDecoder(data)
+	length, err := dec.ReadUint64(bin.LE)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(dec.Remaining()/32) {
+		return nil, fmt.Errorf("invalid stake history length: %d", length)
+	}
+	history := make(StakeHistory, length)
+	for i := uint64(0); i < length; i++ {
+		var epoch uint64
+		var entry StakeHistoryEntry
+		for _, v := range []*uint64{&epoch, &entry.Effective, &entry.Activating, &entry.Deactivating} {
+			if *v, err = dec.ReadUint64(bin.LE); err != nil {
+				return nil, err
+			}
+		}
+		history[epoch] = entry
+	}
+	return history, nil
+}
+
+const (
+	// The warmup/cooldown rate of the stake before the reduce_stake_warmup_cooldown feature.
+	DefaultWarmupCooldownRate = 0.25
+	// The warmup/cooldown rate of the stake since the reduce_stake_warmup_cooldown feature.
+	NewWarmupCooldownRate = 0.09
+)
+
+func warmupCooldownRate(epoch uint64, newRateActivationEpoch *uint64) float64 {
+	if newRateActivationEpoch == nil || epoch < *newRateActivationEpoch {
+		return DefaultWarmupCooldownRate
+	}
+	return NewWarmupCooldownRate
+}
+
+// StakeActivationStatus is the amount of stake that is effective,
+// activating and deactivating at an epoch.
+type StakeActivationStatus struct {
+	Effective    uint64
+	Activating   uint64
+	Deactivating uint64
+}
+
+// State returns the activation state of the stake, as returned by getStakeActivation:
+// one of "active", "inactive", "activating" or "deactivating".
+func (status StakeActivationStatus) State() string {
+	switch {
+	case status.Deactivating > 0:
+		return "deactivating"
+	case status.Activating > 0:
+		return "activating"
+	case status.Effective > 0:
+		return "active"
+	default:
+		return "inactive"
+	}
+}
+
+// Inactive returns the inactive stake of a stake account with the given lamports,
+// as returned by getStakeActivation: the activating stake while activating,
+// and the lamports that are neither effective nor reserved for rent otherwise.
+func (status StakeActivationStatus) Inactive(lamports, rentExemptReserve uint64) uint64 {
+	switch status.State() {
+	case "active":
+		return 0
+	case "activating":
+		return status.Activating
+	case "deactivating":
+		return saturatingSub(lamports, rentExemptReserve+status.Effective)
+	default:
+		return saturatingSub(lamports, rentExemptReserve)
+	}
+}
+
+func saturatingSub(a, b uint64) uint64 {
+	if a < b {
+		return 0
+	}
+	return a - b
+}
+
+// ActivationStatus computes the activation status of the delegation at the target epoch,
+// from the stake history of the cluster. newRateActivationEpoch is the epoch
+// at which the reduced warmup/cooldown rate was activated (nil if it is not active).
+func (d Delegation) ActivationStatus(targetEpoch uint64, history StakeHistory, newRateActivationEpoch *uint64) StakeActivationStatus {
+	effective, activating := d.stakeAndActivating(targetEpoch, history, newRateActivationEpoch)
+
+	switch {
+	case targetEpoch < d.DeactivationEpoch:
+		return StakeActivationStatus{Effective: effective, Activating: activating}
+	case targetEpoch == d.DeactivationEpoch:
+		// Can only deactivate what is effective.
+		return StakeActivationStatus{Effective: effective, Deactivating: effective}
+	}
+
+	prevClusterStake, ok := history[d.DeactivationEpoch]
+	if !ok {
+		// No history: fully deactivated.
+		return StakeActivationStatus{}
+	}
+	prevEpoch := d.DeactivationEpoch
+	currentEffective := effective
+	for {
+		currentEpoch := prevEpoch + 1
+		if prevClusterStake.Deactivating == 0 {
+			break
+		}
+		// The portion of the deactivating stake of the cluster that is this stake.
+		weight := float64(currentEffective) / float64(prevClusterStake.Deactivating)
+		newlyNotEffectiveClusterStake := float64(prevClusterStake.Effective) * warmupCooldownRate(currentEpoch, newRateActivationEpoch)
+		newlyNotEffective := uint64(math.Max(weight*newlyNotEffectiveClusterStake, 1))
+
+		if newlyNotEffective >= currentEffective {
+			currentEffective = 0
+			break
+		}
+		currentEffective -= newlyNotEffective
+		if currentEpoch >= targetEpoch {
+			break
+		}
+		next, ok := history[currentEpoch]
+		if !ok {
+			break
+		}
+		prevEpoch, prevClusterStake = currentEpoch, next
+	}
+	return StakeActivationStatus{Effective: currentEffective, Deactivating: currentEffective}
+}
+
+func (d Delegation) stakeAndActivating(targetEpoch uint64, history StakeHistory, newRateActivationEpoch *uint64) (effective, activating uint64) {
+	switch {
+	case d.ActivationEpoch == math.MaxUint64:
+		// Bootstrap stake: fully effective.
+		return d.Stake, 0
+	case d.ActivationEpoch == d.DeactivationEpoch:
+		// Deactivated before being activated.
+		return 0, 0
+	case targetEpoch == d.ActivationEpoch:
+		return 0, d.Stake
+	case targetEpoch < d.ActivationEpoch:
+		return 0, 0
+	}
+
+	prevClusterStake, ok := history[d.ActivationEpoch]
+	if !ok {
+		// No history: fully effective.
+		return d.Stake, 0
+	}
+	prevEpoch := d.ActivationEpoch
+	for {
+		currentEpoch := prevEpoch + 1
+		if prevClusterStake.Activating == 0 {
+			break
+		}
+		// The portion of the activating stake of the cluster that is this stake.
+		weight := float64(d.Stake-effective) / float64(prevClusterStake.Activating)
+		newlyEffectiveClusterStake := float64(prevClusterStake.Effective) * warmupCooldownRate(currentEpoch, newRateActivationEpoch)
+		effective += uint64(math.Max(weight*newlyEffectiveClusterStake, 1))
+
+		if effective >= d.Stake {
+			effective = d.Stake
+			break
+		}
+		if currentEpoch >= targetEpoch || currentEpoch >= d.DeactivationEpoch {
+			break
+		}
+		next, ok := history[currentEpoch]
+		if !ok {
+			break
+		}
+		prevEpoch, prevClusterStake = currentEpoch, next
+	}
+	return effective, d.Stake - effective
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeStakeState(t *testing.T) {
+	staker := ag_solanago.NewWallet().PublicKey()
+	withdrawer := ag_solanago.NewWallet().PublicKey()
+	voter := ag_solanago.NewWallet().PublicKey()
+
+	buf := new(bytes.Buffer)
+	enc := bin.NewBinEncoder(buf)
+	for _, v := range []interface{}{
+		uint32(StakeStateStake),
+		uint64(2282880),    // rent exempt reserve
+		staker, withdrawer, // authorized
+		int64(0), uint64(0), staker, // lockup
+		voter, uint64(1e9), uint64(100), uint64(math.MaxUint64), float64(0.25), // delegation
+		uint64(42), // credits observed
+		uint8(0),   // flags
+	} {
+		require.NoError(t, enc.Encode(v))
+	}
+	data := append(buf.Bytes(), make([]byte, StakeAccountSize-buf.Len())...)
+
+	state, err := DecodeStakeState(data)
+	require.NoError(t, err)
+	require.Equal(t, StakeStateStake, state.Type)
+	require.Equal(t, uint64(2282880), state.Meta.RentExemptReserve)
+	require.Equal(t, staker, *state.Meta.Authorized.Staker)
+	require.Equal(t, withdrawer, *state.Meta.Authorized.Withdrawer)
+	require.Equal(t, Delegation{
+		VoterPubkey:        voter,
+		Stake:              1e9,
+		ActivationEpoch:    100,
+		DeactivationEpoch:  math.MaxUint64,
+		WarmupCooldownRate: 0.25,
+	}, state.Stake.Delegation)
+	require.Equal(t, uint64(42), state.Stake.CreditsObserved)
+
+	state, err = DecodeStakeState(make([]byte, StakeAccountSize))
+	require.NoError(t, err)
+	require.Equal(t, StakeStateUninitialized, state.Type)
+	require.Nil(t, state.Meta)
+}
+
+func TestDecodeStakeHistory(t *testing.T) {
+	buf := new(bytes.Buffer)
+	enc := bin.NewBinEncoder(buf)
+	for _, v := range []uint64{2, 11, 100, 10, 5, 10, 90, 20, 0} {
+		require.NoError(t, enc.WriteUint64(v, bin.LE))
+	}
+	history, err := DecodeStakeHistory(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, StakeHistory{
+		11: {Effective: 100, Activating: 10, Deactivating: 5},
+		10: {Effective: 90, Activating: 20, Deactivating: 0},
+	}, history)
+}
+
+func TestDelegation_ActivationStatus(t *testing.T) {
+	delegation := Delegation{
+		Stake:             1000,
+		ActivationEpoch:   10,
+		DeactivationEpoch: math.MaxUint64,
+	}
+	history := StakeHistory{
+		10: {Effective: 1000, Activating: 2000},
+		11: {Effective: 1250, Activating: 1750},
+	}
+
+	require.Equal(t, StakeActivationStatus{}, delegation.ActivationStatus(9, history, nil))
+	require.Equal(t, StakeActivationStatus{Activating: 1000}, delegation.ActivationStatus(10, history, nil))
+	// Half of the activating stake of the cluster, which warms up by 25% of the effective stake.
+	status := delegation.ActivationStatus(11, history, nil)
+	require.Equal(t, StakeActivationStatus{Effective: 125, Activating: 875}, status)
+	require.Equal(t, "activating", status.State())
+	// With the reduced rate (9%).
+	newRateEpoch := uint64(0)
+	require.Equal(t, StakeActivationStatus{Effective: 45, Activating: 955}, delegation.ActivationStatus(11, history, &newRateEpoch))
+	// No history after epoch 11.
+	require.Equal(t, StakeActivationStatus{Effective: 125 + 156, Activating: 719}, delegation.ActivationStatus(20, history, nil))
+
+	// Deactivation.
+	delegation = Delegation{
+		Stake:             1000,
+		ActivationEpoch:   math.MaxUint64, // bootstrap
+		DeactivationEpoch: 30,
+	}
+	history = StakeHistory{
+		30: {Effective: 2000, Deactivating: 1000},
+	}
+	require.Equal(t, "active", delegation.ActivationStatus(29, history, nil).State())
+	require.Equal(t, StakeActivationStatus{Effective: 1000, Deactivating: 1000}, delegation.ActivationStatus(30, history, nil))
+	// All of the deactivating stake, which cools down by 25% of the effective stake.
+	require.Equal(t, StakeActivationStatus{Effective: 500, Deactivating: 500}, delegation.ActivationStatus(31, history, nil))
+	require.Equal(t, "inactive", delegation.ActivationStatus(31, StakeHistory{}, nil).State())
+}
+
+func TestStakeActivationStatus_Inactive(t *testing.T) {
+	const lamports, rentExemptReserve = 5000, 2000
+	require.Equal(t, uint64(0), StakeActivationStatus{Effective: 3000}.Inactive(lamports, rentExemptReserve))
+	require.Equal(t, uint64(2000), StakeActivationStatus{Effective: 1000, Activating: 2000}.Inactive(lamports, rentExemptReserve))
+	require.Equal(t, uint64(1000), StakeActivationStatus{Effective: 2000, Deactivating: 2000}.Inactive(lamports, rentExemptReserve))
+	require.Equal(t, uint64(3000), StakeActivationStatus{}.Inactive(lamports, rentExemptReserve))
+	// Less lamports than the rent-exempt reserve.
+	require.Equal(t, uint64(0), StakeActivationStatus{}.Inactive(1000, rentExemptReserve))
+}
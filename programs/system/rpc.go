@@ -0,0 +1,208 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"fmt"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func init() {
+	rpc.RegisterInstructionParser(ProgramID, "system", parseInstruction)
+}
+
+// parseInstruction renders the system instructions like the RPC
+// (see parse_system.rs in solana-transaction-status), for rpc.ParseInstruction.
+func parseInstruction(decoded interface{}, accounts []*ag_solanago.AccountMeta) (*rpc.InstructionInfo, error) {
+	inst, ok := decoded.(*Instruction)
+	if !ok {
+		return nil, fmt.Errorf("unexpected instruction type %T", decoded)
+	}
+	account := func(index int) string {
+		return accounts[index].PublicKey.String()
+	}
+
+	switch impl := inst.Impl.(type) {
+	case *CreateAccount:
+		if err := checkNumAccounts(accounts, 2); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "createAccount",
+			Info: map[string]interface{}{
+				"source":     account(0),
+				"newAccount": account(1),
+				"lamports":   *impl.Lamports,
+				"space":      *impl.Space,
+				"owner":      impl.Owner.String(),
+			},
+		}, nil
+	case *Assign:
+		if err := checkNumAccounts(accounts, 1); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "assign",
+			Info: map[string]interface{}{
+				"account": account(0),
+				"owner":   impl.Owner.String(),
+			},
+		}, nil
+	case *Transfer:
+		if err := checkNumAccounts(accounts, 2); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "transfer",
+			Info: map[string]interface{}{
+				"source":      account(0),
+				"destination": account(1),
+				"lamports":    *impl.Lamports,
+			},
+		}, nil
+	case *CreateAccountWithSeed:
+		if err := checkNumAccounts(accounts, 2); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "createAccountWithSeed",
+			Info: map[string]interface{}{
+				"source":     account(0),
+				"newAccount": account(1),
+				"base":       impl.Base.String(),
+				"seed":       *impl.Seed,
+				"lamports":   *impl.Lamports,
+				"space":      *impl.Space,
+				"owner":      impl.Owner.String(),
+			},
+		}, nil
+	case *AdvanceNonceAccount:
+		if err := checkNumAccounts(accounts, 3); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "advanceNonce",
+			Info: map[string]interface{}{
+				"nonceAccount":            account(0),
+				"recentBlockhashesSysvar": account(1),
+				"nonceAuthority":          account(2),
+			},
+		}, nil
+	case *WithdrawNonceAccount:
+		if err := checkNumAccounts(accounts, 5); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "withdrawFromNonce",
+			Info: map[string]interface{}{
+				"nonceAccount":            account(0),
+				"destination":             account(1),
+				"recentBlockhashesSysvar": account(2),
+				"rentSysvar":              account(3),
+				"nonceAuthority":          account(4),
+				"lamports":                *impl.Lamports,
+			},
+		}, nil
+	case *InitializeNonceAccount:
+		if err := checkNumAccounts(accounts, 3); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "initializeNonce",
+			Info: map[string]interface{}{
+				"nonceAccount":            account(0),
+				"recentBlockhashesSysvar": account(1),
+				"rentSysvar":              account(2),
+				"nonceAuthority":          impl.Authorized.String(),
+			},
+		}, nil
+	case *AuthorizeNonceAccount:
+		if err := checkNumAccounts(accounts, 2); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "authorizeNonce",
+			Info: map[string]interface{}{
+				"nonceAccount":   account(0),
+				"nonceAuthority": account(1),
+				"newAuthorized":  impl.Authorized.String(),
+			},
+		}, nil
+	case *Allocate:
+		if err := checkNumAccounts(accounts, 1); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "allocate",
+			Info: map[string]interface{}{
+				"account": account(0),
+				"space":   *impl.Space,
+			},
+		}, nil
+	case *AllocateWithSeed:
+		if err := checkNumAccounts(accounts, 1); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "allocateWithSeed",
+			Info: map[string]interface{}{
+				"account": account(0),
+				"base":    impl.Base.String(),
+				"seed":    *impl.Seed,
+				"space":   *impl.Space,
+				"owner":   impl.Owner.String(),
+			},
+		}, nil
+	case *AssignWithSeed:
+		if err := checkNumAccounts(accounts, 1); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "assignWithSeed",
+			Info: map[string]interface{}{
+				"account": account(0),
+				"base":    impl.Base.String(),
+				"seed":    *impl.Seed,
+				"owner":   impl.Owner.String(),
+			},
+		}, nil
+	case *TransferWithSeed:
+		if err := checkNumAccounts(accounts, 3); err != nil {
+			return nil, err
+		}
+		return &rpc.InstructionInfo{
+			InstructionType: "transferWithSeed",
+			Info: map[string]interface{}{
+				"source":      account(0),
+				"sourceBase":  account(1),
+				"destination": account(2),
+				"lamports":    *impl.Lamports,
+				"sourceSeed":  *impl.FromSeed,
+				"sourceOwner": impl.FromOwner.String(),
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported instruction type %T", inst.Impl)
+	}
+}
+
+func checkNumAccounts(accounts []*ag_solanago.AccountMeta, num int) error {
+	if len(accounts) < num {
+		return fmt.Errorf("not enough accounts: expected %d, got %d", num, len(accounts))
+	}
+	return nil
+}
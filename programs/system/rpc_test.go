@@ -0,0 +1,218 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransaction(t *testing.T) {
+	payer := ag_solanago.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	recipient := ag_solanago.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	otherProgram := ag_solanago.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+
+	tx, err := ag_solanago.NewTransaction(
+		[]ag_solanago.Instruction{
+			NewTransferInstruction(1000, payer, recipient).Build(),
+			ag_solanago.NewInstruction(otherProgram, ag_solanago.AccountMetaSlice{ag_solanago.Meta(recipient)}, []byte{1, 2, 3}),
+		},
+		ag_solanago.Hash{},
+		ag_solanago.TransactionPayer(payer),
+	)
+	require.NoError(t, err)
+	systemIndex, err := tx.Message.GetAccountIndex(ProgramID)
+	require.NoError(t, err)
+	transferData, err := NewTransferInstruction(7, payer, recipient).Build().Data()
+	require.NoError(t, err)
+	meta := &rpc.TransactionMeta{
+		InnerInstructions: []rpc.InnerInstruction{
+			{
+				Index: 1,
+				Instructions: []rpc.CompiledInstruction{
+					{
+						ProgramIDIndex: systemIndex,
+						Accounts:       []uint16{0, 1},
+						Data:           transferData,
+						StackHeight:    2,
+					},
+				},
+			},
+		},
+	}
+
+	parsedTx, parsedMeta, err := rpc.ParseTransaction(tx, meta)
+	require.NoError(t, err)
+
+	// As returned by getTransaction with the jsonParsed encoding.
+	got, err := json.Marshal(parsedTx.Message.Instructions)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		fmt.Sprintf(`[
+			{
+				"parsed": {
+					"info": {
+						"destination": %q,
+						"lamports": 1000,
+						"source": %q
+					},
+					"type": "transfer"
+				},
+				"program": "system",
+				"programId": "11111111111111111111111111111111",
+				"stackHeight": null
+			},
+			{
+				"accounts": [%q],
+				"data": "Ldp",
+				"programId": %q,
+				"stackHeight": null
+			}
+		]`, recipient, payer, recipient, otherProgram),
+		string(got),
+	)
+
+	got, err = json.Marshal(parsedMeta.InnerInstructions)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		fmt.Sprintf(`[
+			{
+				"index": 1,
+				"instructions": [
+					{
+						"parsed": {
+							"info": {
+								"destination": %q,
+								"lamports": 7,
+								"source": %q
+							},
+							"type": "transfer"
+						},
+						"program": "system",
+						"programId": "11111111111111111111111111111111",
+						"stackHeight": 2
+					}
+				]
+			}
+		]`, recipient, payer),
+		string(got),
+	)
+}
+
+func TestParseInstruction(t *testing.T) {
+	funding := ag_solanago.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	account := ag_solanago.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	owner := ag_solanago.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+
+	for _, tt := range []struct {
+		name        string
+		instruction ag_solanago.Instruction
+		parsed      string
+	}{
+		{
+			name:        "createAccount",
+			instruction: NewCreateAccountInstruction(2039280, 165, owner, funding, account).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"lamports": 2039280,
+					"newAccount": %q,
+					"owner": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+					"source": %q,
+					"space": 165
+				},
+				"type": "createAccount"
+			}`, account, funding),
+		},
+		{
+			name:        "createAccountWithSeed",
+			instruction: NewCreateAccountWithSeedInstruction(funding, "seed", 2039280, 165, owner, funding, account, funding).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"base": %q,
+					"lamports": 2039280,
+					"newAccount": %q,
+					"owner": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+					"seed": "seed",
+					"source": %q,
+					"space": 165
+				},
+				"type": "createAccountWithSeed"
+			}`, funding, account, funding),
+		},
+		{
+			name:        "advanceNonce",
+			instruction: NewAdvanceNonceAccountInstruction(account, ag_solanago.SysVarRecentBlockHashesPubkey, funding).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"nonceAccount": %q,
+					"nonceAuthority": %q,
+					"recentBlockhashesSysvar": "SysvarRecentB1ockHashes11111111111111111111"
+				},
+				"type": "advanceNonce"
+			}`, account, funding),
+		},
+		{
+			name:        "withdrawFromNonce",
+			instruction: NewWithdrawNonceAccountInstruction(42, account, funding, ag_solanago.SysVarRecentBlockHashesPubkey, ag_solanago.SysVarRentPubkey, funding).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"destination": %q,
+					"lamports": 42,
+					"nonceAccount": %q,
+					"nonceAuthority": %q,
+					"recentBlockhashesSysvar": "SysvarRecentB1ockHashes11111111111111111111",
+					"rentSysvar": "SysvarRent111111111111111111111111111111111"
+				},
+				"type": "withdrawFromNonce"
+			}`, funding, account, funding),
+		},
+		{
+			name:        "transferWithSeed",
+			instruction: NewTransferWithSeedInstruction(42, "seed", owner, account, funding, funding).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"destination": %q,
+					"lamports": 42,
+					"source": %q,
+					"sourceBase": %q,
+					"sourceOwner": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+					"sourceSeed": "seed"
+				},
+				"type": "transferWithSeed"
+			}`, funding, account, funding),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.instruction.Data()
+			require.NoError(t, err)
+			parsed := rpc.ParseInstruction(ProgramID, tt.instruction.Accounts(), data)
+			require.Equal(t, "system", parsed.Program)
+			got, err := json.Marshal(parsed.Parsed)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.parsed, string(got))
+		})
+	}
+
+	// The RPC does not parse an instruction without the expected accounts.
+	data, err := NewTransferInstruction(1, funding, account).Build().Data()
+	require.NoError(t, err)
+	parsed := rpc.ParseInstruction(ProgramID, ag_solanago.AccountMetaSlice{ag_solanago.Meta(funding)}, data)
+	require.Nil(t, parsed.Parsed)
+}
@@ -47,6 +47,26 @@ func TestMint(t *testing.T) {
 	}
 }
 
+func TestMint_Decode(t *testing.T) {
+	// A mainnet mint, with 6 decimals.
+	data := []byte{
+		1, 0, 0, 0,
+		5, 234, 156, 241, 108, 228, 17, 152, 241, 164, 153, 55, 200, 140, 55, 10, 148, 212, 175, 255, 137, 181, 186, 203, 142, 244, 94, 99, 36, 187, 120, 247,
+		9, 169, 49, 235, 241, 182, 6, 0,
+		6,
+		1,
+		1, 0, 0, 0,
+		5, 234, 156, 241, 108, 228, 17, 152, 241, 164, 153, 55, 200, 140, 55, 10, 148, 212, 175, 255, 137, 181, 186, 203, 142, 244, 94, 99, 36, 187, 120, 247,
+	}
+	var mint Mint
+	require.NoError(t, mint.Decode(data))
+	require.Equal(t, uint8(6), mint.Decimals)
+	require.Equal(t, uint64(1890000009537801), mint.Supply)
+	require.True(t, mint.IsInitialized)
+	require.Equal(t, "Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi", mint.MintAuthority.String())
+	require.Equal(t, mint.MintAuthority, mint.FreezeAuthority)
+}
+
 func TestAccount(t *testing.T) {
 	accountBytes := []byte{
 		6, 155, 136, 87, 254, 171, 129, 132, 251, 104, 127, 99, 70, 24, 192, 53, 218, 196, 57, 220, 26, 235, 59, 85, 152, 160, 240, 0, 0, 0, 0, 1,
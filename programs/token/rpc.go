@@ -21,17 +21,397 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
 	bin "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-const MINT_SIZE = 82
+const (
+	MINT_SIZE     = 82
+	ACCOUNT_SIZE  = 165
+	MULTISIG_SIZE = 355
+)
+
+func init() {
+	rpc.RegisterInstructionParser(ProgramID, "spl-token", parseInstruction)
+	rpc.RegisterAccountParser(ProgramID, "spl-token", parseAccountData)
+	rpc.RegisterAccountParser(ag_solanago.Token2022ProgramID, "spl-token-2022", parseToken2022AccountData)
+}
+
+// parseAccountData renders the accounts owned by the token program like the RPC
+// (see parse_token.rs in account-decoder), for rpc.ParseAccountData;
+// the token accounts can be rendered only with the decimals of their mint.
+func parseAccountData(data []byte, opts *rpc.ParseAccountOpts) (string, interface{}, error) {
+	switch len(data) {
+	case MINT_SIZE:
+		return parseMint(data)
+	case ACCOUNT_SIZE:
+		return parseAccount(data, opts)
+	case MULTISIG_SIZE:
+		return parseMultisig(data)
+	default:
+		return "", nil, fmt.Errorf("unexpected token account size: %d", len(data))
+	}
+}
+
+// The type of a Token-2022 account with extensions, stored right after the base account.
+const (
+	token2022AccountTypeMint    = 1
+	token2022AccountTypeAccount = 2
+)
+
+// parseToken2022AccountData renders the accounts owned by the Token-2022 program;
+// the extensions are not rendered.
+func parseToken2022AccountData(data []byte, opts *rpc.ParseAccountOpts) (string, interface{}, error) {
+	if len(data) <= ACCOUNT_SIZE || len(data) == MULTISIG_SIZE {
+		return parseAccountData(data, opts)
+	}
+	switch data[ACCOUNT_SIZE] {
+	case token2022AccountTypeMint:
+		return parseMint(data[:MINT_SIZE])
+	case token2022AccountTypeAccount:
+		return parseAccount(data[:ACCOUNT_SIZE], opts)
+	default:
+		return "", nil, fmt.Errorf("unexpected token account type: %d", data[ACCOUNT_SIZE])
+	}
+}
+
+func parseMint(data []byte) (string, interface{}, error) {
+	var mint Mint
+	if err := bin.NewBinDecoder(data).Decode(&mint); err != nil {
+		return "", nil, fmt.Errorf("unable to decode mint: %w", err)
+	}
+	if !mint.IsInitialized {
+		return "", nil, errors.New("mint is not initialized")
+	}
+	return "mint", &rpc.ParsedMint{
+		MintAuthority:   mint.MintAuthority,
+		Supply:          mint.Supply,
+		Decimals:        mint.Decimals,
+		IsInitialized:   mint.IsInitialized,
+		FreezeAuthority: mint.FreezeAuthority,
+	}, nil
+}
+
+func parseAccount(data []byte, opts *rpc.ParseAccountOpts) (string, interface{}, error) {
+	var account Account
+	if err := bin.NewBinDecoder(data).Decode(&account); err != nil {
+		return "", nil, fmt.Errorf("unable to decode account: %w", err)
+	}
+	if opts.TokenDecimals == nil {
+		return "", nil, errors.New("the decimals of the mint are required to parse a token account")
+	}
+	decimals := *opts.TokenDecimals
+
+	out := &rpc.ParsedTokenAccount{
+		Mint:           account.Mint,
+		Owner:          account.Owner,
+		IsNative:       account.IsNative != nil,
+		TokenAmount:    *rpc.NewUiTokenAmount(account.Amount, decimals),
+		Delegate:       account.Delegate,
+		CloseAuthority: account.CloseAuthority,
+	}
+	switch account.State {
+	case Initialized:
+		out.State = "initialized"
+	case Frozen:
+		out.State = "frozen"
+	default:
+		return "", nil, errors.New("account is not initialized")
+	}
+	if account.Delegate != nil {
+		out.DelegatedAmount = rpc.NewUiTokenAmount(account.DelegatedAmount, decimals)
+	}
+	if account.IsNative != nil {
+		out.RentExemptReserve = rpc.NewUiTokenAmount(*account.IsNative, decimals)
+	}
+	return "account", out, nil
+}
+
+func parseMultisig(data []byte) (string, interface{}, error) {
+	var multisig Multisig
+	if err := bin.NewBinDecoder(data).Decode(&multisig); err != nil {
+		return "", nil, fmt.Errorf("unable to decode multisig: %w", err)
+	}
+	if !multisig.IsInitialized {
+		return "", nil, errors.New("multisig is not initialized")
+	}
+	out := &rpc.ParsedMultisig{
+		NumRequiredSigners: multisig.M,
+		NumValidSigners:    multisig.N,
+		IsInitialized:      multisig.IsInitialized,
+		Signers:            []ag_solanago.PublicKey{},
+	}
+	for _, signer := range multisig.Signers {
+		if !signer.IsZero() {
+			out.Signers = append(out.Signers, signer)
+		}
+	}
+	return "multisig", out, nil
+}
+
+// parseInstruction renders the token instructions like the RPC
+// (see parse_token.rs in solana-transaction-status), for rpc.ParseInstruction.
+func parseInstruction(decoded interface{}, accounts []*ag_solanago.AccountMeta) (*rpc.InstructionInfo, error) {
+	inst, ok := decoded.(*Instruction)
+	if !ok {
+		return nil, fmt.Errorf("unexpected instruction type %T", decoded)
+	}
+	account := func(index int) string {
+		return accounts[index].PublicKey.String()
+	}
+	// The authority of the instruction is either a single signer,
+	// or a multisig account followed by its signers.
+	addSigners := func(info map[string]interface{}, lastNonSignerIndex int, ownerField, multisigField string) {
+		if len(accounts) > lastNonSignerIndex+1 {
+			signers := make([]string, 0, len(accounts)-lastNonSignerIndex-1)
+			for i := lastNonSignerIndex + 1; i < len(accounts); i++ {
+				signers = append(signers, account(i))
+			}
+			info[multisigField] = account(lastNonSignerIndex)
+			info["signers"] = signers
+		} else {
+			info[ownerField] = account(lastNonSignerIndex)
+		}
+	}
+	newInfo := func(instructionType string, info map[string]interface{}) (*rpc.InstructionInfo, error) {
+		return &rpc.InstructionInfo{
+			InstructionType: instructionType,
+			Info:            info,
+		}, nil
+	}
+
+	var numAccounts int
+	switch inst.Impl.(type) {
+	case *InitializeMint2, *InitializeMultisig2, *SyncNative, *GetAccountDataSize,
+		*InitializeImmutableOwner, *AmountToUiAmount, *UiAmountToAmount:
+		numAccounts = 1
+	case *InitializeMint, *InitializeAccount3, *InitializeMultisig, *Revoke, *SetAuthority:
+		numAccounts = 2
+	case *InitializeAccount2, *Transfer, *Approve, *MintTo, *Burn, *CloseAccount,
+		*FreezeAccount, *ThawAccount, *MintToChecked, *BurnChecked:
+		numAccounts = 3
+	case *InitializeAccount, *TransferChecked, *ApproveChecked:
+		numAccounts = 4
+	default:
+		return nil, fmt.Errorf("unsupported instruction type %T", inst.Impl)
+	}
+	if len(accounts) < numAccounts {
+		return nil, fmt.Errorf("not enough accounts: expected %d, got %d", numAccounts, len(accounts))
+	}
+
+	switch impl := inst.Impl.(type) {
+	case *InitializeMint:
+		info := map[string]interface{}{
+			"mint":          account(0),
+			"decimals":      *impl.Decimals,
+			"mintAuthority": impl.MintAuthority.String(),
+			"rentSysvar":    account(1),
+		}
+		if impl.FreezeAuthority != nil {
+			info["freezeAuthority"] = impl.FreezeAuthority.String()
+		}
+		return newInfo("initializeMint", info)
+	case *InitializeMint2:
+		info := map[string]interface{}{
+			"mint":          account(0),
+			"decimals":      *impl.Decimals,
+			"mintAuthority": impl.MintAuthority.String(),
+		}
+		if impl.FreezeAuthority != nil {
+			info["freezeAuthority"] = impl.FreezeAuthority.String()
+		}
+		return newInfo("initializeMint2", info)
+	case *InitializeAccount:
+		return newInfo("initializeAccount", map[string]interface{}{
+			"account":    account(0),
+			"mint":       account(1),
+			"owner":      account(2),
+			"rentSysvar": account(3),
+		})
+	case *InitializeAccount2:
+		return newInfo("initializeAccount2", map[string]interface{}{
+			"account":    account(0),
+			"mint":       account(1),
+			"owner":      impl.Owner.String(),
+			"rentSysvar": account(2),
+		})
+	case *InitializeAccount3:
+		return newInfo("initializeAccount3", map[string]interface{}{
+			"account": account(0),
+			"mint":    account(1),
+			"owner":   impl.Owner.String(),
+		})
+	case *InitializeMultisig:
+		signers := make([]string, 0, len(accounts)-2)
+		for i := 2; i < len(accounts); i++ {
+			signers = append(signers, account(i))
+		}
+		return newInfo("initializeMultisig", map[string]interface{}{
+			"multisig":   account(0),
+			"rentSysvar": account(1),
+			"signers":    signers,
+			"m":          *impl.M,
+		})
+	case *InitializeMultisig2:
+		signers := make([]string, 0, len(accounts)-1)
+		for i := 1; i < len(accounts); i++ {
+			signers = append(signers, account(i))
+		}
+		return newInfo("initializeMultisig2", map[string]interface{}{
+			"multisig": account(0),
+			"signers":  signers,
+			"m":        *impl.M,
+		})
+	case *Transfer:
+		info := map[string]interface{}{
+			"source":      account(0),
+			"destination": account(1),
+			"amount":      strconv.FormatUint(*impl.Amount, 10),
+		}
+		addSigners(info, 2, "authority", "multisigAuthority")
+		return newInfo("transfer", info)
+	case *Approve:
+		info := map[string]interface{}{
+			"source":   account(0),
+			"delegate": account(1),
+			"amount":   strconv.FormatUint(*impl.Amount, 10),
+		}
+		addSigners(info, 2, "owner", "multisigOwner")
+		return newInfo("approve", info)
+	case *Revoke:
+		info := map[string]interface{}{
+			"source": account(0),
+		}
+		addSigners(info, 1, "owner", "multisigOwner")
+		return newInfo("revoke", info)
+	case *SetAuthority:
+		var authorityType, ownedField string
+		switch *impl.AuthorityType {
+		case AuthorityMintTokens:
+			authorityType, ownedField = "mintTokens", "mint"
+		case AuthorityFreezeAccount:
+			authorityType, ownedField = "freezeAccount", "mint"
+		case AuthorityAccountOwner:
+			authorityType, ownedField = "accountOwner", "account"
+		case AuthorityCloseAccount:
+			authorityType, ownedField = "closeAccount", "account"
+		default:
+			return nil, fmt.Errorf("unsupported authority type: %d", *impl.AuthorityType)
+		}
+		var newAuthority interface{}
+		if impl.NewAuthority != nil {
+			newAuthority = impl.NewAuthority.String()
+		}
+		info := map[string]interface{}{
+			ownedField:      account(0),
+			"authorityType": authorityType,
+			"newAuthority":  newAuthority,
+		}
+		addSigners(info, 1, "authority", "multisigAuthority")
+		return newInfo("setAuthority", info)
+	case *MintTo:
+		info := map[string]interface{}{
+			"mint":    account(0),
+			"account": account(1),
+			"amount":  strconv.FormatUint(*impl.Amount, 10),
+		}
+		addSigners(info, 2, "mintAuthority", "multisigMintAuthority")
+		return newInfo("mintTo", info)
+	case *Burn:
+		info := map[string]interface{}{
+			"account": account(0),
+			"mint":    account(1),
+			"amount":  strconv.FormatUint(*impl.Amount, 10),
+		}
+		addSigners(info, 2, "authority", "multisigAuthority")
+		return newInfo("burn", info)
+	case *CloseAccount:
+		info := map[string]interface{}{
+			"account":     account(0),
+			"destination": account(1),
+		}
+		addSigners(info, 2, "owner", "multisigOwner")
+		return newInfo("closeAccount", info)
+	case *FreezeAccount:
+		info := map[string]interface{}{
+			"account": account(0),
+			"mint":    account(1),
+		}
+		addSigners(info, 2, "freezeAuthority", "multisigFreezeAuthority")
+		return newInfo("freezeAccount", info)
+	case *ThawAccount:
+		info := map[string]interface{}{
+			"account": account(0),
+			"mint":    account(1),
+		}
+		addSigners(info, 2, "freezeAuthority", "multisigFreezeAuthority")
+		return newInfo("thawAccount", info)
+	case *TransferChecked:
+		info := map[string]interface{}{
+			"source":      account(0),
+			"mint":        account(1),
+			"destination": account(2),
+			"tokenAmount": rpc.NewUiTokenAmount(*impl.Amount, *impl.Decimals),
+		}
+		addSigners(info, 3, "authority", "multisigAuthority")
+		return newInfo("transferChecked", info)
+	case *ApproveChecked:
+		info := map[string]interface{}{
+			"source":      account(0),
+			"mint":        account(1),
+			"delegate":    account(2),
+			"tokenAmount": rpc.NewUiTokenAmount(*impl.Amount, *impl.Decimals),
+		}
+		addSigners(info, 3, "owner", "multisigOwner")
+		return newInfo("approveChecked", info)
+	case *MintToChecked:
+		info := map[string]interface{}{
+			"mint":        account(0),
+			"account":     account(1),
+			"tokenAmount": rpc.NewUiTokenAmount(*impl.Amount, *impl.Decimals),
+		}
+		addSigners(info, 2, "mintAuthority", "multisigMintAuthority")
+		return newInfo("mintToChecked", info)
+	case *BurnChecked:
+		info := map[string]interface{}{
+			"account":     account(0),
+			"mint":        account(1),
+			"tokenAmount": rpc.NewUiTokenAmount(*impl.Amount, *impl.Decimals),
+		}
+		addSigners(info, 2, "authority", "multisigAuthority")
+		return newInfo("burnChecked", info)
+	case *SyncNative:
+		return newInfo("syncNative", map[string]interface{}{
+			"account": account(0),
+		})
+	case *GetAccountDataSize:
+		return newInfo("getAccountDataSize", map[string]interface{}{
+			"mint": account(0),
+		})
+	case *InitializeImmutableOwner:
+		return newInfo("initializeImmutableOwner", map[string]interface{}{
+			"account": account(0),
+		})
+	case *AmountToUiAmount:
+		return newInfo("amountToUiAmount", map[string]interface{}{
+			"mint":   account(0),
+			"amount": strconv.FormatUint(*impl.Amount, 10),
+		})
+	case *UiAmountToAmount:
+		return newInfo("uiAmountToAmount", map[string]interface{}{
+			"mint":     account(0),
+			"uiAmount": *impl.UiAmount,
+		})
+	}
+	return nil, fmt.Errorf("unsupported instruction type %T", inst.Impl)
+}
 
 func (mint *Mint) Decode(data []byte) error {
-	mint = new(Mint)
 	dec := bin.NewBinDecoder(data)
-	if err := dec.Decode(&mint); err != nil {
+	if err := dec.Decode(mint); err != nil {
 		return fmt.Errorf("unable to decode mint: %w", err)
 	}
 	return nil
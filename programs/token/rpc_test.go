@@ -0,0 +1,292 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeAccount(t *testing.T, v interface{}) []byte {
+	buf := new(bytes.Buffer)
+	require.NoError(t, bin.NewBinEncoder(buf).Encode(v))
+	return buf.Bytes()
+}
+
+func TestParseAccountData(t *testing.T) {
+	authority := solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	decimals := uint8(6)
+
+	t.Run("mint", func(t *testing.T) {
+		data := encodeAccount(t, &Mint{
+			MintAuthority: authority.ToPointer(),
+			Supply:        1890000009537801,
+			Decimals:      6,
+			IsInitialized: true,
+		})
+		require.Len(t, data, MINT_SIZE)
+
+		parsed, err := rpc.ParseAccountData(ProgramID, data)
+		require.NoError(t, err)
+		got, err := json.Marshal(parsed)
+		require.NoError(t, err)
+		// As returned by getAccountInfo with the jsonParsed encoding.
+		require.JSONEq(t,
+			fmt.Sprintf(`{
+				"parsed": {
+					"info": {
+						"decimals": 6,
+						"freezeAuthority": null,
+						"isInitialized": true,
+						"mintAuthority": %q,
+						"supply": "1890000009537801"
+					},
+					"type": "mint"
+				},
+				"program": "spl-token",
+				"space": 82
+			}`, authority),
+			string(got),
+		)
+	})
+
+	t.Run("account", func(t *testing.T) {
+		data := encodeAccount(t, &Account{
+			Mint:            mint,
+			Owner:           authority,
+			Amount:          1500000,
+			Delegate:        authority.ToPointer(),
+			State:           Initialized,
+			DelegatedAmount: 10,
+		})
+		require.Len(t, data, ACCOUNT_SIZE)
+
+		// The decimals are read from the mint.
+		_, err := rpc.ParseAccountData(ProgramID, data)
+		require.Error(t, err)
+
+		parsed, err := rpc.ParseAccountDataWithOpts(ProgramID, data, &rpc.ParseAccountOpts{TokenDecimals: &decimals})
+		require.NoError(t, err)
+		got, err := json.Marshal(parsed)
+		require.NoError(t, err)
+		require.JSONEq(t,
+			fmt.Sprintf(`{
+				"parsed": {
+					"info": {
+						"delegate": %q,
+						"delegatedAmount": {
+							"amount": "10",
+							"decimals": 6,
+							"uiAmount": 1e-05,
+							"uiAmountString": "0.00001"
+						},
+						"isNative": false,
+						"mint": %q,
+						"owner": %q,
+						"state": "initialized",
+						"tokenAmount": {
+							"amount": "1500000",
+							"decimals": 6,
+							"uiAmount": 1.5,
+							"uiAmountString": "1.5"
+						}
+					},
+					"type": "account"
+				},
+				"program": "spl-token",
+				"space": 165
+			}`, authority, mint, authority),
+			string(got),
+		)
+	})
+
+	t.Run("native account", func(t *testing.T) {
+		rentExemptReserve := uint64(2039280)
+		data := encodeAccount(t, &Account{
+			Mint:     solana.SolMint,
+			Owner:    authority,
+			Amount:   1000000000,
+			State:    Frozen,
+			IsNative: &rentExemptReserve,
+		})
+		nativeDecimals := uint8(9)
+		parsed, err := rpc.ParseAccountDataWithOpts(ProgramID, data, &rpc.ParseAccountOpts{TokenDecimals: &nativeDecimals})
+		require.NoError(t, err)
+		got, err := json.Marshal(parsed.Parsed.Info)
+		require.NoError(t, err)
+		require.JSONEq(t,
+			fmt.Sprintf(`{
+				"isNative": true,
+				"mint": "So11111111111111111111111111111111111111112",
+				"owner": %q,
+				"rentExemptReserve": {
+					"amount": "2039280",
+					"decimals": 9,
+					"uiAmount": 0.00203928,
+					"uiAmountString": "0.00203928"
+				},
+				"state": "frozen",
+				"tokenAmount": {
+					"amount": "1000000000",
+					"decimals": 9,
+					"uiAmount": 1.0,
+					"uiAmountString": "1"
+				}
+			}`, authority),
+			string(got),
+		)
+	})
+
+	t.Run("multisig", func(t *testing.T) {
+		multisig := &Multisig{M: 1, N: 2, IsInitialized: true}
+		multisig.Signers[0] = authority
+		multisig.Signers[1] = mint
+		data := encodeAccount(t, multisig)
+		require.Len(t, data, MULTISIG_SIZE)
+
+		parsed, err := rpc.ParseAccountData(ProgramID, data)
+		require.NoError(t, err)
+		require.Equal(t, "multisig", parsed.Parsed.Type)
+		got, err := json.Marshal(parsed.Parsed.Info)
+		require.NoError(t, err)
+		require.JSONEq(t,
+			fmt.Sprintf(`{
+				"isInitialized": true,
+				"numRequiredSigners": 1,
+				"numValidSigners": 2,
+				"signers": [%q, %q]
+			}`, authority, mint),
+			string(got),
+		)
+	})
+
+	t.Run("token-2022", func(t *testing.T) {
+		data := encodeAccount(t, &Account{
+			Mint:   mint,
+			Owner:  authority,
+			Amount: 42,
+			State:  Initialized,
+		})
+		// Account type, then an ImmutableOwner extension.
+		data = append(data, 2, 7, 0, 0, 0)
+
+		parsed, err := rpc.ParseAccountDataWithOpts(solana.Token2022ProgramID, data, &rpc.ParseAccountOpts{TokenDecimals: &decimals})
+		require.NoError(t, err)
+		require.Equal(t, "spl-token-2022", parsed.Program)
+		require.Equal(t, "account", parsed.Parsed.Type)
+		require.Equal(t, uint64(len(data)), parsed.Space)
+		require.Equal(t, "0.000042", parsed.Parsed.Info.(*rpc.ParsedTokenAccount).TokenAmount.UiAmountString)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := rpc.ParseAccountData(ProgramID, []byte{1, 2, 3})
+		require.Error(t, err)
+
+		// Not initialized.
+		_, err = rpc.ParseAccountData(ProgramID, make([]byte, MINT_SIZE))
+		require.Error(t, err)
+	})
+}
+
+func TestParseInstruction(t *testing.T) {
+	source := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	destination := solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	owner := solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+	signer := solana.MustPublicKeyFromBase58("SysvarRent111111111111111111111111111111111")
+
+	for _, tt := range []struct {
+		name        string
+		instruction solana.Instruction
+		parsed      string
+	}{
+		{
+			name:        "transfer",
+			instruction: NewTransferInstruction(1000, source, destination, owner, nil).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"amount": "1000",
+					"authority": %q,
+					"destination": %q,
+					"source": %q
+				},
+				"type": "transfer"
+			}`, owner, destination, source),
+		},
+		{
+			name:        "transferChecked",
+			instruction: NewTransferCheckedInstruction(1500000, 6, source, mint, destination, owner, nil).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"authority": %q,
+					"destination": %q,
+					"mint": %q,
+					"source": %q,
+					"tokenAmount": {
+						"amount": "1500000",
+						"decimals": 6,
+						"uiAmount": 1.5,
+						"uiAmountString": "1.5"
+					}
+				},
+				"type": "transferChecked"
+			}`, owner, destination, mint, source),
+		},
+		{
+			name:        "transfer with multisig",
+			instruction: NewTransferInstruction(1000, source, destination, owner, []solana.PublicKey{signer}).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"amount": "1000",
+					"destination": %q,
+					"multisigAuthority": %q,
+					"signers": [%q],
+					"source": %q
+				},
+				"type": "transfer"
+			}`, destination, owner, signer, source),
+		},
+		{
+			name:        "setAuthority",
+			instruction: NewSetAuthorityInstruction(AuthorityCloseAccount, destination, source, owner, nil).Build(),
+			parsed: fmt.Sprintf(`{
+				"info": {
+					"account": %q,
+					"authority": %q,
+					"authorityType": "closeAccount",
+					"newAuthority": %q
+				},
+				"type": "setAuthority"
+			}`, source, owner, destination),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.instruction.Data()
+			require.NoError(t, err)
+			parsed := rpc.ParseInstruction(ProgramID, tt.instruction.Accounts(), data)
+			require.Equal(t, "spl-token", parsed.Program)
+			got, err := json.Marshal(parsed.Parsed)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.parsed, string(got))
+		})
+	}
+}
@@ -0,0 +1,488 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accountcache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+const (
+	DefaultResyncDelay    = time.Second
+	DefaultMaxResyncDelay = 30 * time.Second
+)
+
+// ConnectFunc opens a websocket connection; the cache closes the clients it gets.
+//
+//	connect := func(ctx context.Context) (*ws.Client, error) {
+//		return ws.Connect(ctx, rpc.MainNetBeta_WS)
+//	}
+type ConnectFunc func(ctx context.Context) (*ws.Client, error)
+
+// Decoder decodes the data of an account.
+type Decoder[T any] func(pubkey solana.PublicKey, data []byte) (T, error)
+
+// BinDecoder returns a Decoder that decodes the data into a T with bin.EncodingBin.
+func BinDecoder[T any]() Decoder[*T] {
+	return encodingDecoder[T](bin.EncodingBin)
+}
+
+// BorshDecoder returns a Decoder that decodes the data into a T with bin.EncodingBorsh.
+func BorshDecoder[T any]() Decoder[*T] {
+	return encodingDecoder[T](bin.EncodingBorsh)
+}
+
+func encodingDecoder[T any](encoding bin.Encoding) Decoder[*T] {
+	return func(_ solana.PublicKey, data []byte) (*T, error) {
+		value := new(T)
+		if err := bin.NewDecoderWithEncoding(data, encoding).Decode(value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// Entry is the state of an account in the cache.
+type Entry[T any] struct {
+	Pubkey solana.PublicKey
+	// The slot at which the account was read.
+	Slot uint64
+	// Nil if the account doesn't exist.
+	Account *rpc.Account
+	// The decoded data (if there is a decoder, and the account exists).
+	Value     T
+	DecodeErr error
+}
+
+type AccountCacheOpts[T any] struct {
+	// Defaults to rpc.CommitmentConfirmed.
+	Commitment rpc.CommitmentType
+
+	// (optional) Decodes the data of the accounts into Entry.Value.
+	Decoder Decoder[T]
+
+	// (optional) Called when an account changes (prev is nil, or prev.Account is nil,
+	// for a new account; next.Account is nil for a deleted one). The calls are sequential, in the order
+	// the changes are applied; they must not block for long, as they hold the updates.
+	OnChange func(prev, next *Entry[T])
+
+	// (optional) Called for the errors that don't stop the cache (e.g. the websocket
+	// connection was lost, and the cache is resyncing; or some data could not be decoded).
+	OnError func(err error)
+
+	// Delay before resyncing after a connection error, doubled after each failed
+	// attempt up to MaxResyncDelay. Default to DefaultResyncDelay and DefaultMaxResyncDelay.
+	ResyncDelay    time.Duration
+	MaxResyncDelay time.Duration
+}
+
+// AccountCache keeps the state of a set of accounts (or the accounts of a program) up to date:
+// it subscribes to the accounts, loads a snapshot with getMultipleAccounts (or getProgramAccounts),
+// and then applies the notifications. Each account only moves forward in slots:
+// the updates older than the current state of an account are ignored.
+// When the websocket connection is lost, the cache reconnects, and loads a new snapshot.
+//
+//	cache := accountcache.NewAccountCache(rpcClient, connect, accounts, accountcache.AccountCacheOpts[*token.Account]{
+//		Decoder: accountcache.BinDecoder[token.Account](),
+//	})
+//	err := cache.Start(ctx)
+//	...
+//	defer cache.Close()
+//	entry := cache.Get(account)
+type AccountCache[T any] struct {
+	rpcClient *rpc.Client
+	connect   ConnectFunc
+	opts      AccountCacheOpts[T]
+
+	// The accounts, or the program and filters.
+	accounts  []solana.PublicKey
+	programID solana.PublicKey
+	filters   []rpc.RPCFilter
+	isProgram bool
+
+	// Serializes the updates, and the OnChange calls.
+	applyMu sync.Mutex
+	mu      sync.RWMutex
+	entries map[solana.PublicKey]*Entry[T]
+	slot    uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAccountCache creates a cache of the provided accounts.
+func NewAccountCache[T any](
+	rpcClient *rpc.Client,
+	connect ConnectFunc,
+	accounts []solana.PublicKey,
+	opts AccountCacheOpts[T],
+) *AccountCache[T] {
+	c := newAccountCache(rpcClient, connect, opts)
+	c.accounts = append([]solana.PublicKey(nil), accounts...)
+	return c
+}
+
+// NewProgramAccountCache creates a cache of the accounts owned by the program,
+// matching the (optional) filters.
+func NewProgramAccountCache[T any](
+	rpcClient *rpc.Client,
+	connect ConnectFunc,
+	programID solana.PublicKey,
+	filters []rpc.RPCFilter,
+	opts AccountCacheOpts[T],
+) *AccountCache[T] {
+	c := newAccountCache(rpcClient, connect, opts)
+	c.programID = programID
+	c.filters = filters
+	c.isProgram = true
+	return c
+}
+
+func newAccountCache[T any](rpcClient *rpc.Client, connect ConnectFunc, opts AccountCacheOpts[T]) *AccountCache[T] {
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if opts.ResyncDelay <= 0 {
+		opts.ResyncDelay = DefaultResyncDelay
+	}
+	if opts.MaxResyncDelay <= 0 {
+		opts.MaxResyncDelay = DefaultMaxResyncDelay
+	}
+	return &AccountCache[T]{
+		rpcClient: rpcClient,
+		connect:   connect,
+		opts:      opts,
+		entries:   make(map[solana.PublicKey]*Entry[T]),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start loads the cache, and then keeps it up to date in the background
+// until the context is done or Close is called.
+func (c *AccountCache[T]) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	gen, err := c.sync(ctx)
+	if err != nil {
+		cancel()
+		close(c.done)
+		return err
+	}
+	c.cancel = cancel
+	go c.run(ctx, gen)
+	return nil
+}
+
+// Close stops the updates, and waits for the background goroutine to exit.
+func (c *AccountCache[T]) Close() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// Get returns the state of the account, or nil if it is not in the cache.
+func (c *AccountCache[T]) Get(pubkey solana.PublicKey) *Entry[T] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries[pubkey]
+}
+
+// Entries returns the state of all the accounts in the cache.
+func (c *AccountCache[T]) Entries() []*Entry[T] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]*Entry[T], 0, len(c.entries))
+	for _, entry := range c.entries {
+		out = append(out, entry)
+	}
+	return out
+}
+
+// Slot returns the highest slot applied to the cache.
+func (c *AccountCache[T]) Slot() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.slot
+}
+
+func (c *AccountCache[T]) onError(err error) {
+	if c.opts.OnError != nil {
+		c.opts.OnError(err)
+	}
+}
+
+// generation is a websocket connection with its subscriptions.
+type generation struct {
+	wsClient *ws.Client
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	// The first error of the subscriptions.
+	errs chan error
+}
+
+func (g *generation) fail(err error) {
+	select {
+	case g.errs <- err:
+	default:
+	}
+}
+
+func (g *generation) close() {
+	g.cancel()
+	g.wsClient.Close()
+	g.wg.Wait()
+}
+
+func (c *AccountCache[T]) run(ctx context.Context, gen *generation) {
+	defer close(c.done)
+	for {
+		select {
+		case <-ctx.Done():
+			gen.close()
+			return
+		case err := <-gen.errs:
+			gen.close()
+			c.onError(fmt.Errorf("subscription failed, resyncing: %w", err))
+		}
+
+		delay := c.opts.ResyncDelay
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			var err error
+			gen, err = c.sync(ctx)
+			if err == nil {
+				break
+			}
+			c.onError(fmt.Errorf("unable to resync: %w", err))
+			delay *= 2
+			if delay > c.opts.MaxResyncDelay {
+				delay = c.opts.MaxResyncDelay
+			}
+		}
+	}
+}
+
+// sync connects, subscribes, and then loads the snapshot,
+// so that no update is missed between the snapshot and the subscriptions.
+func (c *AccountCache[T]) sync(ctx context.Context) (*generation, error) {
+	wsClient, err := c.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect: %w", err)
+	}
+	genCtx, cancel := context.WithCancel(ctx)
+	gen := &generation{
+		wsClient: wsClient,
+		cancel:   cancel,
+		errs:     make(chan error, 1),
+	}
+
+	if err := c.subscribe(genCtx, gen); err != nil {
+		gen.close()
+		return nil, err
+	}
+	if err := c.loadSnapshot(ctx); err != nil {
+		gen.close()
+		return nil, err
+	}
+	return gen, nil
+}
+
+func (c *AccountCache[T]) subscribe(ctx context.Context, gen *generation) error {
+	follow := func(recv func(ctx context.Context) error, unsubscribe func()) {
+		gen.wg.Add(1)
+		go func() {
+			defer gen.wg.Done()
+			defer unsubscribe()
+			for {
+				if err := recv(ctx); err != nil {
+					if ctx.Err() == nil {
+						gen.fail(err)
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	if c.isProgram {
+		sub, err := gen.wsClient.ProgramSubscribeWithOpts(c.programID, c.opts.Commitment, solana.EncodingBase64, c.filters)
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to program %s: %w", c.programID, err)
+		}
+		follow(func(ctx context.Context) error {
+			res, err := sub.Recv(ctx)
+			if err != nil {
+				return err
+			}
+			c.apply(res.Value.Pubkey, res.Context.Slot, res.Value.Account)
+			return nil
+		}, sub.Unsubscribe)
+		return nil
+	}
+
+	for _, account := range c.accounts {
+		account := account
+		sub, err := gen.wsClient.AccountSubscribeWithOpts(account, c.opts.Commitment, solana.EncodingBase64)
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to account %s: %w", account, err)
+		}
+		follow(func(ctx context.Context) error {
+			res, err := sub.Recv(ctx)
+			if err != nil {
+				return err
+			}
+			c.apply(account, res.Context.Slot, res.Value)
+			return nil
+		}, sub.Unsubscribe)
+	}
+	return nil
+}
+
+func (c *AccountCache[T]) loadSnapshot(ctx context.Context) error {
+	if !c.isProgram {
+		if len(c.accounts) == 0 {
+			return nil
+		}
+		opts := &rpc.GetMultipleAccountsChunkedOpts{ConsistentSlot: true}
+		opts.Commitment = c.opts.Commitment
+		opts.Encoding = solana.EncodingBase64
+		res, err := c.rpcClient.GetMultipleAccountsChunked(ctx, c.accounts, opts)
+		if err != nil {
+			return fmt.Errorf("unable to load accounts: %w", err)
+		}
+		for i, account := range c.accounts {
+			var value *rpc.Account
+			if i < len(res.Value) {
+				value = res.Value[i]
+			}
+			c.apply(account, res.Context.Slot, value)
+		}
+		return nil
+	}
+
+	conf := rpc.M{
+		"encoding":    solana.EncodingBase64,
+		"commitment":  c.opts.Commitment,
+		"withContext": true,
+	}
+	if len(c.filters) > 0 {
+		conf["filters"] = c.filters
+	}
+	var res struct {
+		Context rpc.Context         `json:"context"`
+		Value   []*rpc.KeyedAccount `json:"value"`
+	}
+	err := c.rpcClient.RPCCallForInto(ctx, &res, "getProgramAccounts", []interface{}{c.programID, conf})
+	if err != nil {
+		return fmt.Errorf("unable to load program accounts: %w", err)
+	}
+	slot := res.Context.Slot
+	found := make(map[solana.PublicKey]bool, len(res.Value))
+	for _, keyed := range res.Value {
+		if keyed == nil {
+			continue
+		}
+		found[keyed.Pubkey] = true
+		c.apply(keyed.Pubkey, slot, keyed.Account)
+	}
+	// The accounts not in the snapshot were deleted (or no longer match the filters).
+	for _, entry := range c.Entries() {
+		if !found[entry.Pubkey] {
+			c.apply(entry.Pubkey, slot, nil)
+		}
+	}
+	return nil
+}
+
+// apply updates the state of an account, unless the update is older.
+func (c *AccountCache[T]) apply(pubkey solana.PublicKey, slot uint64, account *rpc.Account) {
+	if account != nil && account.Lamports == 0 {
+		// Closed account.
+		account = nil
+	}
+
+	c.applyMu.Lock()
+	defer c.applyMu.Unlock()
+
+	c.mu.Lock()
+	prev := c.entries[pubkey]
+	if prev != nil && slot < prev.Slot {
+		c.mu.Unlock()
+		return
+	}
+	if slot > c.slot {
+		c.slot = slot
+	}
+	if prev == nil && account == nil {
+		// Not a change: the account doesn't exist (yet).
+		if !c.isProgram {
+			c.entries[pubkey] = &Entry[T]{Pubkey: pubkey, Slot: slot}
+		}
+		c.mu.Unlock()
+		return
+	}
+	if prev != nil && sameAccount(prev.Account, account) {
+		updated := *prev
+		updated.Slot = slot
+		c.entries[pubkey] = &updated
+		c.mu.Unlock()
+		return
+	}
+
+	next := &Entry[T]{
+		Pubkey:  pubkey,
+		Slot:    slot,
+		Account: account,
+	}
+	if account != nil && c.opts.Decoder != nil {
+		next.Value, next.DecodeErr = c.opts.Decoder(pubkey, account.Data.GetBinary())
+	}
+	if account == nil && c.isProgram {
+		delete(c.entries, pubkey)
+	} else {
+		c.entries[pubkey] = next
+	}
+	c.mu.Unlock()
+
+	if next.DecodeErr != nil {
+		c.onError(fmt.Errorf("unable to decode account %s at slot %d: %w", pubkey, slot, next.DecodeErr))
+	}
+	if c.opts.OnChange != nil {
+		c.opts.OnChange(prev, next)
+	}
+}
+
+func sameAccount(a, b *rpc.Account) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Lamports == b.Lamports &&
+		a.Owner == b.Owner &&
+		a.Executable == b.Executable &&
+		bytes.Equal(a.Data.GetBinary(), b.Data.GetBinary())
+}
@@ -0,0 +1,314 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accountcache
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	accountA = solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	accountB = solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	accountC = solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+)
+
+func decodeByte(_ solana.PublicKey, data []byte) (byte, error) {
+	if len(data) != 1 {
+		return 0, errors.New("invalid data")
+	}
+	return data[0], nil
+}
+
+func accountJSON(owner solana.PublicKey, data byte) map[string]interface{} {
+	return map[string]interface{}{
+		"lamports":   1,
+		"owner":      owner.String(),
+		"executable": false,
+		"rentEpoch":  0,
+		"data":       []string{base64.StdEncoding.EncodeToString([]byte{data}), "base64"},
+	}
+}
+
+// mockNode is a JSON-RPC and websocket server; each websocket connection
+// accepts the subscriptions, and can be notified or dropped.
+type mockNode struct {
+	t       *testing.T
+	rpcURL  string
+	wsURL   string
+	methods chan string
+
+	mu       sync.Mutex
+	snapshot func(method string) interface{}
+	conns    chan *websocket.Conn
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+}
+
+func newMockNode(t *testing.T) *mockNode {
+	node := &mockNode{
+		t:       t,
+		methods: make(chan string, 100),
+		conns:   make(chan *websocket.Conn, 10),
+	}
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		node.mu.Lock()
+		result := node.snapshot(body.Method)
+		node.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": body.ID, "result": result})
+	}))
+	t.Cleanup(rpcServer.Close)
+
+	wsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		node.conns <- conn
+		var nextSubID uint64
+		for {
+			var req struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			node.methods <- req.Method
+			var result interface{} = true
+			if strings.HasSuffix(req.Method, "Subscribe") {
+				nextSubID++
+				result = nextSubID
+			}
+			node.writeMu.Lock()
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+			node.writeMu.Unlock()
+		}
+	}))
+	t.Cleanup(wsServer.Close)
+
+	node.rpcURL = rpcServer.URL
+	node.wsURL = "ws" + strings.TrimPrefix(wsServer.URL, "http")
+	return node
+}
+
+func (node *mockNode) setSnapshot(snapshot func(method string) interface{}) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.snapshot = snapshot
+}
+
+func (node *mockNode) connect(ctx context.Context) (*ws.Client, error) {
+	client, err := ws.Connect(ctx, node.wsURL)
+	if err != nil {
+		return nil, err
+	}
+	conn := <-node.conns
+	node.writeMu.Lock()
+	node.conn = conn
+	node.writeMu.Unlock()
+	return client, nil
+}
+
+func (node *mockNode) notify(subID uint64, result interface{}) {
+	data, err := json.Marshal(result)
+	require.NoError(node.t, err)
+	node.writeMu.Lock()
+	defer node.writeMu.Unlock()
+	require.NoError(node.t, node.conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+		`{"jsonrpc":"2.0","method":"notification","params":{"result":%s,"subscription":%d}}`, data, subID,
+	))))
+}
+
+func (node *mockNode) drop() {
+	node.writeMu.Lock()
+	defer node.writeMu.Unlock()
+	node.conn.Close()
+}
+
+type change struct {
+	pubkey solana.PublicKey
+	slot   uint64
+	prev   *byte
+	next   *byte
+}
+
+func recordChanges() (func(prev, next *Entry[byte]), chan change) {
+	changes := make(chan change, 100)
+	value := func(entry *Entry[byte]) *byte {
+		if entry == nil || entry.Account == nil {
+			return nil
+		}
+		return &entry.Value
+	}
+	return func(prev, next *Entry[byte]) {
+		changes <- change{pubkey: next.Pubkey, slot: next.Slot, prev: value(prev), next: value(next)}
+	}, changes
+}
+
+func nextChange(t *testing.T, changes chan change) change {
+	select {
+	case got := <-changes:
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change")
+		return change{}
+	}
+}
+
+func bytePtr(b byte) *byte {
+	return &b
+}
+
+func TestAccountCache(t *testing.T) {
+	node := newMockNode(t)
+	owner := solana.SystemProgramID
+	node.setSnapshot(func(method string) interface{} {
+		require.Equal(t, "getMultipleAccounts", method)
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 100},
+			"value":   []interface{}{accountJSON(owner, 1), nil},
+		}
+	})
+
+	onChange, changes := recordChanges()
+	errs := make(chan error, 10)
+	cache := NewAccountCache(rpc.New(node.rpcURL), node.connect, []solana.PublicKey{accountA, accountB}, AccountCacheOpts[byte]{
+		Decoder:     decodeByte,
+		OnChange:    onChange,
+		OnError:     func(err error) { errs <- err },
+		ResyncDelay: time.Millisecond,
+	})
+	require.NoError(t, cache.Start(context.Background()))
+	defer cache.Close()
+	require.Equal(t, "accountSubscribe", <-node.methods)
+	require.Equal(t, "accountSubscribe", <-node.methods)
+
+	require.Equal(t, change{pubkey: accountA, slot: 100, next: bytePtr(1)}, nextChange(t, changes))
+	require.Equal(t, byte(1), cache.Get(accountA).Value)
+	require.Nil(t, cache.Get(accountB).Account)
+	require.Nil(t, cache.Get(accountC))
+	require.Equal(t, uint64(100), cache.Slot())
+
+	notification := func(slot uint64, data byte) interface{} {
+		return map[string]interface{}{"context": map[string]interface{}{"slot": slot}, "value": accountJSON(owner, data)}
+	}
+	// Older than the snapshot: ignored.
+	node.notify(1, notification(99, 9))
+	node.notify(1, notification(101, 2))
+	require.Equal(t, change{pubkey: accountA, slot: 101, prev: bytePtr(1), next: bytePtr(2)}, nextChange(t, changes))
+	node.notify(2, notification(102, 3))
+	require.Equal(t, change{pubkey: accountB, slot: 102, next: bytePtr(3)}, nextChange(t, changes))
+
+	// After a disconnection, the cache resubscribes and loads a new snapshot.
+	node.setSnapshot(func(method string) interface{} {
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 110},
+			"value":   []interface{}{accountJSON(owner, 5), accountJSON(owner, 3)},
+		}
+	})
+	node.drop()
+	require.Error(t, <-errs)
+	require.Equal(t, "accountSubscribe", <-node.methods)
+	require.Equal(t, "accountSubscribe", <-node.methods)
+	require.Equal(t, change{pubkey: accountA, slot: 110, prev: bytePtr(2), next: bytePtr(5)}, nextChange(t, changes))
+	require.Equal(t, uint64(110), cache.Get(accountB).Slot)
+	require.Equal(t, byte(3), cache.Get(accountB).Value)
+
+	// Undecodable data is reported.
+	invalid := notification(111, 0)
+	invalid.(map[string]interface{})["value"].(map[string]interface{})["data"] = []string{"AQI=", "base64"}
+	node.notify(1, invalid)
+	require.Equal(t, change{pubkey: accountA, slot: 111, prev: bytePtr(5), next: bytePtr(0)}, nextChange(t, changes))
+	require.Error(t, cache.Get(accountA).DecodeErr)
+	require.Error(t, <-errs)
+	require.Empty(t, changes)
+}
+
+func TestProgramAccountCache(t *testing.T) {
+	node := newMockNode(t)
+	programID := solana.TokenProgramID
+	keyed := func(pubkey solana.PublicKey, data byte) interface{} {
+		return map[string]interface{}{"pubkey": pubkey.String(), "account": accountJSON(programID, data)}
+	}
+	node.setSnapshot(func(method string) interface{} {
+		require.Equal(t, "getProgramAccounts", method)
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 50},
+			"value":   []interface{}{keyed(accountA, 1), keyed(accountB, 2)},
+		}
+	})
+
+	onChange, changes := recordChanges()
+	cache := NewProgramAccountCache(rpc.New(node.rpcURL), node.connect, programID, []rpc.RPCFilter{{DataSize: 1}}, AccountCacheOpts[byte]{
+		Decoder:     decodeByte,
+		OnChange:    onChange,
+		ResyncDelay: time.Millisecond,
+	})
+	require.NoError(t, cache.Start(context.Background()))
+	defer cache.Close()
+	require.Equal(t, "programSubscribe", <-node.methods)
+	nextChange(t, changes)
+	nextChange(t, changes)
+	require.Len(t, cache.Entries(), 2)
+
+	node.notify(1, map[string]interface{}{"context": map[string]interface{}{"slot": 51}, "value": keyed(accountC, 3)})
+	require.Equal(t, change{pubkey: accountC, slot: 51, next: bytePtr(3)}, nextChange(t, changes))
+
+	// The accounts missing from the new snapshot are removed.
+	node.setSnapshot(func(method string) interface{} {
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 60},
+			"value":   []interface{}{keyed(accountA, 1)},
+		}
+	})
+	node.drop()
+	require.Equal(t, "programSubscribe", <-node.methods)
+	removed := map[solana.PublicKey]change{}
+	for i := 0; i < 2; i++ {
+		got := nextChange(t, changes)
+		removed[got.pubkey] = got
+	}
+	require.Equal(t, map[solana.PublicKey]change{
+		accountB: {pubkey: accountB, slot: 60, prev: bytePtr(2)},
+		accountC: {pubkey: accountC, slot: 60, prev: bytePtr(3)},
+	}, removed)
+	require.Len(t, cache.Entries(), 1)
+	require.Equal(t, uint64(60), cache.Get(accountA).Slot)
+	require.Nil(t, cache.Get(accountB))
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// BalanceChanges are the balance changes of the accounts of a transaction.
+type BalanceChanges struct {
+	FeePayer solana.PublicKey
+	// Fee paid by the fee payer, not included in its lamport change.
+	Fee uint64
+
+	// Lamport changes of all the accounts, in the order of the account keys
+	// of the transaction (static keys, then loaded writable and readonly keys).
+	Lamports []*LamportChange
+
+	// Token changes, aggregated by (owner, mint), in order of first appearance.
+	Tokens []*TokenChange
+}
+
+type LamportChange struct {
+	Account solana.PublicKey
+	Pre     uint64
+	Post    uint64
+	// Post - Pre; for the fee payer, the fee is added back.
+	Change int64
+}
+
+type TokenChange struct {
+	// The owner of the token accounts; the token account itself
+	// if the owner was not recorded (transactions prior to solana v1.10).
+	Owner     solana.PublicKey
+	Mint      solana.PublicKey
+	ProgramID solana.PublicKey
+	Decimals  uint8
+
+	// The token accounts of the owner for the mint, that appear in the transaction.
+	Accounts solana.PublicKeySlice
+
+	// Raw amounts (i.e. ignoring the decimals), summed over the token accounts.
+	Pre    *big.Int
+	Post   *big.Int
+	Change *big.Int
+}
+
+// UiPre returns the amount before the transaction as a decimal string, accounting for decimals.
+func (c *TokenChange) UiPre() string {
+	return formatTokenAmount(c.Pre, c.Decimals)
+}
+
+// UiPost returns the amount after the transaction as a decimal string, accounting for decimals.
+func (c *TokenChange) UiPost() string {
+	return formatTokenAmount(c.Post, c.Decimals)
+}
+
+// UiChange returns the change as a decimal string, accounting for decimals (e.g. "-1.5").
+func (c *TokenChange) UiChange() string {
+	return formatTokenAmount(c.Change, c.Decimals)
+}
+
+// BalanceChanges returns the balance changes of the transaction (see TransactionBalanceChanges).
+func (res *GetTransactionResult) BalanceChanges() (*BalanceChanges, error) {
+	if res.Transaction == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+	tx, err := res.Transaction.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return TransactionBalanceChanges(tx, res.Meta)
+}
+
+// BalanceChanges returns the balance changes of the transaction (see TransactionBalanceChanges).
+func (twm TransactionWithMeta) BalanceChanges() (*BalanceChanges, error) {
+	if twm.Transaction == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+	tx, err := twm.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return TransactionBalanceChanges(tx, twm.Meta)
+}
+
+// TransactionBalanceChanges maps the pre and post balances of the meta
+// back to the accounts of the transaction (including the accounts loaded from
+// address lookup tables), and returns the lamport change of each account,
+// and the token change of each (owner, mint).
+func TransactionBalanceChanges(tx *solana.Transaction, meta *TransactionMeta) (*BalanceChanges, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("transaction meta is nil")
+	}
+	keys := tx.Message.AccountKeys
+	if !tx.Message.IsResolved() {
+		keys = append(append(append(solana.PublicKeySlice{}, keys...), meta.LoadedAddresses.Writable...), meta.LoadedAddresses.ReadOnly...)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("transaction has no accounts")
+	}
+	if len(meta.PreBalances) != len(keys) || len(meta.PostBalances) != len(keys) {
+		return nil, fmt.Errorf(
+			"got %d pre balances and %d post balances for %d accounts",
+			len(meta.PreBalances), len(meta.PostBalances), len(keys),
+		)
+	}
+
+	out := &BalanceChanges{
+		FeePayer: keys[0],
+		Fee:      meta.Fee,
+		Lamports: make([]*LamportChange, len(keys)),
+	}
+	for i, key := range keys {
+		change := &LamportChange{
+			Account: key,
+			Pre:     meta.PreBalances[i],
+			Post:    meta.PostBalances[i],
+			Change:  int64(meta.PostBalances[i]) - int64(meta.PreBalances[i]),
+		}
+		if i == 0 {
+			change.Change += int64(meta.Fee)
+		}
+		out.Lamports[i] = change
+	}
+
+	type tokenKey struct {
+		owner solana.PublicKey
+		mint  solana.PublicKey
+	}
+	byKey := make(map[tokenKey]*TokenChange)
+	seenAccount := make(map[tokenKey]map[uint16]bool)
+	add := func(balance TokenBalance, post bool) error {
+		if int(balance.AccountIndex) >= len(keys) {
+			return fmt.Errorf("token balance account index %d out of range", balance.AccountIndex)
+		}
+		if balance.UiTokenAmount == nil {
+			return fmt.Errorf("token balance of account %d has no amount", balance.AccountIndex)
+		}
+		amount, ok := new(big.Int).SetString(balance.UiTokenAmount.Amount, 10)
+		if !ok {
+			return fmt.Errorf("invalid token amount %q", balance.UiTokenAmount.Amount)
+		}
+		key := tokenKey{mint: balance.Mint, owner: keys[balance.AccountIndex]}
+		if balance.Owner != nil {
+			key.owner = *balance.Owner
+		}
+		change, ok := byKey[key]
+		if !ok {
+			change = &TokenChange{
+				Owner:    key.owner,
+				Mint:     key.mint,
+				Decimals: balance.UiTokenAmount.Decimals,
+				Pre:      new(big.Int),
+				Post:     new(big.Int),
+			}
+			byKey[key] = change
+			seenAccount[key] = make(map[uint16]bool)
+			out.Tokens = append(out.Tokens, change)
+		}
+		if balance.ProgramId != nil {
+			change.ProgramID = *balance.ProgramId
+		}
+		if !seenAccount[key][balance.AccountIndex] {
+			seenAccount[key][balance.AccountIndex] = true
+			change.Accounts = append(change.Accounts, keys[balance.AccountIndex])
+		}
+		if post {
+			change.Post.Add(change.Post, amount)
+		} else {
+			change.Pre.Add(change.Pre, amount)
+		}
+		return nil
+	}
+	for _, balance := range meta.PreTokenBalances {
+		if err := add(balance, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, balance := range meta.PostTokenBalances {
+		if err := add(balance, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range out.Tokens {
+		change.Change = new(big.Int).Sub(change.Post, change.Pre)
+	}
+	return out, nil
+}
+
+// formatTokenAmount formats a raw token amount as a decimal string.
+func formatTokenAmount(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+	if decimals == 0 {
+		return amount.String()
+	}
+	abs := new(big.Int).Abs(amount)
+	quo, rem := new(big.Int).QuoRem(abs, solana.DecimalsInBigInt(uint32(decimals)), new(big.Int))
+	out := quo.String()
+	if rem.Sign() != 0 {
+		frac := rem.String()
+		frac = strings.Repeat("0", int(decimals)-len(frac)) + frac
+		out += "." + strings.TrimRight(frac, "0")
+	}
+	if amount.Sign() < 0 {
+		out = "-" + out
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionBalanceChanges(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	payerTokenAccount := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	// Loaded from an address lookup table.
+	recipientTokenAccount := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+
+	tx := &solana.Transaction{
+		Message: solana.Message{
+			AccountKeys: solana.PublicKeySlice{payer, payerTokenAccount, solana.TokenProgramID},
+		},
+	}
+	tokenBalance := func(index uint16, owner solana.PublicKey, amount string) TokenBalance {
+		return TokenBalance{
+			AccountIndex:  index,
+			Owner:         &owner,
+			ProgramId:     &solana.TokenProgramID,
+			Mint:          mint,
+			UiTokenAmount: &UiTokenAmount{Amount: amount, Decimals: 6},
+		}
+	}
+	meta := &TransactionMeta{
+		Fee:          5000,
+		PreBalances:  []uint64{10_000_000, 2_039_280, 1, 0},
+		PostBalances: []uint64{10_000_000 - 5000 - 2_039_280, 2_039_280, 1, 2_039_280},
+		PreTokenBalances: []TokenBalance{
+			tokenBalance(1, payer, "2500000"),
+		},
+		PostTokenBalances: []TokenBalance{
+			tokenBalance(1, payer, "1000000"),
+			tokenBalance(3, recipient, "1500000"),
+		},
+		LoadedAddresses: LoadedAddresses{
+			Writable: solana.PublicKeySlice{recipientTokenAccount},
+		},
+	}
+
+	changes, err := TransactionBalanceChanges(tx, meta)
+	require.NoError(t, err)
+	require.Equal(t, payer, changes.FeePayer)
+	require.Equal(t, uint64(5000), changes.Fee)
+
+	require.Len(t, changes.Lamports, 4)
+	require.Equal(t, &LamportChange{Account: payer, Pre: 10_000_000, Post: 10_000_000 - 5000 - 2_039_280, Change: -2_039_280}, changes.Lamports[0])
+	require.Equal(t, int64(0), changes.Lamports[1].Change)
+	require.Equal(t, recipientTokenAccount, changes.Lamports[3].Account)
+	require.Equal(t, int64(2_039_280), changes.Lamports[3].Change)
+
+	require.Len(t, changes.Tokens, 2)
+	{
+		got := changes.Tokens[0]
+		require.Equal(t, payer, got.Owner)
+		require.Equal(t, mint, got.Mint)
+		require.Equal(t, solana.TokenProgramID, got.ProgramID)
+		require.Equal(t, solana.PublicKeySlice{payerTokenAccount}, got.Accounts)
+		require.Equal(t, big.NewInt(-1_500_000), got.Change)
+		require.Equal(t, "2.5", got.UiPre())
+		require.Equal(t, "1", got.UiPost())
+		require.Equal(t, "-1.5", got.UiChange())
+	}
+	{
+		got := changes.Tokens[1]
+		require.Equal(t, recipient, got.Owner)
+		require.Equal(t, solana.PublicKeySlice{recipientTokenAccount}, got.Accounts)
+		require.Equal(t, "0", got.UiPre())
+		require.Equal(t, "1.5", got.UiChange())
+	}
+
+	meta.PostBalances = meta.PostBalances[:3]
+	_, err = TransactionBalanceChanges(tx, meta)
+	require.Error(t, err)
+}
+
+func TestFormatTokenAmount(t *testing.T) {
+	require.Equal(t, "0", formatTokenAmount(big.NewInt(0), 9))
+	require.Equal(t, "0.000000001", formatTokenAmount(big.NewInt(1), 9))
+	require.Equal(t, "-12.34", formatTokenAmount(big.NewInt(-12340), 3))
+	require.Equal(t, "42", formatTokenAmount(big.NewInt(42), 0))
+}
@@ -0,0 +1,341 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+var (
+	ErrBatchNotSent      = errors.New("batch not sent yet")
+	ErrBatchNoResponse   = errors.New("no response for batched request")
+	errBatchCallRecorded = errors.New("batch call recorded")
+)
+
+// Batch collects RPC calls to be sent in a single (or a few) JSON-RPC batch request(s).
+// Each call returns a typed *BatchFuture that holds the result once the batch is sent.
+//
+//	b := client.NewBatch()
+//	balance := b.GetBalance(pubkey, rpc.CommitmentFinalized)
+//	account := b.GetAccountInfo(pubkey2)
+//	err := b.Send(ctx)
+//	...
+//	bal, err := balance.Get()
+//
+// Calls that are not available as Batch methods can be added with AddToBatch.
+// A Batch is not safe for concurrent use.
+type Batch struct {
+	client       *Client
+	maxBatchSize int
+	calls        []*batchCall
+}
+
+type batchCall struct {
+	method  string
+	params  []interface{}
+	resolve func(ctx context.Context, resp *jsonrpc.RPCResponse, err error)
+}
+
+// NewBatch creates a new, empty batch.
+func (cl *Client) NewBatch() *Batch {
+	return &Batch{
+		client: cl,
+	}
+}
+
+// SetMaxBatchSize sets the maximum number of requests sent in a single batch request;
+// larger batches are split. Zero (the default) means no limit.
+//
+// Regardless of this setting, if the RPC provider rejects a batch as too large
+// (with a 413 status code), the batch is split in half and retried.
+func (b *Batch) SetMaxBatchSize(size int) *Batch {
+	b.maxBatchSize = size
+	return b
+}
+
+// Len returns the number of calls in the batch.
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// BatchFuture holds the result of a batched call.
+type BatchFuture[T any] struct {
+	value T
+	err   error
+	done  bool
+}
+
+// Get returns the result of the call;
+// it returns ErrBatchNotSent if the batch has not been sent yet.
+func (f *BatchFuture[T]) Get() (T, error) {
+	if !f.done {
+		var zero T
+		return zero, ErrBatchNotSent
+	}
+	return f.value, f.err
+}
+
+// AddToBatch adds to the batch the call made by the provided function
+// (which must make exactly one RPC call with the provided client).
+// The function is invoked once to record the request, and once more when the
+// batch response is received, so that the params and the result handling of
+// the Client methods are reused as-is:
+//
+//	supply := rpc.AddToBatch(b, func(ctx context.Context, cl *rpc.Client) (*rpc.GetSupplyResult, error) {
+//		return cl.GetSupply(ctx, rpc.CommitmentFinalized)
+//	})
+func AddToBatch[T any](b *Batch, call func(ctx context.Context, cl *Client) (T, error)) *BatchFuture[T] {
+	future := &BatchFuture[T]{}
+
+	recorder := &batchRecorder{}
+	_, err := call(context.Background(), NewWithCustomRPCClient(recorder))
+	if !errors.Is(err, errBatchCallRecorded) {
+		// The call failed before making the request (e.g. invalid params).
+		future.done = true
+		future.err = err
+		if err == nil {
+			future.err = errors.New("batched call did not make any RPC call")
+		}
+		return future
+	}
+
+	b.calls = append(b.calls, &batchCall{
+		method: recorder.method,
+		params: recorder.params,
+		resolve: func(ctx context.Context, resp *jsonrpc.RPCResponse, err error) {
+			future.done = true
+			if err != nil {
+				future.err = err
+				return
+			}
+			future.value, future.err = call(ctx, NewWithCustomRPCClient(&batchReplayer{response: resp}))
+		},
+	})
+	return future
+}
+
+// Send sends all the calls in the batch, and resolves their futures.
+// The returned error is only about the transport (e.g. the provider could not be reached);
+// the errors of the single calls are returned by their futures.
+func (b *Batch) Send(ctx context.Context) error {
+	calls := b.calls
+	b.calls = nil
+	if len(calls) == 0 {
+		return nil
+	}
+	size := len(calls)
+	if b.maxBatchSize > 0 && b.maxBatchSize < size {
+		size = b.maxBatchSize
+	}
+	for start := 0; start < len(calls); start += size {
+		end := start + size
+		if end > len(calls) {
+			end = len(calls)
+		}
+		if err := b.send(ctx, calls[start:end]); err != nil {
+			for _, call := range calls[start:] {
+				call.resolve(ctx, nil, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Batch) send(ctx context.Context, calls []*batchCall) error {
+	requests := make(jsonrpc.RPCRequests, len(calls))
+	for i, call := range calls {
+		requests[i] = &jsonrpc.RPCRequest{
+			Method: call.method,
+			Params: call.params,
+		}
+	}
+
+	responses, err := b.client.rpcClient.CallBatch(ctx, requests)
+	if err != nil {
+		if isBatchTooLarge(err) && len(calls) > 1 {
+			half := len(calls) / 2
+			if err := b.send(ctx, calls[:half]); err != nil {
+				return err
+			}
+			return b.send(ctx, calls[half:])
+		}
+		return err
+	}
+
+	// CallBatch sets the ID of each request to its index.
+	byID := responses.AsMap()
+	for i, call := range calls {
+		resp, ok := byID[i]
+		if !ok || resp == nil {
+			call.resolve(ctx, nil, ErrBatchNoResponse)
+			continue
+		}
+		call.resolve(ctx, resp, nil)
+	}
+	return nil
+}
+
+func isBatchTooLarge(err error) bool {
+	var httpErr *jsonrpc.HTTPError
+	return errors.As(err, &httpErr) && httpErr.Code == http.StatusRequestEntityTooLarge
+}
+
+// batchRecorder records the method and params of a call, without sending it.
+type batchRecorder struct {
+	method string
+	params []interface{}
+}
+
+func (rec *batchRecorder) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	if rec.method != "" {
+		return errors.New("batched call must make exactly one RPC call")
+	}
+	rec.method = method
+	rec.params = params
+	return errBatchCallRecorded
+}
+
+func (rec *batchRecorder) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return fmt.Errorf("method %s cannot be batched", method)
+}
+
+func (rec *batchRecorder) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("cannot batch a batch call")
+}
+
+// batchReplayer serves the response of a batched call.
+type batchReplayer struct {
+	response *jsonrpc.RPCResponse
+}
+
+func (rep *batchReplayer) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	if rep.response.Error != nil {
+		return rep.response.Error
+	}
+	return rep.response.GetObject(out)
+}
+
+func (rep *batchReplayer) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return fmt.Errorf("method %s cannot be batched", method)
+}
+
+func (rep *batchReplayer) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("cannot batch a batch call")
+}
+
+// GetBalance adds a GetBalance call to the batch.
+func (b *Batch) GetBalance(publicKey solana.PublicKey, commitment CommitmentType) *BatchFuture[*GetBalanceResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetBalanceResult, error) {
+		return cl.GetBalance(ctx, publicKey, commitment)
+	})
+}
+
+// GetAccountInfo adds a GetAccountInfo call to the batch.
+func (b *Batch) GetAccountInfo(account solana.PublicKey) *BatchFuture[*GetAccountInfoResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetAccountInfoResult, error) {
+		return cl.GetAccountInfo(ctx, account)
+	})
+}
+
+// GetAccountInfoWithOpts adds a GetAccountInfoWithOpts call to the batch.
+func (b *Batch) GetAccountInfoWithOpts(account solana.PublicKey, opts *GetAccountInfoOpts) *BatchFuture[*GetAccountInfoResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetAccountInfoResult, error) {
+		return cl.GetAccountInfoWithOpts(ctx, account, opts)
+	})
+}
+
+// GetMultipleAccountsWithOpts adds a GetMultipleAccountsWithOpts call to the batch.
+func (b *Batch) GetMultipleAccountsWithOpts(accounts []solana.PublicKey, opts *GetMultipleAccountsOpts) *BatchFuture[*GetMultipleAccountsResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetMultipleAccountsResult, error) {
+		return cl.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+	})
+}
+
+// GetTokenAccountBalance adds a GetTokenAccountBalance call to the batch.
+func (b *Batch) GetTokenAccountBalance(account solana.PublicKey, commitment CommitmentType) *BatchFuture[*GetTokenAccountBalanceResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetTokenAccountBalanceResult, error) {
+		return cl.GetTokenAccountBalance(ctx, account, commitment)
+	})
+}
+
+// GetTokenSupply adds a GetTokenSupply call to the batch.
+func (b *Batch) GetTokenSupply(tokenMint solana.PublicKey, commitment CommitmentType) *BatchFuture[*GetTokenSupplyResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetTokenSupplyResult, error) {
+		return cl.GetTokenSupply(ctx, tokenMint, commitment)
+	})
+}
+
+// GetTransaction adds a GetTransaction call to the batch.
+func (b *Batch) GetTransaction(txSig solana.Signature, opts *GetTransactionOpts) *BatchFuture[*GetTransactionResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetTransactionResult, error) {
+		return cl.GetTransaction(ctx, txSig, opts)
+	})
+}
+
+// GetSignatureStatuses adds a GetSignatureStatuses call to the batch.
+func (b *Batch) GetSignatureStatuses(searchTransactionHistory bool, transactionSignatures ...solana.Signature) *BatchFuture[*GetSignatureStatusesResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetSignatureStatusesResult, error) {
+		return cl.GetSignatureStatuses(ctx, searchTransactionHistory, transactionSignatures...)
+	})
+}
+
+// GetSignaturesForAddressWithOpts adds a GetSignaturesForAddressWithOpts call to the batch.
+func (b *Batch) GetSignaturesForAddressWithOpts(account solana.PublicKey, opts *GetSignaturesForAddressOpts) *BatchFuture[[]*TransactionSignature] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) ([]*TransactionSignature, error) {
+		return cl.GetSignaturesForAddressWithOpts(ctx, account, opts)
+	})
+}
+
+// GetLatestBlockhash adds a GetLatestBlockhash call to the batch.
+func (b *Batch) GetLatestBlockhash(commitment CommitmentType) *BatchFuture[*GetLatestBlockhashResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetLatestBlockhashResult, error) {
+		return cl.GetLatestBlockhash(ctx, commitment)
+	})
+}
+
+// GetSlot adds a GetSlot call to the batch.
+func (b *Batch) GetSlot(commitment CommitmentType) *BatchFuture[uint64] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (uint64, error) {
+		return cl.GetSlot(ctx, commitment)
+	})
+}
+
+// GetBlockHeight adds a GetBlockHeight call to the batch.
+func (b *Batch) GetBlockHeight(commitment CommitmentType) *BatchFuture[uint64] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (uint64, error) {
+		return cl.GetBlockHeight(ctx, commitment)
+	})
+}
+
+// GetEpochInfo adds a GetEpochInfo call to the batch.
+func (b *Batch) GetEpochInfo(commitment CommitmentType) *BatchFuture[*GetEpochInfoResult] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (*GetEpochInfoResult, error) {
+		return cl.GetEpochInfo(ctx, commitment)
+	})
+}
+
+// GetMinimumBalanceForRentExemption adds a GetMinimumBalanceForRentExemption call to the batch.
+func (b *Batch) GetMinimumBalanceForRentExemption(dataSize uint64, commitment CommitmentType) *BatchFuture[uint64] {
+	return AddToBatch(b, func(ctx context.Context, cl *Client) (uint64, error) {
+		return cl.GetMinimumBalanceForRentExemption(ctx, dataSize, commitment)
+	})
+}
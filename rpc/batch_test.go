@@ -0,0 +1,165 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+// mockBatchJSONRPC serves batch requests, answering each request with the
+// result of the handler for its method; batches larger than maxSize are
+// rejected with a 413 status code.
+func mockBatchJSONRPC(t *testing.T, maxSize int, handler func(method string, params []interface{}) (result interface{}, rpcErr interface{})) (*httptest.Server, *[]int) {
+	var batchSizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var requests []struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&requests))
+		if maxSize > 0 && len(requests) > maxSize {
+			rw.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		batchSizes = append(batchSizes, len(requests))
+
+		responses := make([]map[string]interface{}, 0, len(requests))
+		// Reply in reverse order: responses must be matched by ID.
+		for i := len(requests) - 1; i >= 0; i-- {
+			result, rpcErr := handler(requests[i].Method, requests[i].Params)
+			resp := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      requests[i].ID,
+			}
+			if rpcErr != nil {
+				resp["error"] = rpcErr
+			} else {
+				resp["result"] = result
+			}
+			responses = append(responses, resp)
+		}
+		stdjson.NewEncoder(rw).Encode(responses)
+	}))
+	t.Cleanup(server.Close)
+	return server, &batchSizes
+}
+
+func TestBatch(t *testing.T) {
+	accountWithData := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	server, batchSizes := mockBatchJSONRPC(t, 0, func(method string, params []interface{}) (interface{}, interface{}) {
+		switch method {
+		case "getBalance":
+			return map[string]interface{}{"context": map[string]interface{}{"slot": 10}, "value": 12345}, nil
+		case "getAccountInfo":
+			if params[0] == accountWithData.String() {
+				return stdjson.RawMessage(`{"context":{"slot":11},"value":{"data":["dGVzdA==","base64"],"executable":false,"lamports":1,"owner":"11111111111111111111111111111111","rentEpoch":1}}`), nil
+			}
+			return stdjson.RawMessage(`{"context":{"slot":11},"value":null}`), nil
+		case "getSlot":
+			return 99, nil
+		}
+		return nil, map[string]interface{}{"code": -32601, "message": "Method not found"}
+	})
+	client := New(server.URL)
+
+	b := client.NewBatch()
+	balance := b.GetBalance(accountWithData, CommitmentFinalized)
+	account := b.GetAccountInfo(accountWithData)
+	missing := b.GetAccountInfo(solana.SysVarClockPubkey)
+	slot := b.GetSlot("")
+	health := AddToBatch(b, func(ctx context.Context, cl *Client) (string, error) {
+		return cl.GetHealth(ctx)
+	})
+	require.Equal(t, 5, b.Len())
+
+	_, err := balance.Get()
+	require.ErrorIs(t, err, ErrBatchNotSent)
+
+	require.NoError(t, b.Send(context.Background()))
+	require.Equal(t, []int{5}, *batchSizes)
+
+	{
+		got, err := balance.Get()
+		require.NoError(t, err)
+		require.Equal(t, uint64(12345), got.Value)
+		require.Equal(t, uint64(10), got.Context.Slot)
+	}
+	{
+		got, err := account.Get()
+		require.NoError(t, err)
+		require.Equal(t, []byte("test"), got.GetBinary())
+	}
+	{
+		_, err := missing.Get()
+		require.ErrorIs(t, err, ErrNotFound)
+	}
+	{
+		got, err := slot.Get()
+		require.NoError(t, err)
+		require.Equal(t, uint64(99), got)
+	}
+	{
+		_, err := health.Get()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Method not found")
+	}
+}
+
+func TestBatch_split(t *testing.T) {
+	server, batchSizes := mockBatchJSONRPC(t, 3, func(method string, params []interface{}) (interface{}, interface{}) {
+		return params[0], nil
+	})
+	client := New(server.URL)
+
+	t.Run("max size", func(t *testing.T) {
+		*batchSizes = nil
+		b := client.NewBatch().SetMaxBatchSize(2)
+		var futures []*BatchFuture[uint64]
+		for i := 0; i < 5; i++ {
+			futures = append(futures, b.GetMinimumBalanceForRentExemption(uint64(i), ""))
+		}
+		require.NoError(t, b.Send(context.Background()))
+		require.Equal(t, []int{2, 2, 1}, *batchSizes)
+		for i, future := range futures {
+			got, err := future.Get()
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), got)
+		}
+	})
+
+	t.Run("rejected by the provider", func(t *testing.T) {
+		*batchSizes = nil
+		b := client.NewBatch()
+		var futures []*BatchFuture[uint64]
+		for i := 0; i < 8; i++ {
+			futures = append(futures, b.GetMinimumBalanceForRentExemption(uint64(i), ""))
+		}
+		require.NoError(t, b.Send(context.Background()))
+		require.Equal(t, []int{2, 2, 2, 2}, *batchSizes)
+		for i, future := range futures {
+			got, err := future.Get()
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), got)
+		}
+	})
+}
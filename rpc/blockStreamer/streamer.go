@@ -0,0 +1,413 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockstreamer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+const (
+	DefaultPrefetch          = 8
+	DefaultMaxSlotsPerRange  = 500
+	DefaultPollInterval      = 400 * time.Millisecond
+	DefaultMaxRetries        = 5
+	DefaultRetryDelay        = 500 * time.Millisecond
+	DefaultSubscribeDistance = 4
+	DefaultResubscribeDelay  = 30 * time.Second
+)
+
+// JSON-RPC error codes returned by getBlock.
+const (
+	// Block not available for slot: it is not confirmed yet.
+	errCodeBlockNotAvailable = -32004
+	// Slot was skipped, or missing due to ledger jump to recent snapshot.
+	errCodeSlotSkipped = -32007
+	// Slot was skipped, or missing in long-term storage.
+	errCodeLongTermStorageSlotSkipped = -32009
+)
+
+type BlockStreamerOpts struct {
+	// Commitment of the streamed blocks: either rpc.CommitmentConfirmed or rpc.CommitmentFinalized.
+	// Defaults to rpc.CommitmentFinalized.
+	Commitment rpc.CommitmentType
+
+	// (optional) Options of the getBlock calls; the commitment is overwritten.
+	BlockOpts *rpc.GetBlockOpts
+
+	// Maximum number of blocks fetched ahead of the one being delivered.
+	// Defaults to DefaultPrefetch.
+	Prefetch int
+
+	// Maximum number of slots per getBlocks call. Defaults to DefaultMaxSlotsPerRange.
+	MaxSlotsPerRange uint64
+
+	// How often the tip is polled once the streamer has caught up.
+	// Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+
+	// Number of times a failed call is retried, with an exponential backoff
+	// starting at RetryDelay. Defaults to DefaultMaxRetries;
+	// a negative value disables retries.
+	MaxRetries int
+	RetryDelay time.Duration
+
+	// With a websocket client, the streamer switches to a block subscription
+	// when it is fewer than SubscribeDistance slots behind the tip.
+	// Defaults to DefaultSubscribeDistance.
+	SubscribeDistance uint64
+
+	// Called for the errors that don't stop the stream
+	// (e.g. the block subscription failed, and the streamer fell back to polling);
+	// it may be called concurrently.
+	OnError func(err error)
+}
+
+// StreamedBlock is a slot delivered by a BlockStreamer.
+type StreamedBlock struct {
+	Slot uint64
+	// Nil if the slot was skipped.
+	Block   *rpc.GetBlockResult
+	Skipped bool
+}
+
+// BlockStreamer delivers every slot from a start slot onward, in order:
+// the blocks, and the skipped slots (so that a consumer can persist its position).
+//
+// Slots are listed with getBlocks up to the current tip, so that a slot that
+// isn't in the list is known to be skipped, while a slot past the tip is not available yet
+// and is waited for. The blocks are fetched by prefetch workers, and delivered in order.
+// With a websocket client, blocks near the tip are received from a block subscription
+// (which requires a validator started with --rpc-pubsub-enable-block-subscription);
+// the streamer falls back to polling if the subscription fails.
+type BlockStreamer struct {
+	rpcClient *rpc.Client
+	wsClient  *ws.Client
+	opts      BlockStreamerOpts
+
+	mu             sync.Mutex
+	next           uint64
+	subscribeAfter time.Time
+}
+
+// NewBlockStreamer creates a streamer that starts at startSlot;
+// the websocket client is optional.
+func NewBlockStreamer(
+	rpcClient *rpc.Client,
+	wsClient *ws.Client, // optional
+	startSlot uint64,
+	opts BlockStreamerOpts,
+) *BlockStreamer {
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentFinalized
+	}
+	if opts.Prefetch <= 0 {
+		opts.Prefetch = DefaultPrefetch
+	}
+	if opts.MaxSlotsPerRange == 0 {
+		opts.MaxSlotsPerRange = DefaultMaxSlotsPerRange
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = DefaultRetryDelay
+	}
+	if opts.SubscribeDistance == 0 {
+		opts.SubscribeDistance = DefaultSubscribeDistance
+	}
+	blockOpts := rpc.GetBlockOpts{}
+	if opts.BlockOpts != nil {
+		blockOpts = *opts.BlockOpts
+	}
+	blockOpts.Commitment = opts.Commitment
+	opts.BlockOpts = &blockOpts
+
+	return &BlockStreamer{
+		rpcClient: rpcClient,
+		wsClient:  wsClient,
+		opts:      opts,
+		next:      startSlot,
+	}
+}
+
+// NextSlot returns the next slot to be delivered;
+// use it to resume the stream after a restart.
+func (s *BlockStreamer) NextSlot() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+// Run delivers the slots to the handler, in order, until the context is done,
+// the handler returns an error, or a call fails after all its retries.
+// The handler is called from a single goroutine.
+// Run can be called again after it returns, to continue from NextSlot.
+func (s *BlockStreamer) Run(ctx context.Context, handler func(block *StreamedBlock) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var tip uint64
+		err := s.retry(ctx, func() (err error) {
+			tip, err = s.rpcClient.GetSlot(ctx, s.opts.Commitment)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("getSlot: %w", err)
+		}
+
+		next := s.NextSlot()
+		if s.canSubscribe() && next+s.opts.SubscribeDistance > tip {
+			err := s.runSubscription(ctx, handler)
+			var subErr *subscriptionError
+			if !errors.As(err, &subErr) || ctx.Err() != nil {
+				return err
+			}
+			s.onError(subErr.err)
+			s.mu.Lock()
+			s.subscribeAfter = time.Now().Add(DefaultResubscribeDelay)
+			s.mu.Unlock()
+			continue
+		}
+
+		if next > tip {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.opts.PollInterval):
+			}
+			continue
+		}
+		end := tip
+		if end-next >= s.opts.MaxSlotsPerRange {
+			end = next + s.opts.MaxSlotsPerRange - 1
+		}
+		if err := s.streamRange(ctx, next, end, handler); err != nil {
+			return err
+		}
+	}
+}
+
+// subscriptionError is an error of the block subscription,
+// after which the streamer falls back to polling.
+type subscriptionError struct {
+	err error
+}
+
+func (e *subscriptionError) Error() string {
+	return fmt.Sprintf("block subscription: %s", e.err)
+}
+
+func (e *subscriptionError) Unwrap() error {
+	return e.err
+}
+
+func (s *BlockStreamer) canSubscribe() bool {
+	if s.wsClient == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().After(s.subscribeAfter)
+}
+
+func (s *BlockStreamer) runSubscription(ctx context.Context, handler func(block *StreamedBlock) error) error {
+	blockOpts := s.opts.BlockOpts
+	sub, err := s.wsClient.BlockSubscribe(
+		ws.NewBlockSubscribeFilterAll(),
+		&ws.BlockSubscribeOpts{
+			Commitment:                     s.opts.Commitment,
+			Encoding:                       blockOpts.Encoding,
+			TransactionDetails:             blockOpts.TransactionDetails,
+			Rewards:                        blockOpts.Rewards,
+			MaxSupportedTransactionVersion: blockOpts.MaxSupportedTransactionVersion,
+		},
+	)
+	if err != nil {
+		return &subscriptionError{err}
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		got, err := sub.Recv(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &subscriptionError{err}
+		}
+		slot := got.Value.Slot
+		next := s.NextSlot()
+		if slot < next {
+			continue
+		}
+		if slot > next {
+			// Missed notifications (or skipped slots): catch up with getBlocks.
+			if err := s.streamRange(ctx, next, slot-1, handler); err != nil {
+				return err
+			}
+		}
+		block := &StreamedBlock{
+			Slot:  slot,
+			Block: got.Value.Block,
+		}
+		if block.Block == nil {
+			block, err = s.fetchBlock(ctx, slot)
+			if err != nil {
+				return err
+			}
+		}
+		if err := s.deliver(block, handler); err != nil {
+			return err
+		}
+	}
+}
+
+// streamRange delivers the slots from start to end (included), in order.
+func (s *BlockStreamer) streamRange(ctx context.Context, start, end uint64, handler func(block *StreamedBlock) error) error {
+	var produced rpc.BlocksResult
+	err := s.retry(ctx, func() (err error) {
+		produced, err = s.rpcClient.GetBlocks(ctx, start, &end, s.opts.Commitment)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("getBlocks from %d to %d: %w", start, end, err)
+	}
+	isProduced := make(map[uint64]bool, len(produced))
+	for _, slot := range produced {
+		isProduced[slot] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		block *StreamedBlock
+		err   error
+	}
+	// The results are queued in slot order; the queue size bounds the prefetch.
+	queue := make(chan chan result, s.opts.Prefetch)
+	go func() {
+		defer close(queue)
+		for slot := start; slot <= end; slot++ {
+			ch := make(chan result, 1)
+			if isProduced[slot] {
+				go func(slot uint64) {
+					block, err := s.fetchBlock(ctx, slot)
+					ch <- result{block, err}
+				}(slot)
+			} else {
+				ch <- result{block: &StreamedBlock{Slot: slot, Skipped: true}}
+			}
+			select {
+			case queue <- ch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for ch := range queue {
+		res := <-ch
+		if res.err != nil {
+			return res.err
+		}
+		if err := s.deliver(res.block, handler); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// fetchBlock fetches a block that getBlocks (or the block subscription) reported as produced.
+func (s *BlockStreamer) fetchBlock(ctx context.Context, slot uint64) (*StreamedBlock, error) {
+	var block *rpc.GetBlockResult
+	var skipped bool
+	err := s.retry(ctx, func() (err error) {
+		for {
+			block, err = s.rpcClient.GetBlockWithOpts(ctx, slot, s.opts.BlockOpts)
+			var rpcErr *jsonrpc.RPCError
+			if !errors.As(err, &rpcErr) {
+				return err
+			}
+			switch rpcErr.Code {
+			case errCodeSlotSkipped, errCodeLongTermStorageSlotSkipped:
+				skipped = true
+				return nil
+			case errCodeBlockNotAvailable:
+				// Not available yet on this node: wait for it.
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(s.opts.PollInterval):
+				}
+			default:
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getBlock %d: %w", slot, err)
+	}
+	return &StreamedBlock{
+		Slot:    slot,
+		Block:   block,
+		Skipped: skipped,
+	}, nil
+}
+
+func (s *BlockStreamer) deliver(block *StreamedBlock, handler func(block *StreamedBlock) error) error {
+	if err := handler(block); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.next = block.Slot + 1
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *BlockStreamer) retry(ctx context.Context, call func() error) error {
+	delay := s.opts.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= s.opts.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		s.onError(err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+func (s *BlockStreamer) onError(err error) {
+	if err != nil && s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockstreamer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockStreamer(t *testing.T) {
+	skipped := map[uint64]bool{12: true, 15: true}
+	var mu sync.Mutex
+	calls := map[string]int{}
+	getBlockCalls := map[uint64]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		mu.Lock()
+		call := calls[body.Method]
+		calls[body.Method]++
+		mu.Unlock()
+
+		resp := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      body.ID,
+		}
+		switch body.Method {
+		case "getSlot":
+			// The tip moves forward.
+			resp["result"] = 18 + 4*call
+		case "getBlocks":
+			var blocks []uint64
+			for slot := uint64(body.Params[0].(float64)); slot <= uint64(body.Params[1].(float64)); slot++ {
+				if !skipped[slot] {
+					blocks = append(blocks, slot)
+				}
+			}
+			resp["result"] = blocks
+		case "getBlock":
+			slot := uint64(body.Params[0].(float64))
+			mu.Lock()
+			getBlockCalls[slot]++
+			attempt := getBlockCalls[slot]
+			mu.Unlock()
+			switch {
+			case slot == 20 && attempt == 1:
+				resp["error"] = map[string]interface{}{"code": -32004, "message": "Block not available for slot 20"}
+			case slot == 22 && attempt == 1:
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				return
+			case slot == 25:
+				resp["error"] = map[string]interface{}{"code": -32007, "message": "Slot 25 was skipped"}
+			default:
+				resp["result"] = map[string]interface{}{"parentSlot": slot - 1}
+			}
+		}
+		json.NewEncoder(rw).Encode(resp)
+	}))
+	defer server.Close()
+
+	var errorsCount int
+	streamer := NewBlockStreamer(rpc.New(server.URL), nil, 10, BlockStreamerOpts{
+		Prefetch:         3,
+		MaxSlotsPerRange: 5,
+		PollInterval:     time.Millisecond,
+		RetryDelay:       time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			errorsCount++
+			mu.Unlock()
+		},
+	})
+
+	errStop := errors.New("stop")
+	var slots []uint64
+	var skippedSlots []uint64
+	err := streamer.Run(context.Background(), func(block *StreamedBlock) error {
+		slots = append(slots, block.Slot)
+		if block.Skipped {
+			require.Nil(t, block.Block)
+			skippedSlots = append(skippedSlots, block.Slot)
+		} else {
+			require.Equal(t, block.Slot-1, block.Block.ParentSlot)
+		}
+		if block.Slot == 30 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+
+	var expected []uint64
+	for slot := uint64(10); slot <= 30; slot++ {
+		expected = append(expected, slot)
+	}
+	require.Equal(t, expected, slots)
+	require.Equal(t, []uint64{12, 15, 25}, skippedSlots)
+	require.Equal(t, 1, errorsCount)
+	require.Equal(t, uint64(30), streamer.NextSlot())
+	require.Equal(t, 2, getBlockCalls[20])
+}
@@ -0,0 +1,279 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockhashprovider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+const (
+	// MAX_PROCESSING_AGE is the number of blocks a blockhash is valid for:
+	// the lastValidBlockHeight of the latest blockhash is the current block height
+	// plus MAX_PROCESSING_AGE.
+	MAX_PROCESSING_AGE = 150
+
+	DefaultBlockhashRefreshInterval = 2 * time.Second
+	DefaultBlockhashSlotsPerRefresh = 5
+	DefaultBlockhashExpiryThreshold = 20
+
+	// Approximate duration of a block, used to estimate
+	// the current block height between refreshes.
+	approximateBlockDuration = 400 * time.Millisecond
+)
+
+var ErrNoBlockhash = errors.New("no blockhash available yet")
+
+type BlockhashProviderOpts struct {
+	// Commitment of the fetched blockhashes. Defaults to rpc.CommitmentFinalized.
+	Commitment rpc.CommitmentType
+
+	// How often the blockhash is refreshed when there is no websocket client
+	// (or the slot subscription fails). Defaults to DefaultBlockhashRefreshInterval.
+	RefreshInterval time.Duration
+
+	// With a websocket client, the blockhash is refreshed every SlotsPerRefresh
+	// slot notifications. Defaults to DefaultBlockhashSlotsPerRefresh.
+	SlotsPerRefresh int
+
+	// A blockhash is near expiry when fewer than ExpiryThreshold blocks
+	// remain before its last valid block height. Defaults to DefaultBlockhashExpiryThreshold.
+	ExpiryThreshold uint64
+
+	// Called when a refresh fails.
+	OnError func(err error)
+}
+
+// CachedBlockhash is a blockhash as cached by a BlockhashProvider.
+type CachedBlockhash struct {
+	Blockhash            solana.Hash
+	LastValidBlockHeight uint64
+	// The slot the blockhash was fetched at.
+	Slot      uint64
+	FetchedAt time.Time
+}
+
+// BlockhashProvider caches the latest blockhash, and refreshes it in the background,
+// so that many goroutines can build transactions without calling GetLatestBlockhash each time.
+// It is safe for concurrent use, and implements solana.BlockhashSource, so it can be passed
+// to a TransactionBuilder with SetBlockhashSource.
+type BlockhashProvider struct {
+	rpcClient *rpc.Client
+	wsClient  *ws.Client
+	opts      BlockhashProviderOpts
+
+	mu     sync.RWMutex
+	latest *CachedBlockhash
+
+	closeOnce sync.Once
+	stop      chan struct{}
+}
+
+var _ solana.BlockhashSource = &BlockhashProvider{}
+
+// NewBlockhashProvider creates a new provider; the websocket client is optional:
+// if provided, refreshes are triggered by slot notifications instead of a timer.
+// Call Start to fetch the first blockhash and start refreshing.
+func NewBlockhashProvider(
+	rpcClient *rpc.Client,
+	wsClient *ws.Client, // optional
+	opts BlockhashProviderOpts,
+) *BlockhashProvider {
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentFinalized
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = DefaultBlockhashRefreshInterval
+	}
+	if opts.SlotsPerRefresh <= 0 {
+		opts.SlotsPerRefresh = DefaultBlockhashSlotsPerRefresh
+	}
+	if opts.ExpiryThreshold == 0 {
+		opts.ExpiryThreshold = DefaultBlockhashExpiryThreshold
+	}
+	return &BlockhashProvider{
+		rpcClient: rpcClient,
+		wsClient:  wsClient,
+		opts:      opts,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start fetches the first blockhash, and then keeps refreshing it
+// in the background until Close is called or the context is done.
+func (p *BlockhashProvider) Start(ctx context.Context) error {
+	if err := p.Refresh(ctx); err != nil {
+		return err
+	}
+	go p.run(ctx)
+	return nil
+}
+
+// Close stops the background refresh.
+func (p *BlockhashProvider) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+// Refresh fetches the latest blockhash now.
+func (p *BlockhashProvider) Refresh(ctx context.Context) error {
+	out, err := p.rpcClient.GetLatestBlockhash(ctx, p.opts.Commitment)
+	if err != nil {
+		return err
+	}
+	if out.Value == nil {
+		return ErrNoBlockhash
+	}
+	fetched := &CachedBlockhash{
+		Blockhash:            out.Value.Blockhash,
+		LastValidBlockHeight: out.Value.LastValidBlockHeight,
+		Slot:                 out.Context.Slot,
+		FetchedAt:            time.Now(),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Don't go back in time if refreshes overlap.
+	if p.latest == nil || fetched.LastValidBlockHeight >= p.latest.LastValidBlockHeight {
+		p.latest = fetched
+	}
+	return nil
+}
+
+// Latest returns the freshest cached blockhash.
+func (p *BlockhashProvider) Latest() (CachedBlockhash, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.latest == nil {
+		return CachedBlockhash{}, ErrNoBlockhash
+	}
+	return *p.latest, nil
+}
+
+// RecentBlockhash returns the freshest cached blockhash.
+func (p *BlockhashProvider) RecentBlockhash() (solana.Hash, error) {
+	latest, err := p.Latest()
+	if err != nil {
+		return solana.Hash{}, err
+	}
+	return latest.Blockhash, nil
+}
+
+// EstimatedBlockHeight returns an estimate of the current block height,
+// based on the freshest blockhash and the time elapsed since it was fetched.
+func (p *BlockhashProvider) EstimatedBlockHeight() (uint64, error) {
+	latest, err := p.Latest()
+	if err != nil {
+		return 0, err
+	}
+	elapsedBlocks := uint64(time.Since(latest.FetchedAt) / approximateBlockDuration)
+	if latest.LastValidBlockHeight < MAX_PROCESSING_AGE {
+		return elapsedBlocks, nil
+	}
+	return latest.LastValidBlockHeight - MAX_PROCESSING_AGE + elapsedBlocks, nil
+}
+
+// RemainingBlocks returns the estimated number of blocks left before
+// a blockhash with the provided last valid block height expires.
+func (p *BlockhashProvider) RemainingBlocks(lastValidBlockHeight uint64) (int64, error) {
+	height, err := p.EstimatedBlockHeight()
+	if err != nil {
+		return 0, err
+	}
+	return int64(lastValidBlockHeight) - int64(height), nil
+}
+
+// IsNearExpiry returns true if a blockhash with the provided last valid block height
+// has fewer than ExpiryThreshold blocks left (or if there is no estimate of the block height).
+// Use it with the LastValidBlockHeight of the Latest cached blockhash to know
+// whether the cache itself is going stale (e.g. because refreshes are failing).
+func (p *BlockhashProvider) IsNearExpiry(lastValidBlockHeight uint64) bool {
+	remaining, err := p.RemainingBlocks(lastValidBlockHeight)
+	if err != nil {
+		return true
+	}
+	return remaining < int64(p.opts.ExpiryThreshold)
+}
+
+func (p *BlockhashProvider) run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if p.wsClient != nil {
+		err := p.runWithSlotSubscription(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// Fall back to the timer.
+		p.onError(err)
+	}
+
+	ticker := time.NewTicker(p.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Refresh(ctx); err != nil && ctx.Err() == nil {
+				p.onError(err)
+			}
+		}
+	}
+}
+
+func (p *BlockhashProvider) runWithSlotSubscription(ctx context.Context) error {
+	sub, err := p.wsClient.SlotSubscribe()
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	slots := 0
+	for {
+		_, err := sub.Recv(ctx)
+		if err != nil {
+			return err
+		}
+		slots++
+		if slots < p.opts.SlotsPerRefresh {
+			continue
+		}
+		slots = 0
+		if err := p.Refresh(ctx); err != nil && ctx.Err() == nil {
+			p.onError(err)
+		}
+	}
+}
+
+func (p *BlockhashProvider) onError(err error) {
+	if err != nil && p.opts.OnError != nil {
+		p.opts.OnError(err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockhashprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockNode starts a JSON-RPC server, which returns the result of handle for each call,
+// given the method and how many times it was called.
+func newMockNode(t *testing.T, handle func(method string, call int) interface{}) *rpc.Client {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			// The provider can still be refreshing while the server shuts down.
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		call := calls[body.Method]
+		calls[body.Method]++
+		mu.Unlock()
+
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      body.ID,
+			"result":  handle(body.Method, call),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return rpc.New(server.URL)
+}
+
+func TestBlockhashProvider(t *testing.T) {
+	client := newMockNode(t, func(method string, call int) interface{} {
+		switch method {
+		case "getLatestBlockhash":
+			return map[string]interface{}{
+				"context": map[string]interface{}{"slot": 1000 + call},
+				"value": map[string]interface{}{
+					"blockhash":            solana.Hash{byte(call + 1)}.String(),
+					"lastValidBlockHeight": 500 + call,
+				},
+			}
+		}
+		return nil
+	})
+
+	provider := NewBlockhashProvider(client, nil, BlockhashProviderOpts{
+		RefreshInterval: 10 * time.Millisecond,
+	})
+	defer provider.Close()
+
+	_, err := provider.RecentBlockhash()
+	require.ErrorIs(t, err, ErrNoBlockhash)
+
+	require.NoError(t, provider.Start(context.Background()))
+	first, err := provider.Latest()
+	require.NoError(t, err)
+	require.Equal(t, solana.Hash{1}, first.Blockhash)
+	require.Equal(t, uint64(500), first.LastValidBlockHeight)
+	require.Equal(t, uint64(1000), first.Slot)
+
+	height, err := provider.EstimatedBlockHeight()
+	require.NoError(t, err)
+	require.Equal(t, uint64(350), height)
+	require.False(t, provider.IsNearExpiry(first.LastValidBlockHeight))
+	require.True(t, provider.IsNearExpiry(360))
+
+	require.Eventually(t, func() bool {
+		latest, err := provider.Latest()
+		return err == nil && latest.LastValidBlockHeight > first.LastValidBlockHeight
+	}, 5*time.Second, 10*time.Millisecond)
+
+	tx, err := solana.NewTransactionBuilder().
+		AddInstruction(solana.NewInstruction(
+			solana.MemoProgramID,
+			solana.AccountMetaSlice{solana.Meta(solana.NewWallet().PublicKey()).SIGNER()},
+			[]byte("hello"),
+		)).
+		SetBlockhashSource(provider).
+		Build()
+	require.NoError(t, err)
+	require.False(t, tx.Message.RecentBlockhash.IsZero())
+	require.NotEqual(t, solana.Hash{1}, tx.Message.RecentBlockhash)
+}
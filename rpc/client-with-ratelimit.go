@@ -17,12 +17,15 @@ type clientWithRateLimiting struct {
 }
 
 // NewWithRateLimit creates a new rate-limitted Solana RPC client.
+// The middlewares run after the rate limiter.
 func NewWithRateLimit(
 	rpcEndpoint string,
 	rps int, // requests per second
+	middlewares ...jsonrpc.Middleware,
 ) JSONRPCClient {
 	opts := &jsonrpc.RPCClientOpts{
-		HTTPClient: newHTTP(),
+		HTTPClient:  newHTTP(),
+		Middlewares: middlewares,
 	}
 
 	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
@@ -18,13 +18,16 @@ type clientWithLimiter struct {
 
 // NewWithLimiter creates a new rate-limitted Solana RPC client.
 // Example: NewWithLimiter(URL, rate.Every(time.Second), 1)
+// The middlewares run after the limiter.
 func NewWithLimiter(
 	rpcEndpoint string,
 	every rate.Limit, // time frame
 	b int, // number of requests per time frame
+	middlewares ...jsonrpc.Middleware,
 ) JSONRPCClient {
 	opts := &jsonrpc.RPCClientOpts{
-		HTTPClient: newHTTP(),
+		HTTPClient:  newHTTP(),
+		Middlewares: middlewares,
 	}
 
 	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
@@ -0,0 +1,309 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const (
+	DefaultWeightedLimiterMinBackoff = 500 * time.Millisecond
+	DefaultWeightedLimiterMaxBackoff = 30 * time.Second
+	DefaultWeightedLimiterMaxRetries = 3
+	// After a 429, the rate is divided by this factor...
+	weightedLimiterDecreaseFactor = 2
+	// ... down to this fraction of the configured rate.
+	weightedLimiterMinRateFraction = 1.0 / 16
+	// Each successful call gives back this fraction of the configured rate.
+	weightedLimiterRecoveryFraction = 0.05
+)
+
+type WeightedLimiterOpts struct {
+	// Cost units per second; zero or negative means no limit
+	// (the 429 responses are still handled).
+	Rate float64
+	// Maximum cost units that can be spent at once; defaults to Rate
+	// (and at least the highest cost).
+	Burst float64
+	// Cost of each method; the methods not in the map cost DefaultCost.
+	// e.g. map[string]float64{"getProgramAccounts": 100, "getSignaturesForAddress": 10}
+	MethodCosts map[string]float64
+	// Defaults to 1.
+	DefaultCost float64
+	// Backoff after a 429 response without a Retry-After header,
+	// doubled at each consecutive 429, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Number of times a call that got a 429 response is retried;
+	// defaults to DefaultWeightedLimiterMaxRetries, negative disables.
+	MaxRetries int
+}
+
+// WeightedLimiter is a rate limiter that charges each call the cost of its method
+// (the sum of the costs for batch calls).
+//
+// When the node replies with HTTP 429, the calls are paused for the
+// Retry-After duration (or an exponential backoff), and the rate is halved;
+// it then recovers with each successful call.
+//
+// It is used as a middleware:
+//
+//	limiter := rpc.NewWeightedLimiter(&rpc.WeightedLimiterOpts{
+//		Rate:        100,
+//		MethodCosts: map[string]float64{"getProgramAccounts": 50},
+//	})
+//	client := rpc.NewWithMiddlewares(endpoint, limiter.Middleware())
+type WeightedLimiter struct {
+	opts WeightedLimiterOpts
+
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	// No call is sent before this time.
+	pausedUntil   time.Time
+	consecutive   int
+	throttleCount uint64
+}
+
+func NewWeightedLimiter(opts *WeightedLimiterOpts) *WeightedLimiter {
+	if opts == nil {
+		opts = &WeightedLimiterOpts{}
+	}
+	o := *opts
+	if o.DefaultCost <= 0 {
+		o.DefaultCost = 1
+	}
+	if o.Burst <= 0 {
+		o.Burst = o.Rate
+	}
+	for _, cost := range o.MethodCosts {
+		o.Burst = math.Max(o.Burst, cost)
+	}
+	o.Burst = math.Max(o.Burst, o.DefaultCost)
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = DefaultWeightedLimiterMinBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultWeightedLimiterMaxBackoff
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = DefaultWeightedLimiterMaxRetries
+	}
+	return &WeightedLimiter{
+		opts:   o,
+		rate:   o.Rate,
+		tokens: o.Burst,
+		last:   time.Now(),
+	}
+}
+
+// NewWithWeightedLimiter creates a new Solana RPC client limited by the provided limiter.
+// The middlewares run after the limiter.
+func NewWithWeightedLimiter(
+	rpcEndpoint string,
+	limiter *WeightedLimiter,
+	middlewares ...jsonrpc.Middleware,
+) JSONRPCClient {
+	opts := &jsonrpc.RPCClientOpts{
+		HTTPClient:  newHTTP(),
+		Middlewares: append([]jsonrpc.Middleware{limiter.Middleware()}, middlewares...),
+	}
+	return jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
+}
+
+// Cost returns the cost of a call of the method.
+func (l *WeightedLimiter) Cost(method string) float64 {
+	if cost, ok := l.opts.MethodCosts[method]; ok {
+		return cost
+	}
+	return l.opts.DefaultCost
+}
+
+func (l *WeightedLimiter) callCost(call *jsonrpc.Call) float64 {
+	if !call.IsBatch() {
+		return l.Cost(call.Method())
+	}
+	var cost float64
+	for _, req := range call.Requests {
+		cost += l.Cost(req.Method)
+	}
+	return cost
+}
+
+// refill must be called with the lock held.
+func (l *WeightedLimiter) refill(now time.Time) {
+	if l.opts.Rate <= 0 {
+		return
+	}
+	l.tokens = math.Min(l.opts.Burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// Wait blocks until the cost can be spent, or the context is done.
+func (l *WeightedLimiter) Wait(ctx context.Context, cost float64) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	var delay time.Duration
+	if l.opts.Rate > 0 {
+		// Reserve the tokens now; a negative balance is the queue of waiting calls.
+		l.tokens -= cost
+		if l.tokens < 0 {
+			delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+	if pause := l.pausedUntil.Sub(now); pause > delay {
+		delay = pause
+	}
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Give back the reservation.
+		l.mu.Lock()
+		if l.opts.Rate > 0 {
+			l.refill(time.Now())
+			l.tokens = math.Min(l.opts.Burst, l.tokens+cost)
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Throttle pauses the calls for the provided duration (or an exponential backoff when zero),
+// and reduces the rate; this is done automatically on 429 responses.
+func (l *WeightedLimiter) Throttle(retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.consecutive++
+	l.throttleCount++
+	if retryAfter <= 0 {
+		retryAfter = l.opts.MinBackoff << (l.consecutive - 1)
+		if retryAfter > l.opts.MaxBackoff || retryAfter <= 0 {
+			retryAfter = l.opts.MaxBackoff
+		}
+	}
+	if until := time.Now().Add(retryAfter); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	if l.opts.Rate > 0 {
+		l.refill(time.Now())
+		l.rate = math.Max(l.rate/weightedLimiterDecreaseFactor, l.opts.Rate*weightedLimiterMinRateFraction)
+	}
+}
+
+func (l *WeightedLimiter) succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.consecutive = 0
+	if l.opts.Rate > 0 && l.rate < l.opts.Rate {
+		l.refill(time.Now())
+		l.rate = math.Min(l.opts.Rate, l.rate+l.opts.Rate*weightedLimiterRecoveryFraction)
+	}
+}
+
+type WeightedLimiterStats struct {
+	// The configured rate, in cost units per second.
+	Rate float64
+	// The current rate, lower than Rate after 429 responses.
+	CurrentRate float64
+	// Fraction of the burst in use: 0 when idle, 1 when the budget is spent,
+	// more than 1 when calls are waiting.
+	Utilization float64
+	// The calls are paused until this time, after a 429 response.
+	PausedUntil time.Time
+	// Number of 429 responses received.
+	Throttled uint64
+}
+
+// Stats returns the current state of the limiter;
+// e.g. batch jobs can slow down when the utilization gets close to 1.
+func (l *WeightedLimiter) Stats() WeightedLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	stats := WeightedLimiterStats{
+		Rate:        l.opts.Rate,
+		CurrentRate: l.rate,
+		PausedUntil: l.pausedUntil,
+		Throttled:   l.throttleCount,
+	}
+	if l.opts.Rate > 0 {
+		stats.Utilization = 1 - l.tokens/l.opts.Burst
+	}
+	return stats
+}
+
+// Utilization returns Stats().Utilization.
+func (l *WeightedLimiter) Utilization() float64 {
+	return l.Stats().Utilization
+}
+
+// Middleware returns the middleware that limits the calls.
+func (l *WeightedLimiter) Middleware() jsonrpc.Middleware {
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx context.Context, call *jsonrpc.Call) error {
+			cost := l.callCost(call)
+			for attempt := 0; ; attempt++ {
+				if err := l.Wait(ctx, cost); err != nil {
+					return err
+				}
+				err := next(ctx, call)
+				if !isTooManyRequests(call, err) {
+					l.succeeded()
+					return err
+				}
+				l.Throttle(parseRetryAfter(call.ResponseHeader, time.Now()))
+				if l.opts.MaxRetries < 0 || attempt >= l.opts.MaxRetries || call.HTTPRequest.GetBody == nil {
+					return err
+				}
+				body, bodyErr := call.HTTPRequest.GetBody()
+				if bodyErr != nil {
+					return err
+				}
+				call.HTTPRequest.Body = body
+			}
+		}
+	}
+}
+
+func isTooManyRequests(call *jsonrpc.Call, err error) bool {
+	if call.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	var httpErr *jsonrpc.HTTPError
+	return errors.As(err, &httpErr) && httpErr.Code == http.StatusTooManyRequests
+}
+
+// parseRetryAfter returns the duration of a Retry-After header,
+// in seconds or as a HTTP date; 0 when missing or invalid.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedLimiter_Throttling(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("rate limited"))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":42}`))
+	}))
+	defer server.Close()
+
+	limiter := NewWeightedLimiter(&WeightedLimiterOpts{
+		Rate:       1000,
+		MinBackoff: 10 * time.Millisecond,
+	})
+	client := NewWithCustomRPCClient(NewWithWeightedLimiter(server.URL, limiter))
+
+	start := time.Now()
+	slot, err := client.GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), slot)
+	require.EqualValues(t, 3, atomic.LoadInt64(&requests))
+	// 10ms then 20ms of backoff.
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	stats := limiter.Stats()
+	require.EqualValues(t, 2, stats.Throttled)
+	// Halved twice, then recovered once.
+	require.InDelta(t, 300, stats.CurrentRate, 0.001)
+
+	t.Run("retries disabled", func(t *testing.T) {
+		atomic.StoreInt64(&requests, 0)
+		limiter := NewWeightedLimiter(&WeightedLimiterOpts{MaxRetries: -1, MinBackoff: time.Millisecond})
+		client := NewWithCustomRPCClient(NewWithWeightedLimiter(server.URL, limiter))
+		_, err := client.GetSlot(context.Background(), "")
+		var httpErr *jsonrpc.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusTooManyRequests, httpErr.Code)
+		require.EqualValues(t, 1, atomic.LoadInt64(&requests))
+	})
+}
+
+func TestWeightedLimiter_ConnectionErrorAfterThrottling(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt64(&requests, 1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("rate limited"))
+		case 2:
+			// Drop the connection without a response.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":42}`))
+		}
+	}))
+	defer server.Close()
+
+	limiter := NewWeightedLimiter(&WeightedLimiterOpts{MinBackoff: time.Millisecond})
+	client := NewWithCustomRPCClient(NewWithWeightedLimiter(server.URL, limiter))
+
+	// The connection error is not a 429 response: it is not retried.
+	_, err := client.GetSlot(context.Background(), "")
+	require.Error(t, err)
+	var httpErr *jsonrpc.HTTPError
+	require.False(t, errors.As(err, &httpErr))
+	require.EqualValues(t, 2, atomic.LoadInt64(&requests))
+	require.EqualValues(t, 1, limiter.Stats().Throttled)
+}
+
+func TestWeightedLimiter_Costs(t *testing.T) {
+	limiter := NewWeightedLimiter(&WeightedLimiterOpts{
+		Rate:        10,
+		MethodCosts: map[string]float64{"getProgramAccounts": 20},
+	})
+	require.Equal(t, float64(1), limiter.Cost("getSlot"))
+	require.Equal(t, float64(20), limiter.Cost("getProgramAccounts"))
+	// The burst is raised to the highest cost.
+	require.InDelta(t, 0, limiter.Utilization(), 0.01)
+
+	require.NoError(t, limiter.Wait(context.Background(), 20))
+	require.InDelta(t, 1, limiter.Utilization(), 0.01)
+
+	// The next call has to wait for 1/10s.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, limiter.Wait(ctx, 1), context.DeadlineExceeded)
+	// The reservation was given back.
+	require.Less(t, limiter.Utilization(), 1.0)
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(context.Background(), 1))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	require.Zero(t, parseRetryAfter(header, now))
+	header.Set("Retry-After", "3")
+	require.Equal(t, 3*time.Second, parseRetryAfter(header, now))
+	header.Set("Retry-After", now.Add(time.Minute).Format(http.TimeFormat))
+	require.Equal(t, time.Minute, parseRetryAfter(header, now))
+	header.Set("Retry-After", "soon")
+	require.Zero(t, parseRetryAfter(header, now))
+}
@@ -67,6 +67,18 @@ func NewWithHeaders(rpcEndpoint string, headers map[string]string) *Client {
 	return NewWithCustomRPCClient(rpcClient)
 }
 
+// NewWithMiddlewares creates a new Solana JSON RPC client
+// that runs the provided middlewares around each call.
+// To combine them with rate limiting, see NewWithRateLimit and NewWithLimiter.
+func NewWithMiddlewares(rpcEndpoint string, middlewares ...jsonrpc.Middleware) *Client {
+	opts := &jsonrpc.RPCClientOpts{
+		HTTPClient:  newHTTP(),
+		Middlewares: middlewares,
+	}
+	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
+	return NewWithCustomRPCClient(rpcClient)
+}
+
 // Close closes the client.
 func (cl *Client) Close() error {
 	if cl.rpcClient == nil {
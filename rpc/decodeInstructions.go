@@ -0,0 +1,233 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DecodedInstruction is an instruction of a transaction,
+// decoded with the instruction decoder registered for its program.
+type DecodedInstruction struct {
+	// Index of the top-level instruction this instruction is (or originates from).
+	Index int
+	// Position of this instruction among the inner instructions
+	// of its top-level instruction; -1 for a top-level instruction.
+	InnerIndex int
+	// 1 for a top-level instruction, 2 for an instruction invoked by
+	// a top-level instruction, and so on.
+	StackHeight int
+
+	ProgramID solana.PublicKey
+	Accounts  solana.AccountMetaSlice
+	Data      []byte
+
+	// The decoded instruction (e.g. a *system.Instruction);
+	// a *solana.GenericInstruction if no decoder is registered for the program,
+	// or if decoding failed (see DecodeErr).
+	Decoded interface{}
+	// The error of the decoder, if any.
+	DecodeErr error
+
+	// The instructions invoked by this instruction.
+	Inner []*DecodedInstruction
+}
+
+// Walk calls fn for the instruction and all its inner instructions,
+// depth-first, in execution order; if fn returns false, the inner
+// instructions of that instruction are skipped.
+func (in *DecodedInstruction) Walk(fn func(in *DecodedInstruction) bool) {
+	if !fn(in) {
+		return
+	}
+	for _, inner := range in.Inner {
+		inner.Walk(fn)
+	}
+}
+
+// DecodeInstructions decodes all the instructions of the transaction,
+// including the inner instructions (see DecodeTransactionInstructions).
+func (res *GetTransactionResult) DecodeInstructions() ([]*DecodedInstruction, error) {
+	if res.Transaction == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+	tx, err := res.Transaction.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTransactionInstructions(tx, res.Meta)
+}
+
+// DecodeInstructions decodes all the instructions of the transaction,
+// including the inner instructions (see DecodeTransactionInstructions).
+func (twm TransactionWithMeta) DecodeInstructions() ([]*DecodedInstruction, error) {
+	if twm.Transaction == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+	tx, err := twm.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTransactionInstructions(tx, twm.Meta)
+}
+
+// DecodeTransactionInstructions decodes the top-level instructions of the transaction,
+// and the inner instructions from the meta (which is optional), into a tree of instructions.
+// The accounts loaded from address lookup tables are resolved with
+// the LoadedAddresses of the meta, unless the address tables were already set on the message.
+//
+// The instructions are decoded with solana.DecodeInstruction: the program packages
+// (e.g. programs/system, programs/token) must be imported to register their decoders.
+func DecodeTransactionInstructions(tx *solana.Transaction, meta *TransactionMeta) ([]*DecodedInstruction, error) {
+	accounts, err := transactionAccountMetas(tx, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*DecodedInstruction, len(tx.Message.Instructions))
+	for i, ci := range tx.Message.Instructions {
+		out[i], err = decodeCompiledInstruction(accounts, ci.ProgramIDIndex, ci.Accounts, ci.Data)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		out[i].Index = i
+		out[i].InnerIndex = -1
+		out[i].StackHeight = 1
+	}
+	if meta == nil {
+		return out, nil
+	}
+
+	for _, inner := range meta.InnerInstructions {
+		if int(inner.Index) >= len(out) {
+			return nil, fmt.Errorf("inner instructions of instruction %d: no such instruction", inner.Index)
+		}
+		// The last instruction seen at each stack height.
+		path := []*DecodedInstruction{out[inner.Index]}
+		for j, ci := range inner.Instructions {
+			in, err := decodeCompiledInstruction(accounts, ci.ProgramIDIndex, ci.Accounts, ci.Data)
+			if err != nil {
+				return nil, fmt.Errorf("inner instruction %d of instruction %d: %w", j, inner.Index, err)
+			}
+			in.Index = int(inner.Index)
+			in.InnerIndex = j
+			// Transactions processed before the stack height was recorded
+			// only have direct children.
+			in.StackHeight = int(ci.StackHeight)
+			if in.StackHeight < 2 {
+				in.StackHeight = 2
+			}
+			if in.StackHeight > len(path)+1 {
+				in.StackHeight = len(path) + 1
+			}
+			parent := path[in.StackHeight-2]
+			parent.Inner = append(parent.Inner, in)
+			path = append(path[:in.StackHeight-1], in)
+		}
+	}
+	return out, nil
+}
+
+// transactionAccountMetas returns the metas of all the accounts of the transaction,
+// including the ones loaded from address lookup tables.
+func transactionAccountMetas(tx *solana.Transaction, meta *TransactionMeta) (solana.AccountMetaSlice, error) {
+	msg := tx.Message
+	msg.AccountKeys = append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	if msg.IsVersioned() && msg.NumLookups() > 0 && !msg.IsResolved() && msg.GetAddressTables() == nil {
+		if meta == nil {
+			return nil, errors.New("cannot resolve the address table lookups without the transaction meta")
+		}
+		tables, err := addressTablesFromLoadedAddresses(msg.AddressTableLookups, meta.LoadedAddresses)
+		if err != nil {
+			return nil, err
+		}
+		if err := msg.SetAddressTables(tables); err != nil {
+			return nil, err
+		}
+	}
+	return msg.AccountMetaList()
+}
+
+// addressTablesFromLoadedAddresses rebuilds the (sparse) address tables of the lookups,
+// from the addresses that were loaded from them, in the same order.
+func addressTablesFromLoadedAddresses(
+	lookups solana.MessageAddressTableLookupSlice,
+	loaded LoadedAddresses,
+) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	if lookups.NumWritableLookups() != len(loaded.Writable) ||
+		lookups.NumLookups()-lookups.NumWritableLookups() != len(loaded.ReadOnly) {
+		return nil, fmt.Errorf(
+			"loaded addresses (%d writable, %d readonly) don't match the address table lookups",
+			len(loaded.Writable), len(loaded.ReadOnly),
+		)
+	}
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice)
+	set := func(table solana.PublicKey, index uint8, address solana.PublicKey) {
+		addresses := tables[table]
+		for len(addresses) <= int(index) {
+			addresses = append(addresses, solana.PublicKey{})
+		}
+		addresses[index] = address
+		tables[table] = addresses
+	}
+	var writable, readonly int
+	for _, lookup := range lookups {
+		for _, index := range lookup.WritableIndexes {
+			set(lookup.AccountKey, index, loaded.Writable[writable])
+			writable++
+		}
+		for _, index := range lookup.ReadonlyIndexes {
+			set(lookup.AccountKey, index, loaded.ReadOnly[readonly])
+			readonly++
+		}
+	}
+	return tables, nil
+}
+
+func decodeCompiledInstruction(
+	accounts solana.AccountMetaSlice,
+	programIDIndex uint16,
+	accountIndexes []uint16,
+	data []byte,
+) (*DecodedInstruction, error) {
+	if int(programIDIndex) >= len(accounts) {
+		return nil, fmt.Errorf("program ID index %d out of range", programIDIndex)
+	}
+	out := &DecodedInstruction{
+		ProgramID: accounts[programIDIndex].PublicKey,
+		Accounts:  make(solana.AccountMetaSlice, len(accountIndexes)),
+		Data:      data,
+	}
+	for i, index := range accountIndexes {
+		if int(index) >= len(accounts) {
+			return nil, fmt.Errorf("account index %d out of range", index)
+		}
+		account := *accounts[index]
+		out.Accounts[i] = &account
+	}
+
+	decoded, err := solana.DecodeInstruction(out.ProgramID, out.Accounts, data)
+	if err != nil {
+		if !errors.Is(err, solana.ErrInstructionDecoderNotFound) {
+			out.DecodeErr = err
+		}
+		decoded = solana.NewInstruction(out.ProgramID, out.Accounts, data)
+	}
+	out.Decoded = decoded
+	return out, nil
+}
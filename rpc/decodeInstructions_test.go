@@ -0,0 +1,173 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+// testTransfer is the instruction of a test program, with its registered decoder.
+type testTransfer struct {
+	Lamports uint64
+}
+
+func registerTestTransferProgram() solana.PublicKey {
+	programID := solana.NewWallet().PublicKey()
+	solana.RegisterInstructionDecoder(programID, func(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+		if len(data) != 8 {
+			return nil, fmt.Errorf("invalid data length: %d", len(data))
+		}
+		return &testTransfer{Lamports: binary.LittleEndian.Uint64(data)}, nil
+	})
+	return programID
+}
+
+func newTestTransferInstruction(programID solana.PublicKey, lamports uint64, from, to solana.PublicKey) solana.Instruction {
+	return solana.NewInstruction(
+		programID,
+		solana.AccountMetaSlice{solana.Meta(from).WRITE().SIGNER(), solana.Meta(to).WRITE()},
+		binary.LittleEndian.AppendUint64(nil, lamports),
+	)
+}
+
+func TestDecodeTransactionInstructions(t *testing.T) {
+	transferProgram := registerTestTransferProgram()
+	payer := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	readonlyAccount := solana.NewWallet().PublicKey()
+	otherProgram := solana.NewWallet().PublicKey()
+	tableID := solana.NewWallet().PublicKey()
+	table := solana.PublicKeySlice{solana.NewWallet().PublicKey(), recipient, readonlyAccount}
+
+	built, err := solana.NewTransaction(
+		[]solana.Instruction{
+			newTestTransferInstruction(transferProgram, 1, payer, recipient),
+			solana.NewInstruction(
+				otherProgram,
+				solana.AccountMetaSlice{solana.Meta(readonlyAccount)},
+				[]byte{1, 2, 3},
+			),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(payer),
+		solana.TransactionAddressTables(map[solana.PublicKey]solana.PublicKeySlice{tableID: table}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 2, built.Message.NumLookups())
+
+	// Decode it again, as if it came from the RPC.
+	data, err := built.MarshalBinary()
+	require.NoError(t, err)
+	tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(data))
+	require.NoError(t, err)
+
+	// Static keys: payer, transfer program, other program; then the loaded addresses.
+	indexOf := func(key solana.PublicKey) uint16 {
+		for i, k := range append(tx.Message.AccountKeys, recipient, readonlyAccount) {
+			if k == key {
+				return uint16(i)
+			}
+		}
+		t.Fatalf("account %s not found", key)
+		return 0
+	}
+	transferData, err := newTestTransferInstruction(transferProgram, 2, payer, recipient).Data()
+	require.NoError(t, err)
+	meta := &TransactionMeta{
+		LoadedAddresses: LoadedAddresses{
+			Writable: solana.PublicKeySlice{recipient},
+			ReadOnly: solana.PublicKeySlice{readonlyAccount},
+		},
+		InnerInstructions: []InnerInstruction{
+			{
+				Index: 0,
+				Instructions: []CompiledInstruction{
+					{
+						ProgramIDIndex: indexOf(otherProgram),
+						Accounts:       []uint16{indexOf(readonlyAccount)},
+						StackHeight:    2,
+					},
+					{
+						ProgramIDIndex: indexOf(transferProgram),
+						Accounts:       []uint16{indexOf(payer), indexOf(recipient)},
+						Data:           transferData,
+						StackHeight:    3,
+					},
+					{
+						ProgramIDIndex: indexOf(otherProgram),
+						Accounts:       []uint16{},
+						StackHeight:    2,
+					},
+				},
+			},
+		},
+	}
+
+	instructions, err := DecodeTransactionInstructions(tx, meta)
+	require.NoError(t, err)
+	require.Len(t, instructions, 2)
+
+	{
+		transfer := instructions[0]
+		require.Equal(t, transferProgram, transfer.ProgramID)
+		require.Equal(t, 1, transfer.StackHeight)
+		require.Equal(t, -1, transfer.InnerIndex)
+		require.NoError(t, transfer.DecodeErr)
+		require.Equal(t, &testTransfer{Lamports: 1}, transfer.Decoded)
+		require.Equal(t, solana.AccountMetaSlice{
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(recipient).WRITE(),
+		}, transfer.Accounts)
+
+		require.Len(t, transfer.Inner, 2)
+		require.Equal(t, otherProgram, transfer.Inner[0].ProgramID)
+		require.Equal(t, solana.AccountMetaSlice{solana.Meta(readonlyAccount)}, transfer.Inner[0].Accounts)
+		require.IsType(t, &solana.GenericInstruction{}, transfer.Inner[0].Decoded)
+
+		nested := transfer.Inner[0].Inner
+		require.Len(t, nested, 1)
+		require.Equal(t, 3, nested[0].StackHeight)
+		require.Equal(t, 1, nested[0].InnerIndex)
+		require.Equal(t, &testTransfer{Lamports: 2}, nested[0].Decoded)
+
+		require.Equal(t, 2, transfer.Inner[1].StackHeight)
+		require.Equal(t, 2, transfer.Inner[1].InnerIndex)
+	}
+	{
+		other := instructions[1]
+		require.Equal(t, 1, other.Index)
+		decoded, ok := other.Decoded.(*solana.GenericInstruction)
+		require.True(t, ok)
+		require.Equal(t, []byte{1, 2, 3}, decoded.DataBytes)
+	}
+
+	var heights []int
+	for _, in := range instructions {
+		in.Walk(func(in *DecodedInstruction) bool {
+			heights = append(heights, in.StackHeight)
+			return true
+		})
+	}
+	require.Equal(t, []int{1, 2, 3, 2, 1}, heights)
+
+	_, err = DecodeTransactionInstructions(tx, nil)
+	require.Error(t, err)
+}
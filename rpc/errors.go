@@ -14,6 +14,11 @@
 
 package rpc
 
+import (
+	stdjson "encoding/json"
+	"fmt"
+)
+
 // rpc error:
 // - https://github.com/solana-labs/solana/blob/d5961e9d9f005966f409fbddd40c3651591b27fb/client/src/rpc_custom_error.rs
 
@@ -23,3 +28,102 @@ package rpc
 
 // instruction error
 // - https://github.com/solana-labs/solana/blob/f6371cce176d481b4132e5061262ca015db0f8b1/sdk/program/src/instruction.rs
+
+// TransactionError is the error of a transaction that was processed
+// but failed while executing, as found in the `err` field
+// of transaction statuses and metas.
+//
+// The error is either a plain string (e.g. "AccountInUse"), or an object
+// with a single key (e.g. {"InstructionError":[0,{"Custom":1}]}).
+type TransactionError struct {
+	raw interface{}
+}
+
+// NewTransactionError wraps the provided `err` value;
+// it returns nil if the value is nil (i.e. the transaction succeeded).
+func NewTransactionError(raw interface{}) *TransactionError {
+	if raw == nil {
+		return nil
+	}
+	return &TransactionError{raw: raw}
+}
+
+// Raw returns the error as it was received from the RPC.
+func (e *TransactionError) Raw() interface{} {
+	return e.raw
+}
+
+// Type returns the name of the error (e.g. "InstructionError", "AccountInUse").
+func (e *TransactionError) Type() string {
+	switch v := e.raw.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		for key := range v {
+			return key
+		}
+	}
+	return ""
+}
+
+// InstructionError returns the index of the instruction that failed,
+// and the instruction error (a string, or an object like {"Custom":1}).
+// The returned bool is false if this is not an InstructionError.
+func (e *TransactionError) InstructionError() (index int, instructionErr interface{}, ok bool) {
+	obj, isMap := e.raw.(map[string]interface{})
+	if !isMap {
+		return 0, nil, false
+	}
+	tuple, isSlice := obj["InstructionError"].([]interface{})
+	if !isSlice || len(tuple) != 2 {
+		return 0, nil, false
+	}
+	switch v := tuple[0].(type) {
+	case float64:
+		index = int(v)
+	case stdjson.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, nil, false
+		}
+		index = int(i)
+	default:
+		return 0, nil, false
+	}
+	return index, tuple[1], true
+}
+
+// CustomErrorCode returns the custom program error code
+// if this is an InstructionError with a Custom error.
+func (e *TransactionError) CustomErrorCode() (code uint32, ok bool) {
+	_, instructionErr, ok := e.InstructionError()
+	if !ok {
+		return 0, false
+	}
+	obj, isMap := instructionErr.(map[string]interface{})
+	if !isMap {
+		return 0, false
+	}
+	switch v := obj["Custom"].(type) {
+	case float64:
+		return uint32(v), true
+	case stdjson.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, false
+		}
+		return uint32(i), true
+	}
+	return 0, false
+}
+
+func (e *TransactionError) Error() string {
+	if s, ok := e.raw.(string); ok {
+		return "transaction error: " + s
+	}
+	out, err := json.Marshal(e.raw)
+	if err != nil {
+		return fmt.Sprintf("transaction error: %v", e.raw)
+	}
+	return "transaction error: " + string(out)
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionError(t *testing.T) {
+	require.Nil(t, NewTransactionError(nil))
+
+	{
+		var status SignatureStatusesResult
+		err := json.Unmarshal([]byte(`{"slot":1,"confirmations":null,"err":{"InstructionError":[2,{"Custom":6001}]},"confirmationStatus":"finalized"}`), &status)
+		require.NoError(t, err)
+
+		txErr := NewTransactionError(status.Err)
+		require.Equal(t, "InstructionError", txErr.Type())
+		index, _, ok := txErr.InstructionError()
+		require.True(t, ok)
+		require.Equal(t, 2, index)
+		code, ok := txErr.CustomErrorCode()
+		require.True(t, ok)
+		require.Equal(t, uint32(6001), code)
+		require.Equal(t, `transaction error: {"InstructionError":[2,{"Custom":6001}]}`, txErr.Error())
+	}
+	{
+		txErr := NewTransactionError("AccountInUse")
+		require.Equal(t, "AccountInUse", txErr.Type())
+		_, _, ok := txErr.InstructionError()
+		require.False(t, ok)
+		_, ok = txErr.CustomErrorCode()
+		require.False(t, ok)
+		require.Equal(t, "transaction error: AccountInUse", txErr.Error())
+	}
+}
+
+func TestSignatureStatusesResult_ReachedCommitment(t *testing.T) {
+	confirmed := &SignatureStatusesResult{
+		Confirmations:      pointer.ToUint64(3),
+		ConfirmationStatus: ConfirmationStatusConfirmed,
+	}
+	require.True(t, confirmed.ReachedCommitment(CommitmentProcessed))
+	require.True(t, confirmed.ReachedCommitment(CommitmentConfirmed))
+	require.False(t, confirmed.ReachedCommitment(CommitmentFinalized))
+
+	rooted := &SignatureStatusesResult{}
+	require.True(t, rooted.ReachedCommitment(CommitmentFinalized))
+}
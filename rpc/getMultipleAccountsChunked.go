@@ -0,0 +1,146 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	// MaxMultipleAccounts is the maximum number of accounts
+	// that can be requested in a single getMultipleAccounts call.
+	MaxMultipleAccounts = 100
+
+	DefaultMultipleAccountsConcurrency = 4
+)
+
+type GetMultipleAccountsChunkedOpts struct {
+	GetMultipleAccountsOpts
+
+	// Number of accounts per getMultipleAccounts call.
+	// Defaults to (and is capped at) MaxMultipleAccounts.
+	ChunkSize int
+
+	// Maximum number of concurrent getMultipleAccounts calls.
+	// Defaults to DefaultMultipleAccountsConcurrency.
+	Concurrency int
+
+	// If true, the first chunk is fetched first, and its context slot is used as the
+	// minContextSlot of all the other chunks, so that all the accounts are read
+	// at the same or a later slot.
+	ConsistentSlot bool
+}
+
+// GetMultipleAccountsChunked returns the account information for any number of Pubkeys,
+// by splitting them into chunks that are fetched concurrently.
+// The returned accounts are in the same order as the provided Pubkeys;
+// the returned context slot is the lowest context slot of all the chunks.
+func (cl *Client) GetMultipleAccountsChunked(
+	ctx context.Context,
+	accounts []solana.PublicKey,
+	opts *GetMultipleAccountsChunkedOpts,
+) (out *GetMultipleAccountsResult, err error) {
+	if opts == nil {
+		opts = &GetMultipleAccountsChunkedOpts{}
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 || chunkSize > MaxMultipleAccounts {
+		chunkSize = MaxMultipleAccounts
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultMultipleAccountsConcurrency
+	}
+
+	out = &GetMultipleAccountsResult{
+		Value: make([]*Account, len(accounts)),
+	}
+	chunks := solana.PublicKeySlice(accounts).Split(chunkSize)
+	if len(chunks) == 0 {
+		return out, nil
+	}
+
+	chunkOpts := opts.GetMultipleAccountsOpts
+	results := make([]*GetMultipleAccountsResult, len(chunks))
+	first := 0
+	if opts.ConsistentSlot {
+		results[0], err = cl.GetMultipleAccountsWithOpts(ctx, chunks[0], &chunkOpts)
+		if err == nil && len(results[0].Value) != len(chunks[0]) {
+			err = fmt.Errorf("expected %d accounts, got %d", len(chunks[0]), len(results[0].Value))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chunk 0: %w", err)
+		}
+		minContextSlot := results[0].Context.Slot
+		if chunkOpts.MinContextSlot != nil && *chunkOpts.MinContextSlot > minContextSlot {
+			minContextSlot = *chunkOpts.MinContextSlot
+		}
+		chunkOpts.MinContextSlot = &minContextSlot
+		first = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+	for i := first; i < len(chunks); i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res, err := cl.GetMultipleAccountsWithOpts(ctx, chunks[i], &chunkOpts)
+			if err == nil && len(res.Value) != len(chunks[i]) {
+				err = fmt.Errorf("expected %d accounts, got %d", len(chunks[i]), len(res.Value))
+			}
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("chunk %d: %w", i, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = res
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, res := range results {
+		if i == 0 || res.Context.Slot < out.Context.Slot {
+			out.Context.Slot = res.Context.Slot
+		}
+		copy(out.Value[i*chunkSize:], res.Value)
+	}
+	return out, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetMultipleAccountsChunked(t *testing.T) {
+	// Each account has as many lamports as the first byte of its pubkey.
+	var slot uint64 = 100
+	var mu sync.Mutex
+	var minContextSlots []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ID     interface{}   `json:"id"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&body))
+		keys := body.Params[0].([]interface{})
+		require.LessOrEqual(t, len(keys), MaxMultipleAccounts)
+		if len(body.Params) > 1 {
+			mu.Lock()
+			minContextSlots = append(minContextSlots, body.Params[1].(map[string]interface{})["minContextSlot"])
+			mu.Unlock()
+		}
+
+		value := make([]interface{}, len(keys))
+		for i, key := range keys {
+			pubkey := solana.MustPublicKeyFromBase58(key.(string))
+			if pubkey[0] == 0 {
+				continue // not found
+			}
+			value[i] = map[string]interface{}{
+				"data":       []interface{}{"", "base64"},
+				"executable": false,
+				"lamports":   pubkey[0],
+				"owner":      solana.SystemProgramID.String(),
+				"rentEpoch":  0,
+			}
+		}
+		stdjson.NewEncoder(rw).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      body.ID,
+			"result": map[string]interface{}{
+				"context": map[string]interface{}{"slot": atomic.AddUint64(&slot, 1)},
+				"value":   value,
+			},
+		})
+	}))
+	defer server.Close()
+	client := New(server.URL)
+
+	keys := make([]solana.PublicKey, 250)
+	for i := range keys {
+		keys[i] = solana.PublicKey{byte(i % 200), byte(i)}
+	}
+
+	out, err := client.GetMultipleAccountsChunked(context.Background(), keys, &GetMultipleAccountsChunkedOpts{
+		Concurrency: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Value, len(keys))
+	for i, acc := range out.Value {
+		if i%200 == 0 {
+			require.Nil(t, acc)
+			continue
+		}
+		require.Equal(t, uint64(i%200), acc.Lamports)
+	}
+	require.Empty(t, minContextSlots)
+
+	out, err = client.GetMultipleAccountsChunked(context.Background(), keys, &GetMultipleAccountsChunkedOpts{
+		ChunkSize:      50,
+		ConsistentSlot: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Value, len(keys))
+	require.Len(t, minContextSlots, 4)
+	for _, minContextSlot := range minContextSlots {
+		require.Equal(t, float64(out.Context.Slot), minContextSlot)
+	}
+}
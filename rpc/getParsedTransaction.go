@@ -52,6 +52,29 @@ func (cl *Client) GetParsedTransaction(
 	return
 }
 
+// MarshalJSON renders the instruction like the RPC: a zero StackHeight
+// (i.e. a top-level instruction) is rendered as null, and the accounts and the data
+// of an instruction that is not parsed are always present.
+func (in ParsedInstruction) MarshalJSON() ([]byte, error) {
+	type instruction ParsedInstruction
+	out := struct {
+		instruction
+		Data        *solana.Base58      `json:"data,omitempty"`
+		Accounts    *[]solana.PublicKey `json:"accounts,omitempty"`
+		StackHeight *int64              `json:"stackHeight"`
+	}{instruction: instruction(in)}
+	if in.Parsed == nil {
+		if in.Accounts == nil {
+			in.Accounts = []solana.PublicKey{}
+		}
+		out.Data, out.Accounts = &in.Data, &in.Accounts
+	}
+	if in.StackHeight != 0 {
+		out.StackHeight = &in.StackHeight
+	}
+	return json.Marshal(out)
+}
+
 func (wrap InstructionInfoEnvelope) MarshalJSON() ([]byte, error) {
 	if wrap.asString != "" {
 		return json.Marshal(wrap.asString)
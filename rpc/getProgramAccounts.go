@@ -40,6 +40,15 @@ func (cl *Client) GetProgramAccountsWithOpts(
 	publicKey solana.PublicKey,
 	opts *GetProgramAccountsOpts,
 ) (out GetProgramAccountsResult, err error) {
+	params := getProgramAccountsParams(publicKey, opts)
+	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
+	return
+}
+
+func getProgramAccountsParams(
+	publicKey solana.PublicKey,
+	opts *GetProgramAccountsOpts,
+) []interface{} {
 	obj := M{
 		"encoding": "base64",
 	}
@@ -61,8 +70,5 @@ func (cl *Client) GetProgramAccountsWithOpts(
 		}
 	}
 
-	params := []interface{}{publicKey, obj}
-
-	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
-	return
+	return []interface{}{publicKey, obj}
 }
@@ -0,0 +1,224 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// ErrStopStream can be returned by a stream callback to stop the stream without error.
+var ErrStopStream = errors.New("stop stream")
+
+// GetProgramAccountsStream is like GetProgramAccountsWithOpts, but instead of decoding
+// the whole response in memory, it decodes the accounts one by one as they are read
+// from the response body, and calls the callback for each of them;
+// the memory usage stays bounded no matter how many accounts are returned.
+//
+// The account passed to the callback is not reused.
+// If the callback returns an error, the stream stops and the error is returned
+// (unless it is ErrStopStream).
+func (cl *Client) GetProgramAccountsStream(
+	ctx context.Context,
+	publicKey solana.PublicKey,
+	opts *GetProgramAccountsOpts,
+	callback func(account *KeyedAccount) error,
+) error {
+	params := getProgramAccountsParams(publicKey, opts)
+	err := cl.rpcClient.CallWithCallback(ctx, "getProgramAccounts", params, func(req *http.Request, resp *http.Response) error {
+		err := decodeProgramAccountsStream(stdjson.NewDecoder(resp.Body), callback)
+		if err != nil && resp.StatusCode >= 400 && !errors.Is(err, ErrStopStream) {
+			var rpcErr *jsonrpc.RPCError
+			if !errors.As(err, &rpcErr) {
+				return jsonrpc.NewHTTPError(resp.StatusCode, fmt.Errorf("rpc call getProgramAccounts() on %v status code: %v: %w", req.URL.String(), resp.StatusCode, err))
+			}
+		}
+		return err
+	})
+	if errors.Is(err, ErrStopStream) {
+		return nil
+	}
+	return err
+}
+
+// decodeProgramAccountsStream walks a JSON-RPC response token by token,
+// and decodes each element of the result array.
+func decodeProgramAccountsStream(decoder *stdjson.Decoder, callback func(account *KeyedAccount) error) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "result":
+			if err := decodeKeyedAccountsValue(decoder, callback); err != nil {
+				return err
+			}
+		case "error":
+			var rpcErr *jsonrpc.RPCError
+			if err := decoder.Decode(&rpcErr); err != nil {
+				return err
+			}
+			if rpcErr != nil {
+				return rpcErr
+			}
+		default:
+			var skip stdjson.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+// decodeKeyedAccountsValue decodes either an array of accounts,
+// or an object with the array of accounts in its "value" field.
+func decodeKeyedAccountsValue(decoder *stdjson.Decoder, callback func(account *KeyedAccount) error) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case nil:
+		return nil
+	case stdjson.Delim('['):
+		for decoder.More() {
+			var account *KeyedAccount
+			if err := decoder.Decode(&account); err != nil {
+				return err
+			}
+			if err := callback(account); err != nil {
+				return err
+			}
+		}
+		return expectDelim(decoder, ']')
+	case stdjson.Delim('{'):
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			if key == "value" {
+				if err := decodeKeyedAccountsValue(decoder, callback); err != nil {
+					return err
+				}
+				continue
+			}
+			var skip stdjson.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return err
+			}
+		}
+		return expectDelim(decoder, '}')
+	default:
+		return fmt.Errorf("unexpected token %v in result", tok)
+	}
+}
+
+func expectDelim(decoder *stdjson.Decoder, delim stdjson.Delim) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// ProgramAccountsIterator iterates over the accounts of a GetProgramAccountsStream call.
+//
+//	it := client.GetProgramAccountsIterator(ctx, programID, opts)
+//	defer it.Close()
+//	for it.Next() {
+//		account := it.Account()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ProgramAccountsIterator struct {
+	accounts chan *KeyedAccount
+	done     chan struct{}
+	cancel   context.CancelFunc
+	current  *KeyedAccount
+	err      error
+}
+
+// GetProgramAccountsIterator starts a GetProgramAccountsStream call,
+// and returns an iterator over its accounts. Call Close when done.
+func (cl *Client) GetProgramAccountsIterator(
+	ctx context.Context,
+	publicKey solana.PublicKey,
+	opts *GetProgramAccountsOpts,
+) *ProgramAccountsIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &ProgramAccountsIterator{
+		accounts: make(chan *KeyedAccount),
+		done:     make(chan struct{}),
+		cancel:   cancel,
+	}
+	go func() {
+		defer close(it.done)
+		defer close(it.accounts)
+		it.err = cl.GetProgramAccountsStream(ctx, publicKey, opts, func(account *KeyedAccount) error {
+			select {
+			case it.accounts <- account:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return it
+}
+
+// Next advances to the next account; it returns false when there are
+// no more accounts, or an error occurred (see Err).
+func (it *ProgramAccountsIterator) Next() bool {
+	account, ok := <-it.accounts
+	it.current = account
+	return ok
+}
+
+// Account returns the current account.
+func (it *ProgramAccountsIterator) Account() *KeyedAccount {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any;
+// it must be called after Next returns false.
+func (it *ProgramAccountsIterator) Err() error {
+	<-it.done
+	return it.err
+}
+
+// Close stops the iteration and releases the underlying connection.
+func (it *ProgramAccountsIterator) Close() {
+	it.cancel()
+	for range it.accounts {
+	}
+	<-it.done
+}
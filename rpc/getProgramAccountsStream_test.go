@@ -0,0 +1,109 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func mockProgramAccountsServer(t *testing.T, count int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ID     interface{}   `json:"id"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&body))
+		require.Equal(t, "base64+zstd", body.Params[1].(map[string]interface{})["encoding"])
+
+		id, _ := stdjson.Marshal(body.ID)
+		fmt.Fprintf(rw, `{"jsonrpc":"2.0","result":[`)
+		for i := 0; i < count; i++ {
+			if i > 0 {
+				rw.Write([]byte(","))
+			}
+			fmt.Fprintf(rw,
+				`{"pubkey":"%s","account":{"data":["KLUv/QQAWQAAaGVsbG8td29ybGTcLcaB","base64+zstd"],"executable":false,"lamports":%d,"owner":"11111111111111111111111111111111","rentEpoch":1}}`,
+				solana.PublicKey{byte(i), byte(i >> 8)}, i,
+			)
+		}
+		fmt.Fprintf(rw, `],"id":%s}`, id)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_GetProgramAccountsStream(t *testing.T) {
+	const count = 5000
+	client := New(mockProgramAccountsServer(t, count).URL)
+	opts := &GetProgramAccountsOpts{
+		Encoding: solana.EncodingBase64Zstd,
+	}
+
+	var got int
+	err := client.GetProgramAccountsStream(context.Background(), solana.SystemProgramID, opts, func(account *KeyedAccount) error {
+		require.Equal(t, solana.PublicKey{byte(got), byte(got >> 8)}, account.Pubkey)
+		require.Equal(t, uint64(got), account.Account.Lamports)
+		require.Equal(t, []byte("hello-world"), account.Account.Data.GetBinary())
+		got++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, count, got)
+
+	got = 0
+	err = client.GetProgramAccountsStream(context.Background(), solana.SystemProgramID, opts, func(account *KeyedAccount) error {
+		got++
+		if got == 10 {
+			return ErrStopStream
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, got)
+
+	it := client.GetProgramAccountsIterator(context.Background(), solana.SystemProgramID, opts)
+	got = 0
+	for it.Next() {
+		require.Equal(t, uint64(got), it.Account().Account.Lamports)
+		got++
+	}
+	require.NoError(t, it.Err())
+	it.Close()
+	require.Equal(t, count, got)
+}
+
+func TestClient_GetProgramAccountsStream_error(t *testing.T) {
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32010,"message":"excluded from account secondary indexes"},"id":1}`))
+	defer closer()
+	client := New(server.URL)
+
+	err := client.GetProgramAccountsStream(context.Background(), solana.SystemProgramID, nil, func(account *KeyedAccount) error {
+		return nil
+	})
+	var rpcErr *jsonrpc.RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, -32010, rpcErr.Code)
+	require.True(t, strings.Contains(rpcErr.Message, "secondary indexes"))
+}
@@ -81,3 +81,46 @@ const (
 	ConfirmationStatusConfirmed ConfirmationStatusType = "confirmed"
 	ConfirmationStatusFinalized ConfirmationStatusType = "finalized"
 )
+
+// ReachedCommitment returns true if the transaction has reached
+// (at least) the provided commitment level.
+func (s *SignatureStatusesResult) ReachedCommitment(commitment CommitmentType) bool {
+	status := s.ConfirmationStatus
+	if status == "" {
+		// Older nodes don't report the confirmation status:
+		// a nil number of confirmations means the transaction is rooted.
+		if s.Confirmations == nil {
+			status = ConfirmationStatusFinalized
+		} else {
+			status = ConfirmationStatusProcessed
+		}
+	}
+	return confirmationStatusLevel(status) >= commitmentLevel(commitment)
+}
+
+func confirmationStatusLevel(status ConfirmationStatusType) int {
+	switch status {
+	case ConfirmationStatusProcessed:
+		return 1
+	case ConfirmationStatusConfirmed:
+		return 2
+	case ConfirmationStatusFinalized:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func commitmentLevel(commitment CommitmentType) int {
+	switch commitment {
+	case CommitmentProcessed, CommitmentRecent:
+		return 1
+	case CommitmentConfirmed, CommitmentSingle, CommitmentSingleGossip:
+		return 2
+	case CommitmentFinalized, CommitmentMax, CommitmentRoot:
+		return 3
+	default:
+		// The RPC defaults to finalized.
+		return 3
+	}
+}
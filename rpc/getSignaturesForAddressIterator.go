@@ -0,0 +1,403 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	// MaxSignaturesForAddressLimit is the maximum number of signatures
+	// returned by a single getSignaturesForAddress call.
+	MaxSignaturesForAddressLimit = 1000
+
+	DefaultSignatureHistoryMaxRetries  = 3
+	DefaultSignatureHistoryRetryDelay  = 500 * time.Millisecond
+	DefaultSignatureHistoryConcurrency = 8
+)
+
+type SignatureHistoryDirection string
+
+const (
+	// From the most recent signature to the oldest one (the order of getSignaturesForAddress).
+	SignatureHistoryBackward SignatureHistoryDirection = "backward"
+	// From the oldest signature to the most recent one.
+	SignatureHistoryForward SignatureHistoryDirection = "forward"
+)
+
+// SignatureHistoryCursor is the position of a SignatureHistoryIterator:
+// the signatures that are left to iterate are the ones strictly between Until and Before
+// (a zero signature means no bound).
+// It can be marshaled to JSON, stored, and used later to resume the iteration.
+type SignatureHistoryCursor struct {
+	Direction SignatureHistoryDirection
+	Before    solana.Signature
+	Until     solana.Signature
+}
+
+type signatureHistoryCursorJSON struct {
+	Direction SignatureHistoryDirection `json:"direction"`
+	Before    *solana.Signature         `json:"before,omitempty"`
+	Until     *solana.Signature         `json:"until,omitempty"`
+}
+
+func (c SignatureHistoryCursor) MarshalJSON() ([]byte, error) {
+	out := signatureHistoryCursorJSON{
+		Direction: c.Direction,
+	}
+	if !c.Before.IsZero() {
+		out.Before = &c.Before
+	}
+	if !c.Until.IsZero() {
+		out.Until = &c.Until
+	}
+	return json.Marshal(out)
+}
+
+func (c *SignatureHistoryCursor) UnmarshalJSON(data []byte) error {
+	var in signatureHistoryCursorJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*c = SignatureHistoryCursor{
+		Direction: in.Direction,
+	}
+	if in.Before != nil {
+		c.Before = *in.Before
+	}
+	if in.Until != nil {
+		c.Until = *in.Until
+	}
+	return nil
+}
+
+type SignatureHistoryOpts struct {
+	// Iteration order. Defaults to SignatureHistoryBackward.
+	//
+	// Going forward, all the signatures between the bounds are fetched
+	// (from the most recent one) before the first one is returned.
+	Direction SignatureHistoryDirection
+
+	// (optional) Only iterate over the signatures older than this one.
+	Before solana.Signature
+
+	// (optional) Only iterate over the signatures newer than this one.
+	Until solana.Signature
+
+	// (optional) Resume from a cursor returned by SignatureHistoryIterator.Cursor;
+	// if set, Direction, Before and Until are ignored.
+	Cursor *SignatureHistoryCursor
+
+	// Number of signatures per getSignaturesForAddress call.
+	// Defaults to (and is capped at) MaxSignaturesForAddressLimit.
+	PageSize int
+
+	// (optional) Commitment; "processed" is not supported.
+	Commitment CommitmentType
+
+	// Number of times a failed call is retried, with an exponential backoff
+	// starting at RetryDelay. Defaults to DefaultSignatureHistoryMaxRetries;
+	// a negative value disables retries.
+	MaxRetries int
+	RetryDelay time.Duration
+
+	// If true, the transaction of each signature is fetched with GetTransaction.
+	FetchTransactions bool
+	// Options of the GetTransaction calls.
+	TransactionOpts *GetTransactionOpts
+	// Maximum number of concurrent GetTransaction calls.
+	// Defaults to DefaultSignatureHistoryConcurrency.
+	Concurrency int
+}
+
+type SignatureHistoryEntry struct {
+	Signature *TransactionSignature
+
+	// Only set if FetchTransactions is true.
+	Transaction *GetTransactionResult
+}
+
+// SignatureHistoryIterator iterates over all the signatures of an address,
+// one getSignaturesForAddress page at a time.
+//
+//	it := client.GetSignaturesForAddressIterator(account, opts)
+//	for it.Next(ctx) {
+//		entry := it.Entry()
+//		...
+//		save(it.Cursor())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// After an error, Next can be called again to retry from the same position.
+// An iterator is not safe for concurrent use.
+type SignatureHistoryIterator struct {
+	cl      *Client
+	account solana.PublicKey
+	opts    SignatureHistoryOpts
+	cursor  SignatureHistoryCursor
+
+	// Position of the next getSignaturesForAddress page.
+	fetchBefore solana.Signature
+	exhausted   bool
+	// Signatures of the last pages, to skip duplicates.
+	seen     map[solana.Signature]struct{}
+	prevSeen map[solana.Signature]struct{}
+
+	// Signatures that were fetched but not returned yet, in iteration order.
+	pending []*TransactionSignature
+	ready   []*SignatureHistoryEntry
+	current *SignatureHistoryEntry
+	err     error
+}
+
+// GetSignaturesForAddressIterator returns an iterator over the signatures
+// of the transactions involving the provided address.
+func (cl *Client) GetSignaturesForAddressIterator(
+	account solana.PublicKey,
+	opts *SignatureHistoryOpts,
+) *SignatureHistoryIterator {
+	it := &SignatureHistoryIterator{
+		cl:      cl,
+		account: account,
+	}
+	if opts != nil {
+		it.opts = *opts
+	}
+	if it.opts.Cursor != nil {
+		it.cursor = *it.opts.Cursor
+	} else {
+		it.cursor = SignatureHistoryCursor{
+			Direction: it.opts.Direction,
+			Before:    it.opts.Before,
+			Until:     it.opts.Until,
+		}
+	}
+	if it.cursor.Direction == "" {
+		it.cursor.Direction = SignatureHistoryBackward
+	}
+	if it.opts.PageSize <= 0 || it.opts.PageSize > MaxSignaturesForAddressLimit {
+		it.opts.PageSize = MaxSignaturesForAddressLimit
+	}
+	if it.opts.MaxRetries == 0 {
+		it.opts.MaxRetries = DefaultSignatureHistoryMaxRetries
+	}
+	if it.opts.RetryDelay <= 0 {
+		it.opts.RetryDelay = DefaultSignatureHistoryRetryDelay
+	}
+	if it.opts.Concurrency <= 0 {
+		it.opts.Concurrency = DefaultSignatureHistoryConcurrency
+	}
+	it.fetchBefore = it.cursor.Before
+	return it
+}
+
+// Next advances to the next signature; it returns false when there are
+// no more signatures, or an error occurred (see Err).
+func (it *SignatureHistoryIterator) Next(ctx context.Context) bool {
+	it.err = nil
+	for len(it.ready) == 0 {
+		if len(it.pending) == 0 {
+			if it.exhausted {
+				return false
+			}
+			if err := it.fetch(ctx); err != nil {
+				it.err = err
+				return false
+			}
+			continue
+		}
+		n := len(it.pending)
+		if n > it.opts.PageSize {
+			n = it.opts.PageSize
+		}
+		entries, err := it.entries(ctx, it.pending[:n])
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.pending = it.pending[n:]
+		it.ready = entries
+	}
+
+	it.current = it.ready[0]
+	it.ready = it.ready[1:]
+	if it.cursor.Direction == SignatureHistoryForward {
+		it.cursor.Until = it.current.Signature.Signature
+	} else {
+		it.cursor.Before = it.current.Signature.Signature
+	}
+	return true
+}
+
+// Entry returns the current signature (and transaction).
+func (it *SignatureHistoryIterator) Entry() *SignatureHistoryEntry {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *SignatureHistoryIterator) Err() error {
+	return it.err
+}
+
+// Cursor returns the position of the iterator, right after the current signature.
+func (it *SignatureHistoryIterator) Cursor() SignatureHistoryCursor {
+	return it.cursor
+}
+
+func (it *SignatureHistoryIterator) fetch(ctx context.Context) error {
+	if it.cursor.Direction != SignatureHistoryForward {
+		page, err := it.fetchPage(ctx)
+		if err != nil {
+			return err
+		}
+		it.pending = page
+		return nil
+	}
+
+	// Going forward, all the signatures have to be fetched first,
+	// since getSignaturesForAddress only goes backward.
+	var all []*TransactionSignature
+	for !it.exhausted {
+		page, err := it.fetchPage(ctx)
+		if err != nil {
+			// Start over on the next call.
+			it.fetchBefore = it.cursor.Before
+			it.exhausted = false
+			it.seen, it.prevSeen = nil, nil
+			return err
+		}
+		all = append(all, page...)
+	}
+	for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+		all[i], all[j] = all[j], all[i]
+	}
+	it.pending = all
+	return nil
+}
+
+// fetchPage fetches the next page of signatures, without the duplicates.
+func (it *SignatureHistoryIterator) fetchPage(ctx context.Context) ([]*TransactionSignature, error) {
+	limit := it.opts.PageSize
+	var page []*TransactionSignature
+	err := it.retry(ctx, func() (err error) {
+		page, err = it.cl.GetSignaturesForAddressWithOpts(ctx, it.account, &GetSignaturesForAddressOpts{
+			Limit:      &limit,
+			Before:     it.fetchBefore,
+			Until:      it.cursor.Until,
+			Commitment: it.opts.Commitment,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getSignaturesForAddress before %s: %w", it.fetchBefore, err)
+	}
+	if len(page) < limit {
+		it.exhausted = true
+	}
+	if len(page) > 0 {
+		it.fetchBefore = page[len(page)-1].Signature
+	}
+
+	it.prevSeen, it.seen = it.seen, make(map[solana.Signature]struct{}, len(page))
+	out := page[:0]
+	for _, sig := range page {
+		if sig == nil {
+			continue
+		}
+		if _, ok := it.prevSeen[sig.Signature]; ok {
+			continue
+		}
+		if _, ok := it.seen[sig.Signature]; ok {
+			continue
+		}
+		it.seen[sig.Signature] = struct{}{}
+		out = append(out, sig)
+	}
+	return out, nil
+}
+
+// entries fetches the transactions of the signatures, if requested.
+func (it *SignatureHistoryIterator) entries(ctx context.Context, sigs []*TransactionSignature) ([]*SignatureHistoryEntry, error) {
+	out := make([]*SignatureHistoryEntry, len(sigs))
+	for i, sig := range sigs {
+		out[i] = &SignatureHistoryEntry{Signature: sig}
+	}
+	if !it.opts.FetchTransactions {
+		return out, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	sem := make(chan struct{}, it.opts.Concurrency)
+	for i := range out {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(entry *SignatureHistoryEntry) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := it.retry(ctx, func() (err error) {
+				entry.Transaction, err = it.cl.GetTransaction(ctx, entry.Signature.Signature, it.opts.TransactionOpts)
+				return err
+			})
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("getTransaction %s: %w", entry.Signature.Signature, err)
+					cancel()
+				})
+			}
+		}(out[i])
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (it *SignatureHistoryIterator) retry(ctx context.Context, call func() error) error {
+	delay := it.opts.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= it.opts.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSignatureHistory serves getSignaturesForAddress and getTransaction
+// for an address with the provided number of transactions; the signature of
+// the transaction at slot i is {i}. The first failures calls fail.
+func mockSignatureHistory(t *testing.T, count int, failures int) (*Client, func(method string) int) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var request struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&request))
+
+		mu.Lock()
+		calls[request.Method]++
+		fail := failures > 0
+		failures--
+		mu.Unlock()
+		if fail {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var result interface{}
+		switch request.Method {
+		case "getSignaturesForAddress":
+			opts := request.Params[1].(map[string]interface{})
+			before, until := count+1, 0
+			if v, ok := opts["before"]; ok {
+				before = int(solana.MustSignatureFromBase58(v.(string))[0])
+			}
+			if v, ok := opts["until"]; ok {
+				until = int(solana.MustSignatureFromBase58(v.(string))[0])
+			}
+			limit := int(opts["limit"].(float64))
+			sigs := []map[string]interface{}{}
+			for slot := before - 1; slot > until && slot > 0 && len(sigs) < limit; slot-- {
+				sigs = append(sigs, map[string]interface{}{
+					"signature": solana.Signature{byte(slot)}.String(),
+					"slot":      slot,
+					"err":       nil,
+				})
+			}
+			result = sigs
+		case "getTransaction":
+			sig := solana.MustSignatureFromBase58(request.Params[0].(string))
+			result = map[string]interface{}{"slot": sig[0]}
+		}
+		stdjson.NewEncoder(rw).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result":  result,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return New(server.URL), func(method string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls[method]
+	}
+}
+
+func collectSignatureHistory(t *testing.T, it *SignatureHistoryIterator, max int) []int {
+	var slots []int
+	for len(slots) < max && it.Next(context.Background()) {
+		entry := it.Entry()
+		slots = append(slots, int(entry.Signature.Slot))
+		if entry.Transaction != nil {
+			require.Equal(t, entry.Signature.Slot, entry.Transaction.Slot)
+		}
+	}
+	require.NoError(t, it.Err())
+	return slots
+}
+
+func slotRange(from, to int) []int {
+	var out []int
+	for i := from; ; {
+		out = append(out, i)
+		if i == to {
+			return out
+		}
+		if from < to {
+			i++
+		} else {
+			i--
+		}
+	}
+}
+
+func TestSignatureHistoryIterator(t *testing.T) {
+	account := solana.SysVarClockPubkey
+
+	t.Run("backward", func(t *testing.T) {
+		client, calls := mockSignatureHistory(t, 25, 0)
+		it := client.GetSignaturesForAddressIterator(account, &SignatureHistoryOpts{PageSize: 10})
+		require.Equal(t, slotRange(25, 1), collectSignatureHistory(t, it, 100))
+		require.Equal(t, 3, calls("getSignaturesForAddress"))
+		require.Equal(t, 0, calls("getTransaction"))
+	})
+
+	t.Run("forward with bounds", func(t *testing.T) {
+		client, _ := mockSignatureHistory(t, 25, 0)
+		it := client.GetSignaturesForAddressIterator(account, &SignatureHistoryOpts{
+			Direction: SignatureHistoryForward,
+			PageSize:  4,
+			Before:    solana.Signature{20},
+			Until:     solana.Signature{5},
+		})
+		require.Equal(t, slotRange(6, 19), collectSignatureHistory(t, it, 100))
+	})
+
+	for _, direction := range []SignatureHistoryDirection{SignatureHistoryBackward, SignatureHistoryForward} {
+		t.Run("resume "+string(direction), func(t *testing.T) {
+			client, _ := mockSignatureHistory(t, 25, 0)
+			opts := &SignatureHistoryOpts{Direction: direction, PageSize: 7}
+			it := client.GetSignaturesForAddressIterator(account, opts)
+			first := collectSignatureHistory(t, it, 9)
+
+			data, err := stdjson.Marshal(it.Cursor())
+			require.NoError(t, err)
+			var cursor SignatureHistoryCursor
+			require.NoError(t, stdjson.Unmarshal(data, &cursor))
+			require.Equal(t, it.Cursor(), cursor)
+
+			opts.Cursor = &cursor
+			it = client.GetSignaturesForAddressIterator(account, opts)
+			rest := collectSignatureHistory(t, it, 100)
+			if direction == SignatureHistoryForward {
+				require.Equal(t, slotRange(1, 25), append(first, rest...))
+			} else {
+				require.Equal(t, slotRange(25, 1), append(first, rest...))
+			}
+		})
+	}
+
+	t.Run("transactions and retries", func(t *testing.T) {
+		client, calls := mockSignatureHistory(t, 12, 2)
+		it := client.GetSignaturesForAddressIterator(account, &SignatureHistoryOpts{
+			PageSize:          5,
+			RetryDelay:        time.Millisecond,
+			FetchTransactions: true,
+			Concurrency:       3,
+		})
+		require.Equal(t, slotRange(12, 1), collectSignatureHistory(t, it, 100))
+		require.Equal(t, 12, calls("getTransaction"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		client, _ := mockSignatureHistory(t, 12, 2)
+		it := client.GetSignaturesForAddressIterator(account, &SignatureHistoryOpts{MaxRetries: -1})
+		require.False(t, it.Next(context.Background()))
+		require.Error(t, it.Err())
+		require.False(t, it.Next(context.Background()))
+		require.Error(t, it.Err())
+		// The server recovered.
+		require.Equal(t, slotRange(12, 1), collectSignatureHistory(t, it, 100))
+	})
+}
@@ -262,6 +262,7 @@ type rpcClient struct {
 	endpoint      string
 	httpClient    HTTPClient
 	customHeaders map[string]string
+	handler       Handler
 }
 
 // RPCClientOpts can be provided to NewClientWithOpts() to change configuration of RPCClient.
@@ -272,6 +273,8 @@ type rpcClient struct {
 type RPCClientOpts struct {
 	HTTPClient    HTTPClient
 	CustomHeaders map[string]string
+	// Middlewares are run around each call, the first one being the outermost.
+	Middlewares []Middleware
 }
 
 // RPCResponses is of type []*RPCResponse.
@@ -340,6 +343,7 @@ func NewClientWithOpts(endpoint string, opts *RPCClientOpts) RPCClient {
 		httpClient:    &http.Client{},
 		customHeaders: make(map[string]string),
 	}
+	rpcClient.handler = rpcClient.send
 
 	if opts == nil {
 		return rpcClient
@@ -355,6 +359,8 @@ func NewClientWithOpts(endpoint string, opts *RPCClientOpts) RPCClient {
 		}
 	}
 
+	rpcClient.handler = Chain(opts.Middlewares...)(rpcClient.send)
+
 	return rpcClient
 }
 
@@ -489,9 +495,10 @@ func (client *rpcClient) doCall(
 	RPCRequest *RPCRequest,
 ) (*RPCResponse, error) {
 	var rpcResponse *RPCResponse
-	err := client.doCallWithCallbackOnHTTPResponse(
+	call := &Call{Request: RPCRequest}
+	err := client.doCallWithCallback(
 		ctx,
-		RPCRequest,
+		call,
 		func(httpRequest *http.Request, httpResponse *http.Response) error {
 			decoder := json.NewDecoder(httpResponse.Body)
 			decoder.DisallowUnknownFields()
@@ -520,6 +527,7 @@ func (client *rpcClient) doCall(
 				}
 				return fmt.Errorf("rpc call %v() on %v status code: %v. rpc response missing", RPCRequest.Method, httpRequest.URL.String(), httpResponse.StatusCode)
 			}
+			call.Response = rpcResponse
 			return nil
 		},
 	)
@@ -553,6 +561,15 @@ func (client *rpcClient) doCallWithCallbackOnHTTPResponse(
 	RPCRequest *RPCRequest,
 	callback func(*http.Request, *http.Response) error,
 ) error {
+	return client.doCallWithCallback(ctx, &Call{Request: RPCRequest}, callback)
+}
+
+func (client *rpcClient) doCallWithCallback(
+	ctx context.Context,
+	call *Call,
+	callback func(*http.Request, *http.Response) error,
+) error {
+	RPCRequest := call.Request
 	if RPCRequest != nil && RPCRequest.ID == nil {
 		RPCRequest.ID = newID()
 	}
@@ -563,13 +580,14 @@ func (client *rpcClient) doCallWithCallbackOnHTTPResponse(
 		}
 		return fmt.Errorf("rpc call %v(): %w", RPCRequest.Method, err)
 	}
-	httpResponse, err := client.httpClient.Do(httpRequest)
-	if err != nil {
-		return fmt.Errorf("rpc call %v() on %v: %w", RPCRequest.Method, httpRequest.URL.String(), err)
+	call.HTTPRequest = httpRequest
+	call.callback = func(httpRequest *http.Request, httpResponse *http.Response, err error) error {
+		if err != nil {
+			return fmt.Errorf("rpc call %v() on %v: %w", RPCRequest.Method, httpRequest.URL.String(), err)
+		}
+		return callback(httpRequest, httpResponse)
 	}
-	defer httpResponse.Body.Close()
-
-	return callback(httpRequest, httpResponse)
+	return client.handler(ctx, call)
 }
 
 func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCRequest) ([]*RPCResponse, error) {
@@ -580,39 +598,48 @@ func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCReque
 		}
 		return nil, fmt.Errorf("rpc batch call: %w", err)
 	}
-	httpResponse, err := client.httpClient.Do(httpRequest)
-	if err != nil {
-		return nil, fmt.Errorf("rpc batch call on %v: %w", httpRequest.URL.String(), err)
-	}
-	defer httpResponse.Body.Close()
 
 	var rpcResponse RPCResponses
-	decoder := json.NewDecoder(httpResponse.Body)
-	decoder.DisallowUnknownFields()
-	decoder.UseNumber()
-	err = decoder.Decode(&rpcResponse)
-	// parsing error
-	if err != nil {
-		// if we have some http error, return it
-		if httpResponse.StatusCode >= 400 {
-			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc batch call on %v status code: %v. could not decode body to rpc response: %w", httpRequest.URL.String(), httpResponse.StatusCode, err),
+	call := &Call{
+		Requests:    rpcRequest,
+		HTTPRequest: httpRequest,
+	}
+	call.callback = func(httpRequest *http.Request, httpResponse *http.Response, err error) error {
+		if err != nil {
+			return fmt.Errorf("rpc batch call on %v: %w", httpRequest.URL.String(), err)
+		}
+		decoder := json.NewDecoder(httpResponse.Body)
+		decoder.DisallowUnknownFields()
+		decoder.UseNumber()
+		err = decoder.Decode(&rpcResponse)
+		// parsing error
+		if err != nil {
+			// if we have some http error, return it
+			if httpResponse.StatusCode >= 400 {
+				return &HTTPError{
+					Code: httpResponse.StatusCode,
+					err:  fmt.Errorf("rpc batch call on %v status code: %v. could not decode body to rpc response: %w", httpRequest.URL.String(), httpResponse.StatusCode, err),
+				}
 			}
+			return fmt.Errorf("rpc batch call on %v status code: %v. could not decode body to rpc response: %w", httpRequest.URL.String(), httpResponse.StatusCode, err)
 		}
-		return nil, fmt.Errorf("rpc batch call on %v status code: %v. could not decode body to rpc response: %w", httpRequest.URL.String(), httpResponse.StatusCode, err)
-	}
 
-	// response body empty
-	if rpcResponse == nil || len(rpcResponse) == 0 {
-		// if we have some http error, return it
-		if httpResponse.StatusCode >= 400 {
-			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode),
+		// response body empty
+		if rpcResponse == nil || len(rpcResponse) == 0 {
+			// if we have some http error, return it
+			if httpResponse.StatusCode >= 400 {
+				return &HTTPError{
+					Code: httpResponse.StatusCode,
+					err:  fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode),
+				}
 			}
+			return fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode)
 		}
-		return nil, fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode)
+		call.Responses = rpcResponse
+		return nil
+	}
+	if err := client.handler(ctx, call); err != nil {
+		return nil, err
 	}
 
 	return rpcResponse, nil
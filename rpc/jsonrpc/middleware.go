@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Call is a JSON-RPC call (or batch call) going through the middlewares.
+type Call struct {
+	// The request, for single calls.
+	Request *RPCRequest
+	// The requests, for batch calls.
+	Requests RPCRequests
+	// The HTTP request that will be sent; middlewares can modify it,
+	// e.g. to add headers (the body can be read with HTTPRequest.GetBody).
+	HTTPRequest *http.Request
+
+	// The following fields are set once the call is done.
+
+	// The HTTP status code of the response (0 if no response was received).
+	StatusCode int
+	// The HTTP headers of the response (nil if no response was received).
+	ResponseHeader http.Header
+	// The number of bytes read from the response body.
+	ResponseSize int64
+	// The duration of the HTTP request, including the decoding of the response.
+	Duration time.Duration
+	// The decoded response, for single calls made with Call, CallFor, CallForInto or CallRaw
+	// (the response of CallWithCallback is handled by the callback).
+	// Note that a response with an error is not a failed call.
+	Response *RPCResponse
+	// The decoded responses, for batch calls.
+	Responses RPCResponses
+
+	callback func(*http.Request, *http.Response, error) error
+}
+
+// IsBatch tells whether this is a batch call.
+func (c *Call) IsBatch() bool {
+	return c.Request == nil
+}
+
+// Method returns the method of the request, or "batch" for batch calls.
+func (c *Call) Method() string {
+	if c.IsBatch() {
+		return "batch"
+	}
+	return c.Request.Method
+}
+
+// Params returns the params of the request, or nil for batch calls.
+func (c *Call) Params() interface{} {
+	if c.IsBatch() {
+		return nil
+	}
+	return c.Request.Params
+}
+
+// Handler sends a call.
+type Handler func(ctx context.Context, call *Call) error
+
+// Middleware wraps a Handler, to run code around calls:
+//
+//	logger := func(next jsonrpc.Handler) jsonrpc.Handler {
+//		return func(ctx context.Context, call *jsonrpc.Call) error {
+//			err := next(ctx, call)
+//			log.Printf("%s: %d bytes in %s, err=%v", call.Method(), call.ResponseSize, call.Duration, err)
+//			return err
+//		}
+//	}
+//	client := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+//		Middlewares: []jsonrpc.Middleware{logger},
+//	})
+//
+// A middleware can return without calling next, e.g. to serve a call from a cache.
+type Middleware func(next Handler) Handler
+
+// Chain composes the middlewares into one, the first one being the outermost.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next Handler) Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// MethodTimeouts returns a middleware that applies a timeout to the calls,
+// by method; the defaultTimeout is used for the other methods (and batch calls)
+// unless it is zero.
+func MethodTimeouts(timeouts map[string]time.Duration, defaultTimeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			timeout, ok := timeouts[call.Method()]
+			if !ok {
+				timeout = defaultTimeout
+			}
+			if timeout <= 0 {
+				return next(ctx, call)
+			}
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(ctx, call)
+		}
+	}
+}
+
+// send is the innermost handler: it does the HTTP request,
+// and passes the response to the callback of the call.
+func (client *rpcClient) send(ctx context.Context, call *Call) error {
+	start := time.Now()
+	defer func() {
+		call.Duration = time.Since(start)
+	}()
+	// Clear the response of the previous attempt, if the call is retried.
+	call.StatusCode = 0
+	call.ResponseHeader = nil
+	call.ResponseSize = 0
+
+	httpRequest := call.HTTPRequest.WithContext(ctx)
+	httpResponse, err := client.httpClient.Do(httpRequest)
+	if err != nil {
+		return call.callback(httpRequest, nil, err)
+	}
+	defer httpResponse.Body.Close()
+
+	call.StatusCode = httpResponse.StatusCode
+	call.ResponseHeader = httpResponse.Header
+	body := &countingReader{Reader: httpResponse.Body}
+	httpResponse.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, httpResponse.Body}
+	err = call.callback(httpRequest, httpResponse, nil)
+	call.ResponseSize = body.n
+	return err
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,122 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewares(t *testing.T) {
+	var order []string
+	var calls []*Call
+	recorder := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) error {
+				order = append(order, name+" before")
+				err := next(ctx, call)
+				order = append(order, name+" after")
+				calls = append(calls, call)
+				return err
+			}
+		}
+	}
+	signer := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			body, err := call.HTTPRequest.GetBody()
+			if err != nil {
+				return err
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			call.HTTPRequest.Header.Set("X-Signature", string(data[:10]))
+			return next(ctx, call)
+		}
+	}
+	rpcClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		Middlewares: []Middleware{recorder("outer"), signer, recorder("inner")},
+	})
+
+	responseBody = `{"jsonrpc":"2.0","id":1,"error":{"code":-32004,"message":"Block not available"}}`
+	err := rpcClient.CallForInto(context.Background(), nil, "getBlock", []interface{}{42})
+	request := <-requestChan
+	var rpcErr *RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, order)
+	require.Equal(t, `{"method":`, request.request.Header.Get("X-Signature"))
+
+	require.Len(t, calls, 2)
+	call := calls[1]
+	require.Same(t, calls[0], call)
+	require.False(t, call.IsBatch())
+	require.Equal(t, "getBlock", call.Method())
+	require.Equal(t, []interface{}{42}, call.Params())
+	require.Equal(t, http.StatusOK, call.StatusCode)
+	require.Equal(t, int64(len(responseBody)), call.ResponseSize)
+	require.NotZero(t, call.Duration)
+	require.Equal(t, -32004, call.Response.Error.Code)
+
+	calls = nil
+	responseBody = `[{"jsonrpc":"2.0","id":0,"result":1},{"jsonrpc":"2.0","id":1,"result":2}]`
+	responses, err := rpcClient.CallBatch(context.Background(), RPCRequests{
+		NewRequest("getSlot"),
+		NewRequest("getBlockHeight"),
+	})
+	<-requestChan
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	require.True(t, calls[0].IsBatch())
+	require.Equal(t, "batch", calls[0].Method())
+	require.Len(t, calls[0].Requests, 2)
+	require.Equal(t, responses, calls[0].Responses)
+}
+
+func TestMethodTimeouts(t *testing.T) {
+	var deadlines []time.Duration
+	var sawErr error
+	rpcClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		Middlewares: []Middleware{
+			func(next Handler) Handler {
+				return func(ctx context.Context, call *Call) error {
+					sawErr = next(ctx, call)
+					return sawErr
+				}
+			},
+			MethodTimeouts(map[string]time.Duration{"getProgramAccounts": time.Minute}, 0),
+			func(next Handler) Handler {
+				return func(ctx context.Context, call *Call) error {
+					deadline, ok := ctx.Deadline()
+					if ok {
+						deadlines = append(deadlines, time.Until(deadline))
+					} else {
+						deadlines = append(deadlines, 0)
+					}
+					if call.Method() == "fail" {
+						return errors.New("boom")
+					}
+					return next(ctx, call)
+				}
+			},
+		},
+	})
+
+	responseBody = `{"jsonrpc":"2.0","id":1,"result":1}`
+	var out int
+	require.NoError(t, rpcClient.CallForInto(context.Background(), &out, "getProgramAccounts", nil))
+	<-requestChan
+	require.NoError(t, rpcClient.CallForInto(context.Background(), &out, "getSlot", nil))
+	<-requestChan
+	require.Len(t, deadlines, 2)
+	require.Greater(t, deadlines[0], 50*time.Second)
+	require.Zero(t, deadlines[1])
+
+	err := rpcClient.CallForInto(context.Background(), nil, "fail", nil)
+	require.EqualError(t, err, "boom")
+	require.Equal(t, err, sawErr)
+}
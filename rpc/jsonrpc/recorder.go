@@ -0,0 +1,320 @@
+package jsonrpc
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// ErrInteractionNotFound is returned in replay mode when the cassette
+// has no interaction matching a request.
+var ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+type RecorderMode int
+
+const (
+	// Forward the requests to the HTTP client, and record the interactions.
+	RecorderModeRecord RecorderMode = iota
+	// Serve the requests from the cassette only.
+	RecorderModeReplay
+	// Serve the requests from the cassette when possible,
+	// and forward and record the others.
+	RecorderModeReplayOrRecord
+)
+
+// Cassette is the list of recorded interactions, as stored in a cassette file.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request/response pair.
+// The request is stored without its IDs, and the IDs of the response are replaced
+// with the index of the matching request in the batch (0 for a single request).
+type Interaction struct {
+	Request    stdjson.RawMessage `json:"request"`
+	StatusCode int                `json:"statusCode"`
+	// The response, if it is valid JSON.
+	Response stdjson.RawMessage `json:"response,omitempty"`
+	// The response body otherwise.
+	Body string `json:"body,omitempty"`
+}
+
+// Recorder is a HTTPClient that records JSON-RPC interactions to a cassette file,
+// and replays them, to turn calls to a live node into offline tests:
+//
+//	recorder, err := jsonrpc.NewRecorder("testdata/getAccountInfo.json", jsonrpc.RecorderModeRecord, nil)
+//	...
+//	client := rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{HTTPClient: recorder}))
+//	...
+//	err = recorder.Save()
+//
+// Requests are matched on their methods and params (the IDs are ignored);
+// when the same request was recorded several times, the responses are replayed
+// in the recorded order, and the last one is repeated.
+type Recorder struct {
+	path   string
+	mode   RecorderMode
+	client HTTPClient
+
+	mu       sync.Mutex
+	cassette Cassette
+	// Index of the next interaction to replay, by request key.
+	replayed map[string]int
+}
+
+// NewRecorder creates a recorder for the cassette file at path;
+// in the replay modes, the cassette is loaded (it is only required in RecorderModeReplay).
+// The client is used to forward the requests; defaults to a http.Client.
+func NewRecorder(path string, mode RecorderMode, client HTTPClient) (*Recorder, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+	rec := &Recorder{
+		path:     path,
+		mode:     mode,
+		client:   client,
+		replayed: make(map[string]int),
+	}
+	if mode == RecorderModeRecord {
+		return rec, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && mode == RecorderModeReplayOrRecord {
+			return rec, nil
+		}
+		return nil, fmt.Errorf("unable to read cassette: %w", err)
+	}
+	if err := stdjson.Unmarshal(data, &rec.cassette); err != nil {
+		return nil, fmt.Errorf("unable to decode cassette %s: %w", path, err)
+	}
+	// The cassette is indented (and may have been edited by hand).
+	for _, interaction := range rec.cassette.Interactions {
+		key, _, err := normalizeRecordedRequest(interaction.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request in cassette %s: %w", path, err)
+		}
+		interaction.Request = stdjson.RawMessage(key)
+	}
+	return rec, nil
+}
+
+// Cassette returns the recorded interactions.
+func (rec *Recorder) Cassette() Cassette {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return Cassette{Interactions: append([]*Interaction(nil), rec.cassette.Interactions...)}
+}
+
+// Save writes the cassette file.
+func (rec *Recorder) Save() error {
+	rec.mu.Lock()
+	data, err := stdjson.MarshalIndent(rec.cassette, "", "  ")
+	rec.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(rec.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(rec.path, data, 0o644)
+}
+
+func (rec *Recorder) CloseIdleConnections() {
+	rec.client.CloseIdleConnections()
+}
+
+func (rec *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	key, ids, err := normalizeRecordedRequest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if rec.mode != RecorderModeRecord {
+		if interaction := rec.nextReplay(key); interaction != nil {
+			return replayInteraction(req, interaction, ids)
+		}
+		if rec.mode == RecorderModeReplay {
+			return nil, fmt.Errorf("%w: %s", ErrInteractionNotFound, key)
+		}
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	resp, err := rec.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request:    stdjson.RawMessage(key),
+		StatusCode: resp.StatusCode,
+	}
+	if normalized, err := replaceResponseIDs(respBody, func(id interface{}) interface{} {
+		for i, requestID := range ids {
+			if sameID(id, requestID) {
+				return i
+			}
+		}
+		return id
+	}); err == nil {
+		interaction.Response = normalized
+	} else {
+		interaction.Body = string(respBody)
+	}
+	rec.mu.Lock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, interaction)
+	// Don't replay what was just recorded in RecorderModeReplayOrRecord.
+	rec.replayed[key]++
+	rec.mu.Unlock()
+	return resp, nil
+}
+
+func (rec *Recorder) nextReplay(key string) *Interaction {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	var matching []*Interaction
+	for _, interaction := range rec.cassette.Interactions {
+		if string(interaction.Request) == key {
+			matching = append(matching, interaction)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	next := rec.replayed[key]
+	if next >= len(matching) {
+		if rec.mode == RecorderModeReplayOrRecord {
+			return nil
+		}
+		next = len(matching) - 1
+	}
+	rec.replayed[key]++
+	return matching[next]
+}
+
+func replayInteraction(req *http.Request, interaction *Interaction, ids []interface{}) (*http.Response, error) {
+	body := []byte(interaction.Body)
+	if len(interaction.Response) > 0 {
+		var err error
+		body, err = replaceResponseIDs(interaction.Response, func(id interface{}) interface{} {
+			if index, ok := id.(stdjson.Number); ok {
+				if i, err := strconv.Atoi(index.String()); err == nil && i >= 0 && i < len(ids) {
+					return ids[i]
+				}
+			}
+			return id
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// normalizeRecordedRequest returns the request (or batch of requests) without
+// the IDs, as canonical JSON, and the IDs of the requests.
+func normalizeRecordedRequest(body []byte) (key string, ids []interface{}, err error) {
+	decoded, err := decodeWithNumbers(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to decode request: %w", err)
+	}
+	strip := func(v interface{}) interface{} {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		ids = append(ids, obj["id"])
+		out := make(map[string]interface{}, len(obj))
+		for k, v := range obj {
+			if k != "id" && k != "jsonrpc" {
+				out[k] = v
+			}
+		}
+		return out
+	}
+	if batch, ok := decoded.([]interface{}); ok {
+		for i := range batch {
+			batch[i] = strip(batch[i])
+		}
+	} else {
+		decoded = strip(decoded)
+	}
+	// encoding/json sorts the keys of maps.
+	canonical, err := stdjson.Marshal(decoded)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(canonical), ids, nil
+}
+
+// replaceResponseIDs replaces the IDs of a response (or batch of responses).
+func replaceResponseIDs(body []byte, replace func(id interface{}) interface{}) ([]byte, error) {
+	decoded, err := decodeWithNumbers(body)
+	if err != nil {
+		return nil, err
+	}
+	set := func(v interface{}) {
+		if obj, ok := v.(map[string]interface{}); ok {
+			if id, ok := obj["id"]; ok {
+				obj["id"] = replace(id)
+			}
+		}
+	}
+	if batch, ok := decoded.([]interface{}); ok {
+		for _, v := range batch {
+			set(v)
+		}
+	} else {
+		set(decoded)
+	}
+	return stdjson.Marshal(decoded)
+}
+
+func decodeWithNumbers(data []byte) (interface{}, error) {
+	decoder := stdjson.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func sameID(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
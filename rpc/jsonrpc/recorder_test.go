@@ -0,0 +1,91 @@
+package jsonrpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&calls, 1)
+		var raw stdjson.RawMessage
+		require.NoError(t, stdjson.NewDecoder(r.Body).Decode(&raw))
+		respond := func(req map[string]interface{}) map[string]interface{} {
+			return map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req["id"],
+				"result":  map[string]interface{}{"method": req["method"], "params": req["params"], "call": n},
+			}
+		}
+		var batch []map[string]interface{}
+		if stdjson.Unmarshal(raw, &batch) == nil {
+			// Respond in reverse order, to check the IDs mapping.
+			var out []map[string]interface{}
+			for i := len(batch) - 1; i >= 0; i-- {
+				out = append(out, respond(batch[i]))
+			}
+			require.NoError(t, stdjson.NewEncoder(w).Encode(out))
+			return
+		}
+		var single map[string]interface{}
+		require.NoError(t, stdjson.Unmarshal(raw, &single))
+		require.NoError(t, stdjson.NewEncoder(w).Encode(respond(single)))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ctx := context.Background()
+
+	run := func(t *testing.T, client RPCClient, ids ...any) {
+		var first, second map[string]interface{}
+		require.NoError(t, client.CallForInto(ctx, &first, "getSlot", []interface{}{map[string]string{"commitment": "finalized"}}))
+		require.NoError(t, client.CallForInto(ctx, &second, "getSlot", []interface{}{map[string]string{"commitment": "finalized"}}))
+		require.EqualValues(t, 1, first["call"])
+		require.EqualValues(t, 2, second["call"])
+
+		requests := RPCRequests{
+			{Method: "getBalance", Params: []interface{}{"a"}, ID: ids[0], JSONRPC: jsonrpcVersion},
+			{Method: "getBalance", Params: []interface{}{"b"}, ID: ids[1], JSONRPC: jsonrpcVersion},
+		}
+		responses, err := client.CallBatchRaw(ctx, requests)
+		require.NoError(t, err)
+		require.Len(t, responses, 2)
+		for _, req := range requests {
+			resp := responses.GetByID(req.ID)
+			require.NotNil(t, resp)
+			var result map[string]interface{}
+			require.NoError(t, resp.GetObject(&result))
+			require.Equal(t, req.Params, result["params"])
+		}
+	}
+
+	recorder, err := NewRecorder(path, RecorderModeRecord, nil)
+	require.NoError(t, err)
+	run(t, NewClientWithOpts(server.URL, &RPCClientOpts{HTTPClient: recorder}), "first", "second")
+	require.NoError(t, recorder.Save())
+	require.Len(t, recorder.Cassette().Interactions, 3)
+
+	server.Close()
+
+	replayer, err := NewRecorder(path, RecorderModeReplay, nil)
+	require.NoError(t, err)
+	client := NewClientWithOpts(server.URL, &RPCClientOpts{HTTPClient: replayer})
+	// The IDs differ from the recorded ones.
+	run(t, client, "x", "y")
+
+	// The last recorded response is repeated.
+	var third map[string]interface{}
+	require.NoError(t, client.CallForInto(ctx, &third, "getSlot", []interface{}{map[string]string{"commitment": "finalized"}}))
+	require.EqualValues(t, 2, third["call"])
+
+	_, err = client.Call(ctx, "getSlot", []interface{}{map[string]string{"commitment": "processed"}})
+	require.ErrorIs(t, err, ErrInteractionNotFound)
+}
@@ -0,0 +1,304 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var ErrUnsupportedParsedAccount = errors.New("unsupported parsed account")
+
+// ParsedAccountData returns the "jsonParsed" account data,
+// with the info left as a stdjson.RawMessage.
+func (dt *DataBytesOrJSON) ParsedAccountData() (*ParsedAccountData, error) {
+	if dt == nil || dt.rawDataEncoding != solana.EncodingJSONParsed {
+		return nil, fmt.Errorf("data is not in JSONParsed encoding")
+	}
+	var raw struct {
+		Program string `json:"program"`
+		Parsed  struct {
+			Type string             `json:"type"`
+			Info stdjson.RawMessage `json:"info"`
+		} `json:"parsed"`
+		Space uint64 `json:"space"`
+	}
+	if err := json.Unmarshal(dt.asJSON, &raw); err != nil {
+		return nil, err
+	}
+	return &ParsedAccountData{
+		Program: raw.Program,
+		Parsed: ParsedAccountInfo{
+			Type: raw.Parsed.Type,
+			Info: raw.Parsed.Info,
+		},
+		Space: raw.Space,
+	}, nil
+}
+
+// ParsedAccount decodes the "jsonParsed" account data into the typed value
+// matching its program and type:
+//
+//   - spl-token, spl-token-2022: *ParsedTokenAccount, *ParsedMint, *ParsedMultisig
+//   - nonce: *ParsedNonceAccount
+//   - stake: *ParsedStakeAccount
+//   - vote: *ParsedVoteAccount
+//   - address-lookup-table: *ParsedLookupTableAccount
+//   - sysvar: *ParsedSysvarClock, *ParsedSysvarRent, *ParsedSysvarEpochSchedule,
+//     *ParsedSysvarFees, ParsedSysvarRecentBlockhashes, ParsedSysvarSlotHashes,
+//     *ParsedSysvarSlotHistory, ParsedSysvarStakeHistory, *ParsedSysvarLastRestartSlot
+//
+// For other accounts, it returns an error wrapping ErrUnsupportedParsedAccount;
+// use ParsedAccountData to get the raw info.
+func (dt *DataBytesOrJSON) ParsedAccount() (interface{}, error) {
+	data, err := dt.ParsedAccountData()
+	if err != nil {
+		return nil, err
+	}
+	info := data.Parsed.Info.(stdjson.RawMessage)
+
+	var out interface{}
+	switch data.Program {
+	case "spl-token", "spl-token-2022":
+		switch data.Parsed.Type {
+		case "account":
+			out = new(ParsedTokenAccount)
+		case "mint":
+			out = new(ParsedMint)
+		case "multisig":
+			out = new(ParsedMultisig)
+		}
+	case "nonce":
+		out = &ParsedNonceAccount{State: data.Parsed.Type}
+	case "stake":
+		out = &ParsedStakeAccount{Type: data.Parsed.Type}
+	case "vote":
+		out = new(ParsedVoteAccount)
+	case "address-lookup-table":
+		if data.Parsed.Type == "lookupTable" {
+			out = new(ParsedLookupTableAccount)
+		}
+	case "sysvar":
+		switch data.Parsed.Type {
+		case "clock":
+			out = new(ParsedSysvarClock)
+		case "rent":
+			out = new(ParsedSysvarRent)
+		case "epochSchedule":
+			out = new(ParsedSysvarEpochSchedule)
+		case "fees":
+			out = new(ParsedSysvarFees)
+		case "recentBlockhashes":
+			out = new(ParsedSysvarRecentBlockhashes)
+		case "slotHashes":
+			out = new(ParsedSysvarSlotHashes)
+		case "slotHistory":
+			out = new(ParsedSysvarSlotHistory)
+		case "stakeHistory":
+			out = new(ParsedSysvarStakeHistory)
+		case "lastRestartSlot":
+			out = new(ParsedSysvarLastRestartSlot)
+		}
+	}
+	if out == nil {
+		return nil, fmt.Errorf("%w: program %q, type %q", ErrUnsupportedParsedAccount, data.Program, data.Parsed.Type)
+	}
+	if len(info) > 0 {
+		if err := json.Unmarshal(info, out); err != nil {
+			return nil, fmt.Errorf("unable to decode %s %s: %w", data.Program, data.Parsed.Type, err)
+		}
+	}
+
+	// Return the slices by value.
+	switch v := out.(type) {
+	case *ParsedSysvarRecentBlockhashes:
+		return *v, nil
+	case *ParsedSysvarSlotHashes:
+		return *v, nil
+	case *ParsedSysvarStakeHistory:
+		return *v, nil
+	}
+	return out, nil
+}
+
+type ParsedTokenAccount struct {
+	Mint  solana.PublicKey `json:"mint"`
+	Owner solana.PublicKey `json:"owner"`
+	// "uninitialized", "initialized" or "frozen".
+	State             string            `json:"state"`
+	IsNative          bool              `json:"isNative"`
+	TokenAmount       UiTokenAmount     `json:"tokenAmount"`
+	Delegate          *solana.PublicKey `json:"delegate,omitempty"`
+	DelegatedAmount   *UiTokenAmount    `json:"delegatedAmount,omitempty"`
+	CloseAuthority    *solana.PublicKey `json:"closeAuthority,omitempty"`
+	RentExemptReserve *UiTokenAmount    `json:"rentExemptReserve,omitempty"`
+	// Token-2022 extensions.
+	Extensions []stdjson.RawMessage `json:"extensions,omitempty"`
+}
+
+type ParsedMint struct {
+	MintAuthority   *solana.PublicKey `json:"mintAuthority"`
+	Supply          uint64            `json:"supply,string"`
+	Decimals        uint8             `json:"decimals"`
+	IsInitialized   bool              `json:"isInitialized"`
+	FreezeAuthority *solana.PublicKey `json:"freezeAuthority"`
+	// Token-2022 extensions.
+	Extensions []stdjson.RawMessage `json:"extensions,omitempty"`
+}
+
+type ParsedMultisig struct {
+	NumRequiredSigners uint8              `json:"numRequiredSigners"`
+	NumValidSigners    uint8              `json:"numValidSigners"`
+	IsInitialized      bool               `json:"isInitialized"`
+	Signers            []solana.PublicKey `json:"signers"`
+}
+
+type ParsedFeeCalculator struct {
+	LamportsPerSignature uint64 `json:"lamportsPerSignature,string"`
+}
+
+type ParsedNonceAccount struct {
+	// "uninitialized" or "initialized".
+	State         string              `json:"-"`
+	Authority     solana.PublicKey    `json:"authority"`
+	Blockhash     solana.Hash         `json:"blockhash"`
+	FeeCalculator ParsedFeeCalculator `json:"feeCalculator"`
+}
+
+type ParsedStakeAccount struct {
+	// "uninitialized", "initialized", "delegated" or "rewardsPool".
+	Type  string           `json:"-"`
+	Meta  *ParsedStakeMeta `json:"meta,omitempty"`
+	Stake *ParsedStake     `json:"stake,omitempty"`
+}
+
+type ParsedStakeMeta struct {
+	RentExemptReserve uint64 `json:"rentExemptReserve,string"`
+	Authorized        struct {
+		Staker     solana.PublicKey `json:"staker"`
+		Withdrawer solana.PublicKey `json:"withdrawer"`
+	} `json:"authorized"`
+	Lockup struct {
+		UnixTimestamp int64            `json:"unixTimestamp"`
+		Epoch         uint64           `json:"epoch"`
+		Custodian     solana.PublicKey `json:"custodian"`
+	} `json:"lockup"`
+}
+
+type ParsedStake struct {
+	Delegation struct {
+		Voter              solana.PublicKey `json:"voter"`
+		Stake              uint64           `json:"stake,string"`
+		ActivationEpoch    uint64           `json:"activationEpoch,string"`
+		DeactivationEpoch  uint64           `json:"deactivationEpoch,string"`
+		WarmupCooldownRate float64          `json:"warmupCooldownRate"`
+	} `json:"delegation"`
+	CreditsObserved uint64 `json:"creditsObserved"`
+}
+
+type ParsedVoteAccount struct {
+	NodePubkey           solana.PublicKey `json:"nodePubkey"`
+	AuthorizedWithdrawer solana.PublicKey `json:"authorizedWithdrawer"`
+	Commission           uint8            `json:"commission"`
+	Votes                []struct {
+		Slot              uint64 `json:"slot"`
+		ConfirmationCount uint32 `json:"confirmationCount"`
+	} `json:"votes"`
+	RootSlot         *uint64 `json:"rootSlot"`
+	AuthorizedVoters []struct {
+		Epoch           uint64           `json:"epoch"`
+		AuthorizedVoter solana.PublicKey `json:"authorizedVoter"`
+	} `json:"authorizedVoters"`
+	PriorVoters []struct {
+		AuthorizedPubkey            solana.PublicKey `json:"authorizedPubkey"`
+		EpochOfLastAuthorizedSwitch uint64           `json:"epochOfLastAuthorizedSwitch"`
+		TargetEpoch                 uint64           `json:"targetEpoch"`
+	} `json:"priorVoters"`
+	EpochCredits []struct {
+		Epoch           uint64 `json:"epoch"`
+		Credits         uint64 `json:"credits,string"`
+		PreviousCredits uint64 `json:"previousCredits,string"`
+	} `json:"epochCredits"`
+	LastTimestamp struct {
+		Slot      uint64 `json:"slot"`
+		Timestamp int64  `json:"timestamp"`
+	} `json:"lastTimestamp"`
+}
+
+type ParsedLookupTableAccount struct {
+	DeactivationSlot           uint64             `json:"deactivationSlot,string"`
+	LastExtendedSlot           uint64             `json:"lastExtendedSlot,string"`
+	LastExtendedSlotStartIndex uint8              `json:"lastExtendedSlotStartIndex"`
+	Authority                  *solana.PublicKey  `json:"authority,omitempty"`
+	Addresses                  []solana.PublicKey `json:"addresses"`
+}
+
+type ParsedSysvarClock struct {
+	Slot                uint64 `json:"slot"`
+	Epoch               uint64 `json:"epoch"`
+	EpochStartTimestamp int64  `json:"epochStartTimestamp"`
+	LeaderScheduleEpoch uint64 `json:"leaderScheduleEpoch"`
+	UnixTimestamp       int64  `json:"unixTimestamp"`
+}
+
+type ParsedSysvarRent struct {
+	LamportsPerByteYear uint64  `json:"lamportsPerByteYear,string"`
+	ExemptionThreshold  float64 `json:"exemptionThreshold"`
+	BurnPercent         uint8   `json:"burnPercent"`
+}
+
+type ParsedSysvarEpochSchedule struct {
+	SlotsPerEpoch            uint64 `json:"slotsPerEpoch"`
+	LeaderScheduleSlotOffset uint64 `json:"leaderScheduleSlotOffset"`
+	Warmup                   bool   `json:"warmup"`
+	FirstNormalEpoch         uint64 `json:"firstNormalEpoch"`
+	FirstNormalSlot          uint64 `json:"firstNormalSlot"`
+}
+
+type ParsedSysvarFees struct {
+	FeeCalculator ParsedFeeCalculator `json:"feeCalculator"`
+}
+
+type ParsedSysvarRecentBlockhashes []struct {
+	Blockhash     solana.Hash         `json:"blockhash"`
+	FeeCalculator ParsedFeeCalculator `json:"feeCalculator"`
+}
+
+type ParsedSysvarSlotHashes []struct {
+	Slot uint64      `json:"slot"`
+	Hash solana.Hash `json:"hash"`
+}
+
+type ParsedSysvarSlotHistory struct {
+	NextSlot uint64 `json:"nextSlot"`
+	// The bits of the history, as a string of 0s and 1s.
+	Bits string `json:"bits"`
+}
+
+type ParsedSysvarStakeHistory []struct {
+	Epoch        uint64 `json:"epoch"`
+	StakeHistory struct {
+		Effective    uint64 `json:"effective"`
+		Activating   uint64 `json:"activating"`
+		Deactivating uint64 `json:"deactivating"`
+	} `json:"stakeHistory"`
+}
+
+type ParsedSysvarLastRestartSlot struct {
+	LastRestartSlot uint64 `json:"lastRestartSlot"`
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func parsedAccountFromJSON(t *testing.T, data string) (interface{}, error) {
+	var account Account
+	require.NoError(t, json.Unmarshal([]byte(`{"lamports":1,"owner":"11111111111111111111111111111111","executable":false,"rentEpoch":0,"data":`+data+`}`), &account))
+	return account.Data.ParsedAccount()
+}
+
+func TestParsedAccount(t *testing.T) {
+	t.Run("token account", func(t *testing.T) {
+		got, err := parsedAccountFromJSON(t, `{
+			"program": "spl-token",
+			"parsed": {
+				"type": "account",
+				"info": {
+					"isNative": false,
+					"mint": "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+					"owner": "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+					"state": "initialized",
+					"tokenAmount": {"amount": "1500000", "decimals": 6, "uiAmount": 1.5, "uiAmountString": "1.5"}
+				}
+			},
+			"space": 165
+		}`)
+		require.NoError(t, err)
+		account := got.(*ParsedTokenAccount)
+		require.Equal(t, solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"), account.Mint)
+		require.Equal(t, "initialized", account.State)
+		require.Equal(t, "1500000", account.TokenAmount.Amount)
+		require.Equal(t, uint8(6), account.TokenAmount.Decimals)
+		require.Nil(t, account.Delegate)
+	})
+
+	t.Run("mint", func(t *testing.T) {
+		got, err := parsedAccountFromJSON(t, `{
+			"program": "spl-token",
+			"parsed": {
+				"type": "mint",
+				"info": {
+					"decimals": 6,
+					"freezeAuthority": null,
+					"isInitialized": true,
+					"mintAuthority": "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+					"supply": "5034943397637516"
+				}
+			},
+			"space": 82
+		}`)
+		require.NoError(t, err)
+		mint := got.(*ParsedMint)
+		require.Equal(t, uint64(5034943397637516), mint.Supply)
+		require.Nil(t, mint.FreezeAuthority)
+		require.Equal(t, solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"), *mint.MintAuthority)
+	})
+
+	t.Run("nonce", func(t *testing.T) {
+		got, err := parsedAccountFromJSON(t, `{
+			"program": "nonce",
+			"parsed": {
+				"type": "initialized",
+				"info": {
+					"authority": "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+					"blockhash": "8tHYbxU6ohRbBx8tVGhCr3ZMdmN8jBHyNc3N2G6Q8DzM",
+					"feeCalculator": {"lamportsPerSignature": "5000"}
+				}
+			},
+			"space": 80
+		}`)
+		require.NoError(t, err)
+		nonce := got.(*ParsedNonceAccount)
+		require.Equal(t, "initialized", nonce.State)
+		require.Equal(t, uint64(5000), nonce.FeeCalculator.LamportsPerSignature)
+		require.Equal(t, solana.MustHashFromBase58("8tHYbxU6ohRbBx8tVGhCr3ZMdmN8jBHyNc3N2G6Q8DzM"), nonce.Blockhash)
+	})
+
+	t.Run("stake", func(t *testing.T) {
+		got, err := parsedAccountFromJSON(t, `{
+			"program": "stake",
+			"parsed": {
+				"type": "delegated",
+				"info": {
+					"meta": {
+						"authorized": {
+							"staker": "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+							"withdrawer": "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"
+						},
+						"lockup": {"custodian": "11111111111111111111111111111111", "epoch": 0, "unixTimestamp": 0},
+						"rentExemptReserve": "2282880"
+					},
+					"stake": {
+						"creditsObserved": 169965713,
+						"delegation": {
+							"activationEpoch": "386",
+							"deactivationEpoch": "18446744073709551615",
+							"stake": "9997717120",
+							"voter": "Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi",
+							"warmupCooldownRate": 0.25
+						}
+					}
+				}
+			},
+			"space": 200
+		}`)
+		require.NoError(t, err)
+		stake := got.(*ParsedStakeAccount)
+		require.Equal(t, "delegated", stake.Type)
+		require.Equal(t, uint64(2282880), stake.Meta.RentExemptReserve)
+		require.Equal(t, uint64(9997717120), stake.Stake.Delegation.Stake)
+		require.Equal(t, uint64(18446744073709551615), stake.Stake.Delegation.DeactivationEpoch)
+	})
+
+	t.Run("sysvar", func(t *testing.T) {
+		got, err := parsedAccountFromJSON(t, `{
+			"program": "sysvar",
+			"parsed": {
+				"type": "clock",
+				"info": {"epoch": 400, "epochStartTimestamp": 1, "leaderScheduleEpoch": 401, "slot": 172800000, "unixTimestamp": 2}
+			},
+			"space": 40
+		}`)
+		require.NoError(t, err)
+		require.Equal(t, uint64(172800000), got.(*ParsedSysvarClock).Slot)
+
+		got, err = parsedAccountFromJSON(t, `{
+			"program": "sysvar",
+			"parsed": {
+				"type": "slotHashes",
+				"info": [{"hash": "8tHYbxU6ohRbBx8tVGhCr3ZMdmN8jBHyNc3N2G6Q8DzM", "slot": 10}]
+			},
+			"space": 20488
+		}`)
+		require.NoError(t, err)
+		slotHashes := got.(ParsedSysvarSlotHashes)
+		require.Len(t, slotHashes, 1)
+		require.Equal(t, uint64(10), slotHashes[0].Slot)
+	})
+
+	t.Run("lookup table", func(t *testing.T) {
+		got, err := parsedAccountFromJSON(t, `{
+			"program": "address-lookup-table",
+			"parsed": {
+				"type": "lookupTable",
+				"info": {
+					"addresses": ["7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"],
+					"authority": "Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi",
+					"deactivationSlot": "18446744073709551615",
+					"lastExtendedSlot": "201",
+					"lastExtendedSlotStartIndex": 0
+				}
+			},
+			"space": 88
+		}`)
+		require.NoError(t, err)
+		table := got.(*ParsedLookupTableAccount)
+		require.Equal(t, uint64(201), table.LastExtendedSlot)
+		require.Len(t, table.Addresses, 1)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := parsedAccountFromJSON(t, `{"program": "spl-governance", "parsed": {"type": "realm", "info": {}}, "space": 1}`)
+		require.ErrorIs(t, err, ErrUnsupportedParsedAccount)
+
+		_, err = parsedAccountFromJSON(t, `["AQID", "base64"]`)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,433 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// InstructionParser returns the "parsed" object of an instruction,
+// from the value returned by its decoder (see solana.RegisterInstructionDecoder).
+type InstructionParser func(decoded interface{}, accounts []*solana.AccountMeta) (*InstructionInfo, error)
+
+// AccountParser returns the type and the info of the "parsed" object of an account;
+// opts is never nil.
+type AccountParser func(data []byte, opts *ParseAccountOpts) (accountType string, info interface{}, err error)
+
+// ErrNoAccountParser is returned when parsing the data of an account
+// whose owner has no registered account parser.
+var ErrNoAccountParser = errors.New("no account parser registered")
+
+type ParseAccountOpts struct {
+	// The decimals of the mint of a token account, which the RPC reads from the mint account;
+	// they are required to render the token amounts.
+	TokenDecimals *uint8
+}
+
+// ParsedAccountData is the "jsonParsed" representation of the data of an account.
+type ParsedAccountData struct {
+	Program string            `json:"program"`
+	Parsed  ParsedAccountInfo `json:"parsed"`
+	Space   uint64            `json:"space"`
+}
+
+type ParsedAccountInfo struct {
+	Type string      `json:"type"`
+	Info interface{} `json:"info"`
+}
+
+type programParsers struct {
+	name        string
+	instruction InstructionParser
+	account     AccountParser
+}
+
+var (
+	parsersMu sync.RWMutex
+	// The names used by the RPC for the built-in programs.
+	parsers = map[solana.PublicKey]*programParsers{
+		solana.SystemProgramID:                    {name: "system"},
+		solana.StakeProgramID:                     {name: "stake"},
+		solana.VoteProgramID:                      {name: "vote"},
+		solana.BPFLoaderProgramID:                 {name: "bpf-loader"},
+		solana.BPFLoaderUpgradeableProgramID:      {name: "bpf-upgradeable-loader"},
+		solana.AddressLookupTableProgramID:        {name: "address-lookup-table"},
+		solana.TokenProgramID:                     {name: "spl-token"},
+		solana.Token2022ProgramID:                 {name: "spl-token-2022"},
+		solana.SPLAssociatedTokenAccountProgramID: {name: "spl-associated-token-account"},
+		solana.MemoProgramID:                      {name: "spl-memo"},
+		solana.ComputeBudget:                      {name: "compute-budget"},
+	}
+)
+
+func getProgramParsers(programID solana.PublicKey) programParsers {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	if p, ok := parsers[programID]; ok {
+		return *p
+	}
+	return programParsers{}
+}
+
+func setProgramParsers(programID solana.PublicKey, programName string, set func(p *programParsers)) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	p, ok := parsers[programID]
+	if !ok {
+		p = &programParsers{}
+		parsers[programID] = p
+	}
+	if programName != "" {
+		p.name = programName
+	}
+	set(p)
+}
+
+// RegisterInstructionParser sets the name of a program in the "jsonParsed" representation
+// of its instructions, and optionally a parser (by default, the instructions are rendered
+// from the value returned by the decoder of the program, see DefaultInstructionParser).
+func RegisterInstructionParser(programID solana.PublicKey, programName string, parser InstructionParser) {
+	setProgramParsers(programID, programName, func(p *programParsers) {
+		p.instruction = parser
+	})
+}
+
+// RegisterAccountParser registers the parser of the accounts owned by a program.
+func RegisterAccountParser(owner solana.PublicKey, programName string, parser AccountParser) {
+	setProgramParsers(owner, programName, func(p *programParsers) {
+		p.account = parser
+	})
+}
+
+// ParseInstruction returns the "jsonParsed" representation of an instruction,
+// as returned by the RPC: with the "parsed" object if the instruction can be decoded
+// with a registered decoder, or else with the raw accounts and data.
+func ParseInstruction(programID solana.PublicKey, accounts []*solana.AccountMeta, data []byte) *ParsedInstruction {
+	decoded, err := solana.DecodeInstruction(programID, accounts, data)
+	if err != nil {
+		decoded = nil
+	}
+	return parseInstruction(programID, accounts, data, decoded)
+}
+
+// Parsed returns the "jsonParsed" representation of the instruction (see ParseInstruction).
+func (in *DecodedInstruction) Parsed() *ParsedInstruction {
+	var decoded interface{}
+	if _, generic := in.Decoded.(*solana.GenericInstruction); !generic && in.DecodeErr == nil {
+		decoded = in.Decoded
+	}
+	out := parseInstruction(in.ProgramID, in.Accounts, in.Data, decoded)
+	out.StackHeight = int64(in.StackHeight)
+	return out
+}
+
+func parseInstruction(programID solana.PublicKey, accounts []*solana.AccountMeta, data []byte, decoded interface{}) *ParsedInstruction {
+	p := getProgramParsers(programID)
+	out := &ParsedInstruction{
+		Program:   p.name,
+		ProgramId: programID,
+	}
+	if decoded != nil {
+		parser := p.instruction
+		if parser == nil {
+			parser = DefaultInstructionParser
+		}
+		info, err := parser(decoded, accounts)
+		if err == nil && info != nil {
+			out.Parsed = &InstructionInfoEnvelope{asInstructionInfo: info}
+			return out
+		}
+	}
+	out.Program = ""
+	out.Data = data
+	out.Accounts = make([]solana.PublicKey, len(accounts))
+	for i, account := range accounts {
+		out.Accounts[i] = account.PublicKey
+	}
+	return out
+}
+
+// ParseTransaction returns the "jsonParsed" representation of a transaction,
+// and of its meta (which is optional), as returned by the RPC.
+func ParseTransaction(tx *solana.Transaction, meta *TransactionMeta) (*ParsedTransaction, *ParsedTransactionMeta, error) {
+	instructions, err := DecodeTransactionInstructions(tx, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	accounts, err := transactionAccountMetas(tx, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := &ParsedTransaction{
+		Signatures: tx.Signatures,
+		Message: ParsedMessage{
+			AccountKeys:     make([]ParsedMessageAccount, len(accounts)),
+			Instructions:    make([]*ParsedInstruction, len(instructions)),
+			RecentBlockHash: tx.Message.RecentBlockhash.String(),
+		},
+	}
+	for i, account := range accounts {
+		out.Message.AccountKeys[i] = ParsedMessageAccount{
+			PublicKey: account.PublicKey,
+			Signer:    account.IsSigner,
+			Writable:  account.IsWritable,
+		}
+	}
+	for i, in := range instructions {
+		out.Message.Instructions[i] = in.Parsed()
+		// The RPC renders a null stack height for the top-level instructions.
+		out.Message.Instructions[i].StackHeight = 0
+	}
+	if meta == nil {
+		return out, nil, nil
+	}
+
+	outMeta := &ParsedTransactionMeta{
+		Err:               meta.Err,
+		Fee:               meta.Fee,
+		PreBalances:       meta.PreBalances,
+		PostBalances:      meta.PostBalances,
+		PreTokenBalances:  meta.PreTokenBalances,
+		PostTokenBalances: meta.PostTokenBalances,
+		LogMessages:       meta.LogMessages,
+	}
+	for _, in := range instructions {
+		if len(in.Inner) == 0 {
+			continue
+		}
+		inner := ParsedInnerInstruction{Index: uint64(in.Index)}
+		for _, child := range in.Inner {
+			child.Walk(func(child *DecodedInstruction) bool {
+				inner.Instructions = append(inner.Instructions, child.Parsed())
+				return true
+			})
+		}
+		outMeta.InnerInstructions = append(outMeta.InnerInstructions, inner)
+	}
+	return out, outMeta, nil
+}
+
+// ParseAccountData returns the "jsonParsed" representation of the data of an account,
+// with the parser registered for its owner.
+func ParseAccountData(owner solana.PublicKey, data []byte) (*ParsedAccountData, error) {
+	return ParseAccountDataWithOpts(owner, data, nil)
+}
+
+// ParseAccountDataWithOpts is like ParseAccountData, with the data that some accounts
+// need to be rendered (e.g. the decimals of the mint of a token account).
+func ParseAccountDataWithOpts(owner solana.PublicKey, data []byte, opts *ParseAccountOpts) (*ParsedAccountData, error) {
+	p := getProgramParsers(owner)
+	if p.account == nil {
+		return nil, fmt.Errorf("%w for program %s", ErrNoAccountParser, owner)
+	}
+	if opts == nil {
+		opts = &ParseAccountOpts{}
+	}
+	accountType, info, err := p.account(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	name := p.name
+	if name == "" {
+		name = owner.String()
+	}
+	return &ParsedAccountData{
+		Program: name,
+		Parsed: ParsedAccountInfo{
+			Type: accountType,
+			Info: info,
+		},
+		Space: uint64(len(data)),
+	}, nil
+}
+
+// DefaultInstructionParser renders the value returned by an instruction decoder:
+// the type is the name of the instruction type (e.g. "transferChecked"),
+// and the info contains its parameters, and the accounts returned
+// by its GetXxxAccount methods (e.g. "source" for GetSourceAccount).
+// The names are derived from the Go types, and can differ from the names used by the RPC.
+func DefaultInstructionParser(decoded interface{}, accounts []*solana.AccountMeta) (*InstructionInfo, error) {
+	impl := reflect.ValueOf(decoded)
+	if variant := findBaseVariant(impl); variant != nil {
+		impl = reflect.ValueOf(variant.Impl)
+	}
+	for impl.Kind() == reflect.Ptr || impl.Kind() == reflect.Interface {
+		if impl.IsNil() {
+			return nil, fmt.Errorf("decoded instruction is nil")
+		}
+		impl = impl.Elem()
+	}
+	if impl.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot parse decoded instruction of type %s", impl.Type())
+	}
+
+	if !impl.CanAddr() {
+		addressable := reflect.New(impl.Type()).Elem()
+		addressable.Set(impl)
+		impl = addressable
+	}
+	info := StructInfo(impl.Interface())
+	addAccountGetters(info, impl.Addr())
+	return &InstructionInfo{
+		InstructionType: lowerFirst(impl.Type().Name()),
+		Info:            info,
+	}, nil
+}
+
+func findBaseVariant(v reflect.Value) *bin.BaseVariant {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	variantType := reflect.TypeOf(bin.BaseVariant{})
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous && field.Type == variantType {
+			variant := v.Field(i).Interface().(bin.BaseVariant)
+			return &variant
+		}
+	}
+	return nil
+}
+
+var (
+	accountMetaType      = reflect.TypeOf(&solana.AccountMeta{})
+	accountMetaSliceType = reflect.TypeOf(solana.AccountMetaSlice{})
+)
+
+// StructInfo returns the exported fields of a struct as a map,
+// with lower camel case keys (e.g. "closeAuthority" for CloseAuthority);
+// nil pointers are omitted, and account meta slices are rendered as lists of keys
+// (except the Accounts and AccountMetaSlice fields, which hold all the accounts of an instruction).
+func StructInfo(v interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return out
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return out
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := rv.Field(i)
+		if field.Type == accountMetaSliceType {
+			if field.Name == "Accounts" || field.Name == "AccountMetaSlice" || value.Len() == 0 {
+				continue
+			}
+			keys := make([]string, 0, value.Len())
+			for _, meta := range value.Interface().(solana.AccountMetaSlice) {
+				if meta != nil {
+					keys = append(keys, meta.PublicKey.String())
+				}
+			}
+			out[lowerFirst(field.Name)] = keys
+			continue
+		}
+		if field.Type.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		out[lowerFirst(field.Name)] = value.Interface()
+	}
+	return out
+}
+
+// addAccountGetters adds the accounts returned by the GetXxxAccount methods to the info.
+func addAccountGetters(info map[string]interface{}, v reflect.Value) {
+	for i := 0; i < v.NumMethod(); i++ {
+		method := v.Type().Method(i)
+		name := method.Name
+		if !strings.HasPrefix(name, "Get") || !strings.HasSuffix(name, "Account") {
+			continue
+		}
+		fn := v.Method(i)
+		if fn.Type().NumIn() != 0 || fn.Type().NumOut() != 1 || fn.Type().Out(0) != accountMetaType {
+			continue
+		}
+		meta := callAccountGetter(fn)
+		if meta == nil {
+			continue
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(name, "Get"), "Account")
+		if key == "" {
+			key = "account"
+		}
+		info[lowerFirst(key)] = meta.PublicKey.String()
+	}
+}
+
+// callAccountGetter calls an account getter, which panics
+// if the instruction has fewer accounts than expected.
+func callAccountGetter(fn reflect.Value) (meta *solana.AccountMeta) {
+	defer func() {
+		if r := recover(); r != nil {
+			meta = nil
+		}
+	}()
+	return fn.Call(nil)[0].Interface().(*solana.AccountMeta)
+}
+
+// NewUiTokenAmount returns a token amount as rendered by the RPC.
+func NewUiTokenAmount(amount uint64, decimals uint8) *UiTokenAmount {
+	uiAmountString := strconv.FormatUint(amount, 10)
+	if decimals > 0 {
+		if pad := int(decimals) + 1 - len(uiAmountString); pad > 0 {
+			uiAmountString = strings.Repeat("0", pad) + uiAmountString
+		}
+		point := len(uiAmountString) - int(decimals)
+		uiAmountString = uiAmountString[:point] + "." + uiAmountString[point:]
+		uiAmountString = strings.TrimRight(strings.TrimRight(uiAmountString, "0"), ".")
+	}
+	uiAmount, _ := strconv.ParseFloat(uiAmountString, 64)
+	return &UiTokenAmount{
+		Amount:         strconv.FormatUint(amount, 10),
+		Decimals:       decimals,
+		UiAmount:       &uiAmount,
+		UiAmountString: uiAmountString,
+	}
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
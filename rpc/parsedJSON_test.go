@@ -0,0 +1,215 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransaction(t *testing.T) {
+	payer := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	recipient := solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	otherProgram := solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+	transferProgram := registerTestTransferProgram()
+	RegisterInstructionParser(transferProgram, "test-transfer", func(decoded interface{}, accounts []*solana.AccountMeta) (*InstructionInfo, error) {
+		if len(accounts) < 2 {
+			return nil, fmt.Errorf("not enough accounts")
+		}
+		return &InstructionInfo{
+			InstructionType: "transfer",
+			Info: map[string]interface{}{
+				"source":      accounts[0].PublicKey.String(),
+				"destination": accounts[1].PublicKey.String(),
+				"lamports":    decoded.(*testTransfer).Lamports,
+			},
+		}, nil
+	})
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			newTestTransferInstruction(transferProgram, 1000, payer, recipient),
+			solana.NewInstruction(otherProgram, solana.AccountMetaSlice{solana.Meta(recipient)}, []byte{1, 2, 3}),
+			solana.NewInstruction(otherProgram, nil, nil),
+		},
+		solana.Hash{},
+		solana.TransactionPayer(payer),
+	)
+	require.NoError(t, err)
+	transferIndex, err := tx.Message.GetAccountIndex(transferProgram)
+	require.NoError(t, err)
+	meta := &TransactionMeta{
+		Fee: 5000,
+		InnerInstructions: []InnerInstruction{
+			{
+				Index: 1,
+				Instructions: []CompiledInstruction{
+					{
+						ProgramIDIndex: transferIndex,
+						Accounts:       []uint16{0, 1},
+						Data:           mustInstructionData(t, newTestTransferInstruction(transferProgram, 7, payer, recipient)),
+						StackHeight:    2,
+					},
+				},
+			},
+		},
+	}
+
+	parsedTx, parsedMeta, err := ParseTransaction(tx, meta)
+	require.NoError(t, err)
+
+	got, err := json.Marshal(parsedTx.Message.Instructions)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		fmt.Sprintf(`[
+			{
+				"program": "test-transfer",
+				"programId": %q,
+				"parsed": {
+					"type": "transfer",
+					"info": {"source": %q, "destination": %q, "lamports": 1000}
+				},
+				"stackHeight": null
+			},
+			{
+				"programId": %q,
+				"accounts": [%q],
+				"data": "Ldp",
+				"stackHeight": null
+			},
+			{
+				"programId": %q,
+				"accounts": [],
+				"data": "",
+				"stackHeight": null
+			}
+		]`, transferProgram, payer, recipient, otherProgram, recipient, otherProgram),
+		string(got),
+	)
+	require.Len(t, parsedTx.Message.AccountKeys, 4)
+	require.Equal(t, ParsedMessageAccount{PublicKey: payer, Signer: true, Writable: true}, parsedTx.Message.AccountKeys[0])
+
+	require.Len(t, parsedMeta.InnerInstructions, 1)
+	require.Equal(t, uint64(1), parsedMeta.InnerInstructions[0].Index)
+	got, err = json.Marshal(parsedMeta.InnerInstructions[0].Instructions)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		fmt.Sprintf(`[
+			{
+				"program": "test-transfer",
+				"programId": %q,
+				"parsed": {
+					"type": "transfer",
+					"info": {"source": %q, "destination": %q, "lamports": 7}
+				},
+				"stackHeight": 2
+			}
+		]`, transferProgram, payer, recipient),
+		string(got),
+	)
+}
+
+func TestParseInstructionWithoutParser(t *testing.T) {
+	// The instruction is decoded, but there is no parser registered for its program:
+	// it is rendered with the default parser.
+	transferProgram := registerTestTransferProgram()
+	recipient := solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	got := ParseInstruction(transferProgram, solana.AccountMetaSlice{solana.Meta(recipient)}, []byte{1, 0, 0, 0, 0, 0, 0, 0})
+	require.NotNil(t, got.Parsed)
+	require.Equal(t, "testTransfer", got.Parsed.asInstructionInfo.InstructionType)
+	require.Equal(t, uint64(1), got.Parsed.asInstructionInfo.Info["lamports"])
+	require.Nil(t, got.Accounts)
+	require.Nil(t, got.Data)
+
+	// The instruction cannot be decoded: it is rendered with the raw accounts and data.
+	got = ParseInstruction(transferProgram, solana.AccountMetaSlice{solana.Meta(recipient)}, []byte{1, 2, 3})
+	require.Nil(t, got.Parsed)
+	require.Equal(t, []solana.PublicKey{recipient}, got.Accounts)
+	require.Equal(t, solana.Base58{1, 2, 3}, got.Data)
+}
+
+func TestParseComputeBudgetInstruction(t *testing.T) {
+	instruction := computebudget.NewSetComputeUnitLimitInstruction(200000).Build()
+	got := ParseInstruction(instruction.ProgramID(), instruction.Accounts(), mustInstructionData(t, instruction))
+
+	out, err := json.Marshal(got)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{
+			"program": "compute-budget",
+			"programId": "ComputeBudget111111111111111111111111111111",
+			"parsed": {
+				"type": "setComputeUnitLimit",
+				"info": {"units": 200000}
+			},
+			"stackHeight": null
+		}`,
+		string(out),
+	)
+}
+
+func TestParseAccountDataWithoutParser(t *testing.T) {
+	_, err := ParseAccountData(solana.NewWallet().PublicKey(), []byte{1, 2, 3})
+	require.ErrorIs(t, err, ErrNoAccountParser)
+}
+
+func TestNewUiTokenAmount(t *testing.T) {
+	for _, tt := range []struct {
+		amount         uint64
+		decimals       uint8
+		uiAmount       float64
+		uiAmountString string
+	}{
+		{0, 6, 0, "0"},
+		{1000000, 6, 1, "1"},
+		{1500000, 6, 1.5, "1.5"},
+		{5, 9, 0.000000005, "0.000000005"},
+		{123, 0, 123, "123"},
+		{18446744073709551615, 2, 184467440737095516.15, "184467440737095516.15"},
+	} {
+		got := NewUiTokenAmount(tt.amount, tt.decimals)
+		require.Equal(t, fmt.Sprint(tt.amount), got.Amount)
+		require.Equal(t, tt.decimals, got.Decimals)
+		require.Equal(t, tt.uiAmount, *got.UiAmount)
+		require.Equal(t, tt.uiAmountString, got.UiAmountString)
+	}
+}
+
+func TestRegisterInstructionParser(t *testing.T) {
+	programID := solana.NewWallet().PublicKey()
+	solana.RegisterInstructionDecoder(programID, func(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+		return string(data), nil
+	})
+	RegisterInstructionParser(programID, "echo", func(decoded interface{}, accounts []*solana.AccountMeta) (*InstructionInfo, error) {
+		return &InstructionInfo{
+			InstructionType: "echo",
+			Info:            map[string]interface{}{"message": decoded},
+		}, nil
+	})
+
+	got := ParseInstruction(programID, nil, []byte("hello"))
+	require.Equal(t, "echo", got.Program)
+	require.Equal(t, "hello", got.Parsed.asInstructionInfo.Info["message"])
+}
+
+func mustInstructionData(t *testing.T, instruction solana.Instruction) []byte {
+	data, err := instruction.Data()
+	require.NoError(t, err)
+	return data
+}
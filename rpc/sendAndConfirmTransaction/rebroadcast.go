@@ -0,0 +1,264 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sendandconfirmtransaction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+const (
+	DefaultRebroadcastInterval = 2 * time.Second
+	DefaultPollInterval        = 2 * time.Second
+)
+
+type RebroadcastOpts struct {
+	// Commitment the transaction must reach to be considered confirmed.
+	// Defaults to rpc.CommitmentFinalized.
+	Commitment rpc.CommitmentType
+
+	// Preflight options for the first send;
+	// rebroadcasts always skip the preflight checks.
+	SkipPreflight       bool
+	PreflightCommitment rpc.CommitmentType
+
+	// The last block height at which the blockhash of the transaction is valid,
+	// as returned by GetLatestBlockhash along with the blockhash.
+	// If zero, the LastValidBlockHeight of the latest blockhash is used,
+	// which is an upper bound of the real one.
+	LastValidBlockHeight uint64
+
+	// How often the transaction is re-sent. Defaults to DefaultRebroadcastInterval.
+	RebroadcastInterval time.Duration
+
+	// How often the block height (and the signature status, if there is no
+	// websocket subscription) is polled. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+type ConfirmationOutcome int
+
+const (
+	// The transaction reached the target commitment and succeeded.
+	OutcomeConfirmed ConfirmationOutcome = iota
+	// The transaction reached the target commitment, but failed while executing.
+	OutcomeFailed
+	// The blockhash of the transaction expired before the transaction was processed.
+	OutcomeExpired
+)
+
+func (o ConfirmationOutcome) String() string {
+	switch o {
+	case OutcomeConfirmed:
+		return "confirmed"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+type ConfirmationResult struct {
+	Signature solana.Signature
+	Outcome   ConfirmationOutcome
+
+	// The slot the transaction was processed in; zero if expired.
+	Slot uint64
+
+	// The execution error of the transaction; only set if Outcome is OutcomeFailed.
+	Err *rpc.TransactionError
+}
+
+// SendAndConfirmTransactionWithRebroadcast sends a signed transaction, and keeps re-sending
+// the same bytes (with maxRetries set to zero) until the transaction reaches the target
+// commitment, or its blockhash expires.
+//
+// The confirmation is received via a signatureSubscribe on the websocket client;
+// if wsClient is nil (or the subscription fails), the signature status is polled instead.
+//
+// An error is returned only if the transaction could not be sent at all
+// (e.g. it failed the preflight checks), or if the context is done.
+func SendAndConfirmTransactionWithRebroadcast(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	wsClient *ws.Client, // optional
+	transaction *solana.Transaction,
+	opts RebroadcastOpts,
+) (*ConfirmationResult, error) {
+	if len(transaction.Signatures) == 0 {
+		return nil, errors.New("transaction is not signed")
+	}
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentFinalized
+	}
+	if opts.RebroadcastInterval <= 0 {
+		opts.RebroadcastInterval = DefaultRebroadcastInterval
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	sig := transaction.Signatures[0]
+
+	rawTx, err := transaction.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	lastValidBlockHeight := opts.LastValidBlockHeight
+	if lastValidBlockHeight == 0 {
+		latest, err := rpcClient.GetLatestBlockhash(ctx, opts.Commitment)
+		if err != nil {
+			return nil, err
+		}
+		lastValidBlockHeight = latest.Value.LastValidBlockHeight
+	}
+
+	maxRetries := uint(0)
+	_, err = rpcClient.SendRawTransactionWithOpts(ctx, rawTx, rpc.TransactionOpts{
+		SkipPreflight:       opts.SkipPreflight,
+		PreflightCommitment: opts.PreflightCommitment,
+		MaxRetries:          &maxRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	notifications := subscribeSignature(ctx, wsClient, sig, opts.Commitment)
+
+	rebroadcastTicker := time.NewTicker(opts.RebroadcastInterval)
+	defer rebroadcastTicker.Stop()
+	pollTicker := time.NewTicker(opts.PollInterval)
+	defer pollTicker.Stop()
+
+	// Set while the transaction is processed, but not yet at the target commitment:
+	// it is not rebroadcast, and its status is polled even with a subscription.
+	// It is reset if the status disappears (e.g. its fork was dropped).
+	processed := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case notification := <-notifications:
+			if notification.err != nil {
+				// Fall back to polling.
+				notifications = nil
+				continue
+			}
+			return newConfirmationResult(sig, notification.res.Context.Slot, notification.res.Value.Err), nil
+		case <-rebroadcastTicker.C:
+			if processed {
+				continue
+			}
+			// Best effort: the next poll will tell whether the transaction landed.
+			rpcClient.SendRawTransactionWithOpts(ctx, rawTx, rpc.TransactionOpts{
+				SkipPreflight: true,
+				MaxRetries:    &maxRetries,
+			})
+		case <-pollTicker.C:
+			if notifications == nil || processed {
+				status, err := getSignatureStatus(ctx, rpcClient, sig)
+				if err == nil {
+					if status != nil && status.ReachedCommitment(opts.Commitment) {
+						return newConfirmationResult(sig, status.Slot, status.Err), nil
+					}
+					processed = status != nil
+				}
+			}
+			blockHeight, err := rpcClient.GetBlockHeight(ctx, opts.Commitment)
+			if err != nil || blockHeight <= lastValidBlockHeight {
+				continue
+			}
+			// The blockhash has expired: the transaction can't land anymore,
+			// unless it is already processed.
+			status, err := getSignatureStatus(ctx, rpcClient, sig)
+			if err != nil {
+				continue
+			}
+			if status == nil {
+				return &ConfirmationResult{
+					Signature: sig,
+					Outcome:   OutcomeExpired,
+				}, nil
+			}
+			if status.ReachedCommitment(opts.Commitment) {
+				return newConfirmationResult(sig, status.Slot, status.Err), nil
+			}
+			processed = true
+		}
+	}
+}
+
+func newConfirmationResult(sig solana.Signature, slot uint64, txErr interface{}) *ConfirmationResult {
+	out := &ConfirmationResult{
+		Signature: sig,
+		Outcome:   OutcomeConfirmed,
+		Slot:      slot,
+	}
+	if txErr != nil {
+		out.Outcome = OutcomeFailed
+		out.Err = rpc.NewTransactionError(txErr)
+	}
+	return out
+}
+
+func getSignatureStatus(ctx context.Context, rpcClient *rpc.Client, sig solana.Signature) (*rpc.SignatureStatusesResult, error) {
+	out, err := rpcClient.GetSignatureStatuses(ctx, false, sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Value) == 0 {
+		return nil, nil
+	}
+	return out.Value[0], nil
+}
+
+type signatureNotification struct {
+	res *ws.SignatureResult
+	err error
+}
+
+// subscribeSignature returns a channel that receives the signature notification;
+// it returns nil if wsClient is nil or the subscription could not be created.
+func subscribeSignature(
+	ctx context.Context,
+	wsClient *ws.Client,
+	sig solana.Signature,
+	commitment rpc.CommitmentType,
+) <-chan signatureNotification {
+	if wsClient == nil {
+		return nil
+	}
+	sub, err := wsClient.SignatureSubscribe(sig, commitment)
+	if err != nil {
+		return nil
+	}
+	ch := make(chan signatureNotification, 1)
+	go func() {
+		defer sub.Unsubscribe()
+		res, err := sub.Recv(ctx)
+		ch <- signatureNotification{res: res, err: err}
+	}()
+	return ch
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sendandconfirmtransaction
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNode struct {
+	mu    sync.Mutex
+	calls map[string]int
+	// returns the result for the method, given its params and how many times it was called.
+	handle func(method string, params []interface{}, call int) interface{}
+}
+
+func newMockNode(t *testing.T, handle func(method string, params []interface{}, call int) interface{}) (*mockNode, *rpc.Client) {
+	node := &mockNode{
+		calls:  make(map[string]int),
+		handle: handle,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			// Background pollers can still be calling while the server shuts down.
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		node.mu.Lock()
+		call := node.calls[body.Method]
+		node.calls[body.Method]++
+		node.mu.Unlock()
+
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      body.ID,
+			"result":  node.handle(body.Method, body.Params, call),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return node, rpc.New(server.URL)
+}
+
+func (node *mockNode) Calls(method string) int {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.calls[method]
+}
+
+func newSignedTestTransaction(t *testing.T) *solana.Transaction {
+	payer := solana.NewWallet()
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			solana.NewInstruction(
+				solana.MemoProgramID,
+				solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).SIGNER()},
+				[]byte("hello"),
+			),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	require.NoError(t, err)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		return &payer.PrivateKey
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestSendAndConfirmTransactionWithRebroadcast(t *testing.T) {
+	opts := RebroadcastOpts{
+		Commitment:           rpc.CommitmentConfirmed,
+		LastValidBlockHeight: 100,
+		RebroadcastInterval:  10 * time.Millisecond,
+		PollInterval:         25 * time.Millisecond,
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		tx := newSignedTestTransaction(t)
+		node, client := newMockNode(t, func(method string, _ []interface{}, call int) interface{} {
+			switch method {
+			case "sendTransaction":
+				return tx.Signatures[0].String()
+			case "getBlockHeight":
+				return 50
+			case "getSignatureStatuses":
+				if call < 2 {
+					return map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": []interface{}{nil}}
+				}
+				return map[string]interface{}{
+					"context": map[string]interface{}{"slot": 1},
+					"value": []interface{}{
+						map[string]interface{}{"slot": 42, "confirmations": 1, "err": nil, "confirmationStatus": "confirmed"},
+					},
+				}
+			}
+			return nil
+		})
+
+		res, err := SendAndConfirmTransactionWithRebroadcast(context.Background(), client, nil, tx, opts)
+		require.NoError(t, err)
+		require.Equal(t, OutcomeConfirmed, res.Outcome)
+		require.Equal(t, tx.Signatures[0], res.Signature)
+		require.Equal(t, uint64(42), res.Slot)
+		require.Nil(t, res.Err)
+		require.Greater(t, node.Calls("sendTransaction"), 1)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		tx := newSignedTestTransaction(t)
+		_, client := newMockNode(t, func(method string, _ []interface{}, call int) interface{} {
+			switch method {
+			case "sendTransaction":
+				return tx.Signatures[0].String()
+			case "getBlockHeight":
+				return 50
+			case "getSignatureStatuses":
+				return map[string]interface{}{
+					"context": map[string]interface{}{"slot": 1},
+					"value": []interface{}{
+						map[string]interface{}{"slot": 42, "confirmations": 1, "err": map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": 1}}}, "confirmationStatus": "confirmed"},
+					},
+				}
+			}
+			return nil
+		})
+
+		res, err := SendAndConfirmTransactionWithRebroadcast(context.Background(), client, nil, tx, opts)
+		require.NoError(t, err)
+		require.Equal(t, OutcomeFailed, res.Outcome)
+		code, ok := res.Err.CustomErrorCode()
+		require.True(t, ok)
+		require.Equal(t, uint32(1), code)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		tx := newSignedTestTransaction(t)
+		_, client := newMockNode(t, func(method string, _ []interface{}, call int) interface{} {
+			switch method {
+			case "sendTransaction":
+				return tx.Signatures[0].String()
+			case "getBlockHeight":
+				return 98 + call
+			case "getSignatureStatuses":
+				return map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": []interface{}{nil}}
+			}
+			return nil
+		})
+
+		res, err := SendAndConfirmTransactionWithRebroadcast(context.Background(), client, nil, tx, opts)
+		require.NoError(t, err)
+		require.Equal(t, OutcomeExpired, res.Outcome)
+		require.Nil(t, res.Err)
+	})
+
+	t.Run("dropped fork", func(t *testing.T) {
+		tx := newSignedTestTransaction(t)
+		var mu sync.Mutex
+		var dropped bool
+		var rebroadcastsAfterDrop int
+		_, client := newMockNode(t, func(method string, _ []interface{}, call int) interface{} {
+			mu.Lock()
+			defer mu.Unlock()
+			switch method {
+			case "sendTransaction":
+				if dropped {
+					rebroadcastsAfterDrop++
+				}
+				return tx.Signatures[0].String()
+			case "getBlockHeight":
+				if call < 10 {
+					return 50
+				}
+				return 101
+			case "getSignatureStatuses":
+				// Processed on a fork that is then dropped.
+				if call < 3 {
+					return map[string]interface{}{
+						"context": map[string]interface{}{"slot": 1},
+						"value": []interface{}{
+							map[string]interface{}{"slot": 42, "confirmations": 0, "err": nil, "confirmationStatus": "processed"},
+						},
+					}
+				}
+				dropped = true
+				return map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": []interface{}{nil}}
+			}
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		res, err := SendAndConfirmTransactionWithRebroadcast(ctx, client, nil, tx, opts)
+		require.NoError(t, err)
+		require.Equal(t, OutcomeExpired, res.Outcome)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Greater(t, rebroadcastsAfterDrop, 0)
+	})
+}
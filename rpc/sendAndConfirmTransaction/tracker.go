@@ -0,0 +1,258 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sendandconfirmtransaction
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// MaxSignatureStatusesBatchSize is the maximum number of signatures
+// accepted by a single getSignatureStatuses call.
+const MaxSignatureStatusesBatchSize = 256
+
+type SignatureTrackerOpts struct {
+	// Commitment the transactions must reach to be considered confirmed.
+	// Defaults to rpc.CommitmentFinalized.
+	Commitment rpc.CommitmentType
+
+	// How often the statuses are polled. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+
+	// Number of signatures per getSignatureStatuses call.
+	// Defaults to (and is capped at) MaxSignatureStatusesBatchSize.
+	BatchSize int
+
+	// If true, the node searches its ledger cache for signatures
+	// not found in the recent status cache.
+	SearchTransactionHistory bool
+
+	// Called when an RPC call fails; the call is retried at the next poll.
+	OnError func(err error)
+}
+
+// SignatureTracker tracks the confirmation of many signatures at once,
+// by polling their statuses in batches.
+// It is safe for concurrent use.
+type SignatureTracker struct {
+	rpcClient *rpc.Client
+	opts      SignatureTrackerOpts
+
+	mu      sync.Mutex
+	tracked map[solana.Signature]*trackedSignature
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type trackedSignature struct {
+	lastValidBlockHeight uint64
+	callbacks            []func(*ConfirmationResult)
+}
+
+// NewSignatureTracker creates a new tracker, and starts polling in the background;
+// call Close to stop it.
+func NewSignatureTracker(rpcClient *rpc.Client, opts SignatureTrackerOpts) *SignatureTracker {
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentFinalized
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.BatchSize <= 0 || opts.BatchSize > MaxSignatureStatusesBatchSize {
+		opts.BatchSize = MaxSignatureStatusesBatchSize
+	}
+	tracker := &SignatureTracker{
+		rpcClient: rpcClient,
+		opts:      opts,
+		tracked:   make(map[solana.Signature]*trackedSignature),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go tracker.run()
+	return tracker
+}
+
+// Track starts tracking the provided signature; the returned channel receives
+// exactly one result when the signature reaches the target commitment, fails,
+// or expires (i.e. the block height exceeds lastValidBlockHeight before the
+// transaction is processed).
+// If lastValidBlockHeight is zero, the signature never expires.
+func (tracker *SignatureTracker) Track(sig solana.Signature, lastValidBlockHeight uint64) <-chan *ConfirmationResult {
+	ch := make(chan *ConfirmationResult, 1)
+	tracker.TrackWithCallback(sig, lastValidBlockHeight, func(res *ConfirmationResult) {
+		ch <- res
+	})
+	return ch
+}
+
+// TrackWithCallback is like Track, but calls the provided callback
+// (from the tracker's goroutine) instead of sending to a channel.
+func (tracker *SignatureTracker) TrackWithCallback(
+	sig solana.Signature,
+	lastValidBlockHeight uint64,
+	callback func(*ConfirmationResult),
+) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	entry, ok := tracker.tracked[sig]
+	if !ok {
+		entry = &trackedSignature{
+			lastValidBlockHeight: lastValidBlockHeight,
+		}
+		tracker.tracked[sig] = entry
+	}
+	if lastValidBlockHeight > entry.lastValidBlockHeight {
+		entry.lastValidBlockHeight = lastValidBlockHeight
+	}
+	entry.callbacks = append(entry.callbacks, callback)
+}
+
+// Untrack stops tracking the provided signature without notifying its subscribers.
+func (tracker *SignatureTracker) Untrack(sig solana.Signature) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	delete(tracker.tracked, sig)
+}
+
+// Len returns the number of signatures being tracked.
+func (tracker *SignatureTracker) Len() int {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return len(tracker.tracked)
+}
+
+// Close stops the tracker; pending signatures are not notified.
+func (tracker *SignatureTracker) Close() {
+	select {
+	case <-tracker.stop:
+	default:
+		close(tracker.stop)
+	}
+	<-tracker.done
+}
+
+func (tracker *SignatureTracker) run() {
+	defer close(tracker.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-tracker.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(tracker.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tracker.stop:
+			return
+		case <-ticker.C:
+			tracker.poll(ctx)
+		}
+	}
+}
+
+func (tracker *SignatureTracker) poll(ctx context.Context) {
+	tracker.mu.Lock()
+	sigs := make([]solana.Signature, 0, len(tracker.tracked))
+	canExpire := false
+	for sig, entry := range tracker.tracked {
+		sigs = append(sigs, sig)
+		canExpire = canExpire || entry.lastValidBlockHeight > 0
+	}
+	tracker.mu.Unlock()
+	if len(sigs) == 0 {
+		return
+	}
+
+	// The block height is fetched BEFORE the statuses, so that a signature
+	// that is not found is guaranteed to have expired.
+	var blockHeight uint64
+	if canExpire {
+		var err error
+		blockHeight, err = tracker.rpcClient.GetBlockHeight(ctx, tracker.opts.Commitment)
+		if err != nil {
+			tracker.onError(err)
+			blockHeight = 0
+		}
+	}
+
+	for start := 0; start < len(sigs); start += tracker.opts.BatchSize {
+		end := start + tracker.opts.BatchSize
+		if end > len(sigs) {
+			end = len(sigs)
+		}
+		batch := sigs[start:end]
+		out, err := tracker.rpcClient.GetSignatureStatuses(ctx, tracker.opts.SearchTransactionHistory, batch...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			tracker.onError(err)
+			continue
+		}
+		for i, sig := range batch {
+			var status *rpc.SignatureStatusesResult
+			if i < len(out.Value) {
+				status = out.Value[i]
+			}
+			tracker.handleStatus(sig, status, blockHeight)
+		}
+	}
+}
+
+func (tracker *SignatureTracker) handleStatus(sig solana.Signature, status *rpc.SignatureStatusesResult, blockHeight uint64) {
+	var res *ConfirmationResult
+	tracker.mu.Lock()
+	entry, ok := tracker.tracked[sig]
+	if !ok {
+		tracker.mu.Unlock()
+		return
+	}
+	switch {
+	case status != nil && status.ReachedCommitment(tracker.opts.Commitment):
+		res = newConfirmationResult(sig, status.Slot, status.Err)
+	case status == nil && entry.lastValidBlockHeight > 0 && blockHeight > entry.lastValidBlockHeight:
+		res = &ConfirmationResult{
+			Signature: sig,
+			Outcome:   OutcomeExpired,
+		}
+	default:
+		tracker.mu.Unlock()
+		return
+	}
+	delete(tracker.tracked, sig)
+	tracker.mu.Unlock()
+
+	for _, callback := range entry.callbacks {
+		callback(res)
+	}
+}
+
+func (tracker *SignatureTracker) onError(err error) {
+	if tracker.opts.OnError != nil {
+		tracker.opts.OnError(err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sendandconfirmtransaction
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureTracker(t *testing.T) {
+	// Signatures starting with 1 are confirmed, with 2 have failed;
+	// all the others are never found.
+	var maxBatch int
+	var mu sync.Mutex
+	node, client := newMockNode(t, func(method string, params []interface{}, call int) interface{} {
+		switch method {
+		case "getBlockHeight":
+			return 1000
+		case "getSignatureStatuses":
+			sigs := params[0].([]interface{})
+			mu.Lock()
+			if len(sigs) > maxBatch {
+				maxBatch = len(sigs)
+			}
+			mu.Unlock()
+			value := make([]interface{}, len(sigs))
+			for i, s := range sigs {
+				sig := solana.MustSignatureFromBase58(s.(string))
+				switch sig[0] {
+				case 1:
+					value[i] = map[string]interface{}{"slot": 7, "confirmations": nil, "err": nil, "confirmationStatus": "finalized"}
+				case 2:
+					value[i] = map[string]interface{}{"slot": 8, "confirmations": nil, "err": "AccountInUse", "confirmationStatus": "finalized"}
+				}
+			}
+			return map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": value}
+		}
+		return nil
+	})
+
+	tracker := NewSignatureTracker(client, SignatureTrackerOpts{
+		Commitment:   rpc.CommitmentFinalized,
+		PollInterval: 10 * time.Millisecond,
+	})
+	defer tracker.Close()
+
+	newSig := func(kind byte, i int) solana.Signature {
+		return solana.Signature{kind, byte(i), byte(i >> 8)}
+	}
+
+	var wg sync.WaitGroup
+	results := make(map[solana.Signature]*ConfirmationResult)
+	var resultsMu sync.Mutex
+	for i := 0; i < 300; i++ {
+		for kind := byte(1); kind <= 4; kind++ {
+			sig := newSig(kind, i)
+			lastValidBlockHeight := uint64(0)
+			if kind == 3 {
+				lastValidBlockHeight = 999
+			}
+			if kind == 4 {
+				tracker.Track(sig, lastValidBlockHeight)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res := <-tracker.Track(sig, lastValidBlockHeight)
+				resultsMu.Lock()
+				results[sig] = res
+				resultsMu.Unlock()
+			}()
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return tracker.Len() == 300
+	}, 5*time.Second, 10*time.Millisecond)
+	wg.Wait()
+	require.LessOrEqual(t, maxBatch, MaxSignatureStatusesBatchSize)
+	require.Greater(t, node.Calls("getSignatureStatuses"), 1)
+
+	for i := 0; i < 300; i++ {
+		require.Equal(t, OutcomeConfirmed, results[newSig(1, i)].Outcome)
+		require.Equal(t, uint64(7), results[newSig(1, i)].Slot)
+		require.Equal(t, OutcomeFailed, results[newSig(2, i)].Outcome)
+		require.Equal(t, "AccountInUse", results[newSig(2, i)].Err.Type())
+		require.Equal(t, OutcomeExpired, results[newSig(3, i)].Outcome)
+		require.Nil(t, results[newSig(4, i)])
+	}
+}
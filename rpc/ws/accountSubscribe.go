@@ -33,11 +33,13 @@ type AccountResult struct {
 func (cl *Client) AccountSubscribe(
 	account solana.PublicKey,
 	commitment rpc.CommitmentType,
+	subOpts ...SubscriptionOption,
 ) (*AccountSubscription, error) {
 	return cl.AccountSubscribeWithOpts(
 		account,
 		commitment,
 		"",
+		subOpts...,
 	)
 }
 
@@ -47,6 +49,7 @@ func (cl *Client) AccountSubscribeWithOpts(
 	account solana.PublicKey,
 	commitment rpc.CommitmentType,
 	encoding solana.EncodingType,
+	subOpts ...SubscriptionOption,
 ) (*AccountSubscription, error) {
 
 	params := []interface{}{account.String()}
@@ -70,6 +73,7 @@ func (cl *Client) AccountSubscribeWithOpts(
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -117,3 +121,7 @@ func (sw *AccountSubscription) Response() <-chan *AccountResult {
 func (sw *AccountSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *AccountSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -84,6 +84,7 @@ type BlockSubscribeOpts struct {
 func (cl *Client) BlockSubscribe(
 	filter BlockSubscribeFilter,
 	opts *BlockSubscribeOpts,
+	subOpts ...SubscriptionOption,
 ) (*BlockSubscription, error) {
 	var params []interface{}
 	if filter != nil {
@@ -136,6 +137,7 @@ func (cl *Client) BlockSubscribe(
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -183,3 +185,7 @@ func (sw *BlockSubscription) Response() <-chan *BlockResult {
 func (sw *BlockSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *BlockSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
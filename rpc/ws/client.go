@@ -43,10 +43,12 @@ type Client struct {
 	connCtx                 context.Context
 	connCtxCancel           context.CancelFunc
 	lock                    sync.RWMutex
-	subscriptionByRequestID map[uint64]*Subscription
-	subscriptionByWSSubID   map[uint64]*Subscription
+	subscriptionByRequestID map[uint64]*serverSubscription
+	subscriptionByWSSubID   map[uint64]*serverSubscription
+	subscriptionByKey       map[string]*serverSubscription
 	reconnectOnErr          bool
 	shortID                 bool
+	subscriptionConfig      subscriptionConfig
 }
 
 const (
@@ -70,8 +72,9 @@ func Connect(ctx context.Context, rpcEndpoint string) (c *Client, err error) {
 func ConnectWithOptions(ctx context.Context, rpcEndpoint string, opt *Options) (c *Client, err error) {
 	c = &Client{
 		rpcURL:                  rpcEndpoint,
-		subscriptionByRequestID: map[uint64]*Subscription{},
-		subscriptionByWSSubID:   map[uint64]*Subscription{},
+		subscriptionByRequestID: map[uint64]*serverSubscription{},
+		subscriptionByWSSubID:   map[uint64]*serverSubscription{},
+		subscriptionByKey:       map[string]*serverSubscription{},
 	}
 
 	dialer := &websocket.Dialer{
@@ -84,6 +87,13 @@ func ConnectWithOptions(ctx context.Context, rpcEndpoint string, opt *Options) (
 		c.shortID = opt.ShortID
 	}
 
+	if opt != nil {
+		c.subscriptionConfig = subscriptionConfig{
+			bufferSize:     opt.SubscriptionBufferSize,
+			overflowPolicy: opt.OverflowPolicy,
+		}
+	}
+
 	if opt != nil && opt.HandshakeTimeout > 0 {
 		dialer.HandshakeTimeout = opt.HandshakeTimeout
 	}
@@ -182,6 +192,10 @@ func (c *Client) handleMessage(message []byte) {
 
 	requestID, ok := getUint64WithOk(message, "id")
 	if ok {
+		if errData, dataType, _, err := jsonparser.Get(message, "error"); err == nil && dataType == jsonparser.Object {
+			c.handleSubscriptionError(requestID, errData)
+			return
+		}
 		subID, _ := getUint64WithOk(message, "result")
 		c.handleNewSubscriptionMessage(requestID, subID)
 		return
@@ -191,6 +205,36 @@ func (c *Client) handleMessage(message []byte) {
 	c.handleSubscriptionMessage(subID, message)
 }
 
+// serverSubscription is a subscription on the node,
+// shared by the local subscriptions with identical requests.
+type serverSubscription struct {
+	key               string
+	req               *request
+	subID             uint64
+	unsubscribeMethod string
+	subscribers       []*Subscription
+}
+
+func (s *serverSubscription) remove(sub *Subscription) bool {
+	for i, other := range s.subscribers {
+		if other == sub {
+			s.subscribers = append(s.subscribers[:i], s.subscribers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// subscriptionKey identifies identical subscription requests.
+func subscriptionKey(method string, params []interface{}, conf map[string]interface{}) (string, error) {
+	// The keys of maps are sorted.
+	data, err := json.Marshal([]interface{}{method, params, conf})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 func (c *Client) handleNewSubscriptionMessage(requestID, subID uint64) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -202,7 +246,7 @@ func (c *Client) handleNewSubscriptionMessage(requestID, subID uint64) {
 		)
 	}
 
-	callBack, found := c.subscriptionByRequestID[requestID]
+	shared, found := c.subscriptionByRequestID[requestID]
 	if !found {
 		zlog.Error("cannot find websocket message handler for a new stream.... this should not happen",
 			zap.Uint64("request_id", requestID),
@@ -210,8 +254,11 @@ func (c *Client) handleNewSubscriptionMessage(requestID, subID uint64) {
 		)
 		return
 	}
-	callBack.subID = subID
-	c.subscriptionByWSSubID[subID] = callBack
+	shared.subID = subID
+	for _, sub := range shared.subscribers {
+		sub.subID = subID
+	}
+	c.subscriptionByWSSubID[subID] = shared
 
 	zlog.Debug("registered ws subscription",
 		zap.Uint64("subscription_id", subID),
@@ -221,6 +268,37 @@ func (c *Client) handleNewSubscriptionMessage(requestID, subID uint64) {
 	return
 }
 
+// handleSubscriptionError closes the local subscriptions of a request rejected by the node
+// (e.g. with "Method not found"); they receive the *json2.Error of the response.
+func (c *Client) handleSubscriptionError(requestID uint64, data []byte) {
+	rpcErr := &json2.Error{}
+	if err := json.Unmarshal(data, rpcErr); err != nil {
+		rpcErr = &json2.Error{
+			Code:    json2.E_SERVER,
+			Message: string(data),
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	shared, found := c.subscriptionByRequestID[requestID]
+	if !found {
+		// E.g. an unsubscribe request.
+		zlog.Warn("received an error for an unknown request",
+			zap.Uint64("request_id", requestID),
+			zap.Error(rpcErr),
+		)
+		return
+	}
+	for _, sub := range shared.subscribers {
+		sub.sendErr(rpcErr)
+	}
+	shared.subscribers = nil
+	delete(c.subscriptionByRequestID, requestID)
+	delete(c.subscriptionByKey, shared.key)
+}
+
 func (c *Client) handleSubscriptionMessage(subID uint64, message []byte) {
 	if traceEnabled {
 		zlog.Debug("received subscription message",
@@ -229,33 +307,43 @@ func (c *Client) handleSubscriptionMessage(subID uint64, message []byte) {
 	}
 
 	c.lock.RLock()
-	sub, found := c.subscriptionByWSSubID[subID]
+	shared, found := c.subscriptionByWSSubID[subID]
+	var subscribers []*Subscription
+	if found {
+		subscribers = append(subscribers, shared.subscribers...)
+	}
 	c.lock.RUnlock()
 	if !found {
 		zlog.Warn("unable to find subscription for ws message", zap.Uint64("subscription_id", subID))
 		return
 	}
 
-	// Decode the message using the subscription-provided decoderFunc.
-	result, err := sub.decoderFunc(message)
-	if err != nil {
-		fmt.Println("*****************************")
-		c.closeSubscription(sub.req.ID, fmt.Errorf("unable to decode client response: %w", err))
-		return
-	}
-
-	// this cannot be blocking or else
-	// we  will no read any other message
-	if len(sub.stream) >= cap(sub.stream) {
-		zlog.Warn("closing ws client subscription... not consuming fast en ought",
-			zap.Uint64("request_id", sub.req.ID),
-		)
-		c.closeSubscription(sub.req.ID, fmt.Errorf("reached channel max capacity %d", len(sub.stream)))
-		return
-	}
-
-	if !sub.closed {
-		sub.stream <- result
+	for _, sub := range subscribers {
+		// Each local subscription decodes the message with its own decoderFunc:
+		// the subscriptions sharing a request can expect different result types
+		// (e.g. BlockSubscribe and ParsedBlockSubscribe), and do not share the results.
+		result, err := sub.decoderFunc(message)
+		if err != nil {
+			c.closeSubscription(shared, sub, fmt.Errorf("unable to decode client response: %w", err))
+			continue
+		}
+		// Unless the subscription uses OverflowBlock, this cannot be blocking
+		// or else we will not read any other message.
+		if !sub.deliver(result) {
+			zlog.Warn("closing ws client subscription... not consuming fast enough",
+				zap.Uint64("request_id", shared.req.ID),
+			)
+			c.closeSubscription(shared, sub, fmt.Errorf("%w: reached channel max capacity %d", ErrSubscriptionOverflow, cap(sub.stream)))
+			continue
+		}
+		if dropped := sub.dropped.Load(); dropped > 0 && dropped&(dropped-1) == 0 {
+			// Log at each power of two.
+			zlog.Warn("dropping messages of ws client subscription... not consuming fast enough",
+				zap.Uint64("request_id", shared.req.ID),
+				zap.Uint64("dropped", dropped),
+				zap.Stringer("overflow_policy", sub.overflowPolicy),
+			)
+		}
 	}
 	return
 }
@@ -264,34 +352,45 @@ func (c *Client) closeAllSubscription(err error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	for _, sub := range c.subscriptionByRequestID {
-		sub.err <- err
+	for _, shared := range c.subscriptionByRequestID {
+		for _, sub := range shared.subscribers {
+			sub.sendErr(err)
+		}
 	}
 
-	c.subscriptionByRequestID = map[uint64]*Subscription{}
-	c.subscriptionByWSSubID = map[uint64]*Subscription{}
+	c.subscriptionByRequestID = map[uint64]*serverSubscription{}
+	c.subscriptionByWSSubID = map[uint64]*serverSubscription{}
+	c.subscriptionByKey = map[string]*serverSubscription{}
 }
 
-func (c *Client) closeSubscription(reqID uint64, err error) {
+// closeSubscription removes a local subscription,
+// and closes the server subscription when it was the last one.
+func (c *Client) closeSubscription(shared *serverSubscription, sub *Subscription, err error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	sub, found := c.subscriptionByRequestID[reqID]
-	if !found {
+	if !shared.remove(sub) {
+		return
+	}
+	sub.sendErr(err)
+	if len(shared.subscribers) > 0 {
+		return
+	}
+	if c.subscriptionByRequestID[shared.req.ID] != shared {
+		// Already removed, e.g. after a connection error.
 		return
 	}
 
-	sub.err <- err
-
-	err = c.unsubscribe(sub.subID, sub.unsubscribeMethod)
+	err = c.unsubscribe(shared.subID, shared.unsubscribeMethod)
 	if err != nil {
 		zlog.Warn("unable to send rpc unsubscribe call",
 			zap.Error(err),
 		)
 	}
 
-	delete(c.subscriptionByRequestID, sub.req.ID)
-	delete(c.subscriptionByWSSubID, sub.subID)
+	delete(c.subscriptionByRequestID, shared.req.ID)
+	delete(c.subscriptionByWSSubID, shared.subID)
+	delete(c.subscriptionByKey, shared.key)
 }
 
 func (c *Client) unsubscribe(subID uint64, method string) error {
@@ -309,32 +408,70 @@ func (c *Client) unsubscribe(subID uint64, method string) error {
 	return nil
 }
 
+// SubscriptionCount returns the number of subscriptions on the node,
+// and the number of local subscriptions sharing them.
+func (c *Client) SubscriptionCount() (server int, local int) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, shared := range c.subscriptionByRequestID {
+		server++
+		local += len(shared.subscribers)
+	}
+	return server, local
+}
+
+// subscribe creates a local subscription; identical requests (same method, params and conf)
+// share the same subscription on the node, which is closed when the last local one is.
+// The notifications are decoded for each local subscription, with its decoderFunc.
 func (c *Client) subscribe(
 	params []interface{},
 	conf map[string]interface{},
 	subscriptionMethod string,
 	unsubscribeMethod string,
 	decoderFunc decoderFunc,
+	subOpts ...SubscriptionOption,
 ) (*Subscription, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	subConf := c.subscriptionConfig
+	for _, opt := range subOpts {
+		opt(&subConf)
+	}
+	key, err := subscriptionKey(subscriptionMethod, params, conf)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe: unable to encode subsciption request: %w", err)
+	}
+	newLocalSubscription := func(shared *serverSubscription) *Subscription {
+		sub := newSubscription(shared.req, nil, unsubscribeMethod, decoderFunc, subConf)
+		sub.subID = shared.subID
+		sub.closeFunc = func(err error) {
+			c.closeSubscription(shared, sub, err)
+		}
+		shared.subscribers = append(shared.subscribers, sub)
+		return sub
+	}
+
+	if shared, ok := c.subscriptionByKey[key]; ok {
+		zlog.Debug("sharing existing subscription", zap.Uint64("request_id", shared.req.ID), zap.Int("subscribers", len(shared.subscribers)+1))
+		return newLocalSubscription(shared), nil
+	}
+
 	req := newRequest(params, subscriptionMethod, conf, c.shortID)
 	data, err := req.encode()
 	if err != nil {
 		return nil, fmt.Errorf("subscribe: unable to encode subsciption request: %w", err)
 	}
 
-	sub := newSubscription(
-		req,
-		func(err error) {
-			c.closeSubscription(req.ID, err)
-		},
-		unsubscribeMethod,
-		decoderFunc,
-	)
+	shared := &serverSubscription{
+		key:               key,
+		req:               req,
+		unsubscribeMethod: unsubscribeMethod,
+	}
+	sub := newLocalSubscription(shared)
 
-	c.subscriptionByRequestID[req.ID] = sub
+	c.subscriptionByRequestID[req.ID] = shared
+	c.subscriptionByKey[key] = shared
 	zlog.Info("added new subscription to websocket client", zap.Int("count", len(c.subscriptionByRequestID)))
 
 	zlog.Debug("writing data to conn", zap.String("data", string(data)))
@@ -342,6 +479,7 @@ func (c *Client) subscribe(
 	err = c.conn.WriteMessage(websocket.TextMessage, data)
 	if err != nil {
 		delete(c.subscriptionByRequestID, req.ID)
+		delete(c.subscriptionByKey, key)
 		return nil, fmt.Errorf("unable to write request: %w", err)
 	}
 
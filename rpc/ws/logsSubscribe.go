@@ -52,10 +52,12 @@ func (cl *Client) LogsSubscribe(
 	// Filter criteria for the logs to receive results by account type.
 	filter LogsSubscribeFilterType,
 	commitment rpc.CommitmentType, // (optional)
+	subOpts ...SubscriptionOption,
 ) (*LogSubscription, error) {
 	return cl.logsSubscribe(
 		filter,
 		commitment,
+		subOpts...,
 	)
 }
 
@@ -65,12 +67,14 @@ func (cl *Client) LogsSubscribeMentions(
 	mentions solana.PublicKey,
 	// (optional)
 	commitment rpc.CommitmentType,
+	subOpts ...SubscriptionOption,
 ) (*LogSubscription, error) {
 	return cl.logsSubscribe(
 		rpc.M{
 			"mentions": []string{mentions.String()},
 		},
 		commitment,
+		subOpts...,
 	)
 }
 
@@ -78,6 +82,7 @@ func (cl *Client) LogsSubscribeMentions(
 func (cl *Client) logsSubscribe(
 	filter interface{},
 	commitment rpc.CommitmentType,
+	subOpts ...SubscriptionOption,
 ) (*LogSubscription, error) {
 
 	params := []interface{}{filter}
@@ -96,6 +101,7 @@ func (cl *Client) logsSubscribe(
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -143,3 +149,7 @@ func (sw *LogSubscription) Response() <-chan *LogResult {
 func (sw *LogSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *LogSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
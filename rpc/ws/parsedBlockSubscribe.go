@@ -42,6 +42,7 @@ type ParsedBlockResult struct {
 func (cl *Client) ParsedBlockSubscribe(
 	filter BlockSubscribeFilter,
 	opts *BlockSubscribeOpts,
+	subOpts ...SubscriptionOption,
 ) (*ParsedBlockSubscription, error) {
 	var params []interface{}
 	if filter != nil {
@@ -81,6 +82,7 @@ func (cl *Client) ParsedBlockSubscribe(
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -125,3 +127,7 @@ func (sw *ParsedBlockSubscription) Response() <-chan *ParsedBlockResult {
 func (sw *ParsedBlockSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *ParsedBlockSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -33,12 +33,14 @@ type ProgramResult struct {
 func (cl *Client) ProgramSubscribe(
 	programID solana.PublicKey,
 	commitment rpc.CommitmentType,
+	subOpts ...SubscriptionOption,
 ) (*ProgramSubscription, error) {
 	return cl.ProgramSubscribeWithOpts(
 		programID,
 		commitment,
 		"",
 		nil,
+		subOpts...,
 	)
 }
 
@@ -49,6 +51,7 @@ func (cl *Client) ProgramSubscribeWithOpts(
 	commitment rpc.CommitmentType,
 	encoding solana.EncodingType,
 	filters []rpc.RPCFilter,
+	subOpts ...SubscriptionOption,
 ) (*ProgramSubscription, error) {
 
 	params := []interface{}{programID.String()}
@@ -75,6 +78,7 @@ func (cl *Client) ProgramSubscribeWithOpts(
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -122,3 +126,7 @@ func (sw *ProgramSubscription) Response() <-chan *ProgramResult {
 func (sw *ProgramSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *ProgramSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -20,7 +20,7 @@ type RootResult uint64
 
 // SignatureSubscribe subscribes to receive notification
 // anytime a new root is set by the validator.
-func (cl *Client) RootSubscribe() (*RootSubscription, error) {
+func (cl *Client) RootSubscribe(subOpts ...SubscriptionOption) (*RootSubscription, error) {
 	genSub, err := cl.subscribe(
 		nil,
 		nil,
@@ -31,6 +31,7 @@ func (cl *Client) RootSubscribe() (*RootSubscription, error) {
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -78,3 +79,7 @@ func (sw *RootSubscription) Response() <-chan *RootResult {
 func (sw *RootSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *RootSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
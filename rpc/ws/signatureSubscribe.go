@@ -38,6 +38,7 @@ type SignatureResult struct {
 func (cl *Client) SignatureSubscribe(
 	signature solana.Signature, // Transaction Signature.
 	commitment rpc.CommitmentType, // (optional)
+	subOpts ...SubscriptionOption,
 ) (*SignatureSubscription, error) {
 	params := []interface{}{signature.String()}
 	conf := map[string]interface{}{}
@@ -55,6 +56,7 @@ func (cl *Client) SignatureSubscribe(
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -115,3 +117,7 @@ func (sw *SignatureSubscription) RecvWithTimeout(timeout time.Duration) (*Signat
 func (sw *SignatureSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *SignatureSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
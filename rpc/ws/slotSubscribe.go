@@ -23,7 +23,7 @@ type SlotResult struct {
 }
 
 // SlotSubscribe subscribes to receive notification anytime a slot is processed by the validator.
-func (cl *Client) SlotSubscribe() (*SlotSubscription, error) {
+func (cl *Client) SlotSubscribe(subOpts ...SubscriptionOption) (*SlotSubscription, error) {
 	genSub, err := cl.subscribe(
 		nil,
 		nil,
@@ -34,6 +34,7 @@ func (cl *Client) SlotSubscribe() (*SlotSubscription, error) {
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -81,3 +82,7 @@ func (sw *SlotSubscription) Response() <-chan *SlotResult {
 func (sw *SlotSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *SlotSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -57,7 +57,7 @@ const (
 //
 // This subscription is unstable; the format of this subscription
 // may change in the future and it may not always be supported.
-func (cl *Client) SlotsUpdatesSubscribe() (*SlotsUpdatesSubscription, error) {
+func (cl *Client) SlotsUpdatesSubscribe(subOpts ...SubscriptionOption) (*SlotsUpdatesSubscription, error) {
 	genSub, err := cl.subscribe(
 		nil,
 		nil,
@@ -68,6 +68,7 @@ func (cl *Client) SlotsUpdatesSubscribe() (*SlotsUpdatesSubscription, error) {
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -115,3 +116,7 @@ func (sw *SlotsUpdatesSubscription) Response() <-chan *SlotsUpdatesResult {
 func (sw *SlotsUpdatesSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *SlotsUpdatesSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// WithDataEncoding sets the encoding used by SubscribeAccountAs and ProgramSubscribeAs
+// to decode the account data; defaults to bin.EncodingBin.
+func WithDataEncoding(encoding bin.Encoding) SubscriptionOption {
+	return func(conf *subscriptionConfig) {
+		conf.dataEncoding = encoding
+	}
+}
+
+// DecodedAccount is an account notification, with the data decoded into T.
+type DecodedAccount[T any] struct {
+	// The slot of the notification.
+	Slot   uint64
+	Pubkey solana.PublicKey
+	// The account, with the raw data.
+	Account *rpc.Account
+	// The decoded data; nil if the account has no data (e.g. it was closed).
+	Value *T
+}
+
+// DecodeError is returned when the data of an account could not be decoded;
+// the subscription is still active, and Recv can be called again.
+type DecodeError struct {
+	Slot   uint64
+	Pubkey solana.PublicKey
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unable to decode account %s at slot %d: %s", e.Pubkey, e.Slot, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func decodeAccountAs[T any](slot uint64, pubkey solana.PublicKey, account *rpc.Account, encoding bin.Encoding) (*DecodedAccount[T], error) {
+	out := &DecodedAccount[T]{
+		Slot:    slot,
+		Pubkey:  pubkey,
+		Account: account,
+	}
+	if account == nil || account.Data == nil {
+		return out, nil
+	}
+	data := account.Data.GetBinary()
+	if len(data) == 0 {
+		return out, nil
+	}
+	value := new(T)
+	if err := bin.NewDecoderWithEncoding(data, encoding).Decode(value); err != nil {
+		return out, &DecodeError{Slot: slot, Pubkey: pubkey, Err: err}
+	}
+	out.Value = value
+	return out, nil
+}
+
+func dataEncoding(subOpts []SubscriptionOption) bin.Encoding {
+	conf := subscriptionConfig{dataEncoding: bin.EncodingBin}
+	for _, opt := range subOpts {
+		opt(&conf)
+	}
+	return conf.dataEncoding
+}
+
+// TypedAccountSubscription is an account subscription
+// that decodes the account data into T.
+type TypedAccountSubscription[T any] struct {
+	sub      *AccountSubscription
+	account  solana.PublicKey
+	encoding bin.Encoding
+}
+
+// SubscribeAccountAs subscribes to an account, and decodes its data into T
+// (with bin.EncodingBin, unless set with WithDataEncoding):
+//
+//	sub, err := ws.SubscribeAccountAs[token.Mint](client, mint, rpc.CommitmentConfirmed)
+//	...
+//	got, err := sub.Recv(ctx)
+//	fmt.Println(got.Slot, got.Value.Supply)
+func SubscribeAccountAs[T any](
+	cl *Client,
+	account solana.PublicKey,
+	commitment rpc.CommitmentType,
+	subOpts ...SubscriptionOption,
+) (*TypedAccountSubscription[T], error) {
+	sub, err := cl.AccountSubscribeWithOpts(account, commitment, solana.EncodingBase64, subOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedAccountSubscription[T]{
+		sub:      sub,
+		account:  account,
+		encoding: dataEncoding(subOpts),
+	}, nil
+}
+
+// Recv returns the next notification. When the data can't be decoded,
+// it returns a *DecodeError along with the notification (without Value).
+func (sw *TypedAccountSubscription[T]) Recv(ctx context.Context) (*DecodedAccount[T], error) {
+	res, err := sw.sub.Recv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAccountAs[T](res.Context.Slot, sw.account, res.Value, sw.encoding)
+}
+
+func (sw *TypedAccountSubscription[T]) Err() <-chan error {
+	return sw.sub.Err()
+}
+
+func (sw *TypedAccountSubscription[T]) Unsubscribe() {
+	sw.sub.Unsubscribe()
+}
+
+func (sw *TypedAccountSubscription[T]) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
+
+// TypedProgramSubscription is a program subscription
+// that decodes the data of the accounts into T.
+type TypedProgramSubscription[T any] struct {
+	sub      *ProgramSubscription
+	encoding bin.Encoding
+}
+
+// ProgramSubscribeAs subscribes to the accounts owned by a program
+// (optionally filtered, e.g. by data size to only get one type of account),
+// and decodes their data into T (with bin.EncodingBin, unless set with WithDataEncoding).
+func ProgramSubscribeAs[T any](
+	cl *Client,
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType,
+	filters []rpc.RPCFilter,
+	subOpts ...SubscriptionOption,
+) (*TypedProgramSubscription[T], error) {
+	sub, err := cl.ProgramSubscribeWithOpts(programID, commitment, solana.EncodingBase64, filters, subOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedProgramSubscription[T]{
+		sub:      sub,
+		encoding: dataEncoding(subOpts),
+	}, nil
+}
+
+// Recv returns the next notification. When the data can't be decoded,
+// it returns a *DecodeError along with the notification (without Value).
+func (sw *TypedProgramSubscription[T]) Recv(ctx context.Context) (*DecodedAccount[T], error) {
+	res, err := sw.sub.Recv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAccountAs[T](res.Context.Slot, res.Value.Pubkey, res.Value.Account, sw.encoding)
+}
+
+func (sw *TypedProgramSubscription[T]) Err() <-chan error {
+	return sw.sub.Err()
+}
+
+func (sw *TypedProgramSubscription[T]) Unsubscribe() {
+	sw.sub.Unsubscribe()
+}
+
+func (sw *TypedProgramSubscription[T]) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
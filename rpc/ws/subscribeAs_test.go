@@ -0,0 +1,112 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+type testAccountState struct {
+	Version uint8
+	Counter uint64
+	Name    string
+}
+
+func encodeTestAccountState(t *testing.T, encoding bin.Encoding, state testAccountState) string {
+	data, err := bin.MarshalBin(&state)
+	if encoding == bin.EncodingBorsh {
+		data, err = bin.MarshalBorsh(&state)
+	}
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func TestSubscribeAccountAs(t *testing.T) {
+	url, requests, notify := newMockWSServer(t)
+	client, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer client.Close()
+
+	account := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	sub, err := SubscribeAccountAs[testAccountState](client, account, rpc.CommitmentConfirmed, WithDataEncoding(bin.EncodingBorsh))
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	req := <-requests
+	require.Equal(t, "accountSubscribe", req.Method)
+	require.Equal(t, map[string]interface{}{"commitment": "confirmed", "encoding": "base64"}, req.Params[1])
+
+	notification := func(slot uint64, data string) string {
+		return fmt.Sprintf(`{"context":{"slot":%d},"value":{"lamports":1,"owner":"11111111111111111111111111111111","executable":false,"rentEpoch":0,"data":[%q,"base64"]}}`, slot, data)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notify(1, notification(10, encodeTestAccountState(t, bin.EncodingBorsh, testAccountState{Version: 1, Counter: 7, Name: "hello"})))
+	got, err := sub.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), got.Slot)
+	require.Equal(t, account, got.Pubkey)
+	require.Equal(t, &testAccountState{Version: 1, Counter: 7, Name: "hello"}, got.Value)
+
+	// Invalid data is reported, and the subscription goes on.
+	notify(1, notification(11, base64.StdEncoding.EncodeToString([]byte{1, 2})))
+	got, err = sub.Recv(ctx)
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, uint64(11), decodeErr.Slot)
+	require.Equal(t, uint64(11), got.Slot)
+	require.Nil(t, got.Value)
+
+	// Closed account.
+	notify(1, notification(12, ""))
+	got, err = sub.Recv(ctx)
+	require.NoError(t, err)
+	require.Nil(t, got.Value)
+}
+
+func TestProgramSubscribeAs(t *testing.T) {
+	url, requests, notify := newMockWSServer(t)
+	client, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer client.Close()
+
+	programID := solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+	sub, err := ProgramSubscribeAs[testAccountState](client, programID, "", []rpc.RPCFilter{{DataSize: 17}})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	require.Equal(t, "programSubscribe", (<-requests).Method)
+
+	account := solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	notify(1, fmt.Sprintf(
+		`{"context":{"slot":20},"value":{"pubkey":%q,"account":{"lamports":1,"owner":%q,"executable":false,"rentEpoch":0,"data":[%q,"base64"]}}}`,
+		account, programID, encodeTestAccountState(t, bin.EncodingBin, testAccountState{Version: 2, Counter: 9, Name: "abc"}),
+	))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got, err := sub.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), got.Slot)
+	require.Equal(t, account, got.Pubkey)
+	require.Equal(t, &testAccountState{Version: 2, Counter: 9, Name: "abc"}, got.Value)
+}
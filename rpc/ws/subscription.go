@@ -17,7 +17,89 @@
 
 package ws
 
-import "context"
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// DefaultSubscriptionBufferSize is the default number of messages
+// buffered for each subscription.
+const DefaultSubscriptionBufferSize = 200_000
+
+// ErrSubscriptionOverflow is the error of a subscription closed by the OverflowClose policy.
+var ErrSubscriptionOverflow = errors.New("subscription buffer is full")
+
+// OverflowPolicy is what happens when a message is received
+// for a subscription whose buffer is full.
+type OverflowPolicy int
+
+const (
+	// Close the subscription with an error wrapping ErrSubscriptionOverflow (the default).
+	OverflowClose OverflowPolicy = iota
+	// Wait for the consumer; this blocks the messages of all the subscriptions of the client.
+	OverflowBlock
+	// Drop the oldest buffered message to make room for the new one.
+	OverflowDropOldest
+	// Drop the new message.
+	OverflowDropNewest
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowClose:
+		return "close"
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	default:
+		return "unknown"
+	}
+}
+
+type subscriptionConfig struct {
+	bufferSize     int
+	overflowPolicy OverflowPolicy
+	// Only used by the typed subscriptions.
+	dataEncoding bin.Encoding
+}
+
+// SubscriptionOption configures a subscription;
+// the defaults are set with the Options of the client.
+type SubscriptionOption func(*subscriptionConfig)
+
+// WithBufferSize sets the number of messages buffered for the subscription.
+func WithBufferSize(size int) SubscriptionOption {
+	return func(conf *subscriptionConfig) {
+		if size > 0 {
+			conf.bufferSize = size
+		}
+	}
+}
+
+// WithOverflowPolicy sets what happens when the buffer of the subscription is full.
+func WithOverflowPolicy(policy OverflowPolicy) SubscriptionOption {
+	return func(conf *subscriptionConfig) {
+		conf.overflowPolicy = policy
+	}
+}
+
+type SubscriptionStats struct {
+	BufferSize     int
+	OverflowPolicy OverflowPolicy
+	// Number of messages waiting in the buffer.
+	Queued int
+	// Number of messages received from the node.
+	Received uint64
+	// Number of messages dropped because the buffer was full.
+	Dropped uint64
+}
 
 type Subscription struct {
 	req               *request
@@ -28,6 +110,14 @@ type Subscription struct {
 	closed            bool
 	unsubscribeMethod string
 	decoderFunc       decoderFunc
+
+	overflowPolicy OverflowPolicy
+	// Held while delivering a message; closed is set, and the channels closed, with it held.
+	mu        sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+	received  atomic.Uint64
+	dropped   atomic.Uint64
 }
 
 type decoderFunc func([]byte) (interface{}, error)
@@ -37,15 +127,22 @@ func newSubscription(
 	closeFunc func(err error),
 	unsubscribeMethod string,
 	decoderFunc decoderFunc,
+	conf subscriptionConfig,
 ) *Subscription {
+	if conf.bufferSize <= 0 {
+		conf.bufferSize = DefaultSubscriptionBufferSize
+	}
 	return &Subscription{
-		req:               req,
-		subID:             0,
-		stream:            make(chan result, 200_000),
-		err:               make(chan error, 100_000),
+		req:    req,
+		subID:  0,
+		stream: make(chan result, conf.bufferSize),
+		// A subscription gets at most one error, as it is closed with it.
+		err:               make(chan error, 1),
 		closeFunc:         closeFunc,
 		unsubscribeMethod: unsubscribeMethod,
 		decoderFunc:       decoderFunc,
+		overflowPolicy:    conf.overflowPolicy,
+		done:              make(chan struct{}),
 	}
 }
 
@@ -60,13 +157,88 @@ func (s *Subscription) Recv(ctx context.Context) (interface{}, error) {
 	}
 }
 
+// Stats returns the counters of the subscription.
+func (s *Subscription) Stats() SubscriptionStats {
+	return SubscriptionStats{
+		BufferSize:     cap(s.stream),
+		OverflowPolicy: s.overflowPolicy,
+		Queued:         len(s.stream),
+		Received:       s.received.Load(),
+		Dropped:        s.dropped.Load(),
+	}
+}
+
 func (s *Subscription) Unsubscribe() {
 	s.unsubscribe(nil)
 }
 
 func (s *Subscription) unsubscribe(err error) {
-	s.closeFunc(err)
-	s.closed = true
-	close(s.stream)
-	close(s.err)
+	s.closeOnce.Do(func() {
+		// Unblock a pending delivery.
+		close(s.done)
+		s.closeFunc(err)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		close(s.stream)
+		close(s.err)
+	})
+}
+
+// sendErr sends the error of the subscription, without blocking.
+func (s *Subscription) sendErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.err <- err:
+	default:
+	}
+}
+
+// deliver queues a message according to the overflow policy;
+// it returns false when the subscription must be closed.
+func (s *Subscription) deliver(res result) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return true
+	}
+	s.received.Add(1)
+
+	if s.overflowPolicy == OverflowBlock {
+		select {
+		case s.stream <- res:
+		case <-s.done:
+		}
+		return true
+	}
+
+	select {
+	case s.stream <- res:
+		return true
+	default:
+	}
+	switch s.overflowPolicy {
+	case OverflowDropNewest:
+		s.dropped.Add(1)
+		return true
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-s.stream:
+				s.dropped.Add(1)
+			default:
+			}
+			select {
+			case s.stream <- res:
+				return true
+			default:
+			}
+		}
+	default:
+		return false
+	}
 }
@@ -0,0 +1,303 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gorilla/rpc/v2/json2"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSubscription(opts ...SubscriptionOption) *Subscription {
+	var conf subscriptionConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return newSubscription(&request{ID: 1}, func(error) {}, "slotUnsubscribe", nil, conf)
+}
+
+func drain(sub *Subscription) []result {
+	var out []result
+	for len(sub.stream) > 0 {
+		out = append(out, <-sub.stream)
+	}
+	return out
+}
+
+func TestSubscription_OverflowPolicies(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		sub := newTestSubscription()
+		stats := sub.Stats()
+		require.Equal(t, DefaultSubscriptionBufferSize, stats.BufferSize)
+		require.Equal(t, OverflowClose, stats.OverflowPolicy)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		sub := newTestSubscription(WithBufferSize(2))
+		require.True(t, sub.deliver(1))
+		require.True(t, sub.deliver(2))
+		require.False(t, sub.deliver(3))
+		require.Equal(t, []result{1, 2}, drain(sub))
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		sub := newTestSubscription(WithBufferSize(2), WithOverflowPolicy(OverflowDropNewest))
+		for i := 1; i <= 5; i++ {
+			require.True(t, sub.deliver(i))
+		}
+		require.Equal(t, SubscriptionStats{
+			BufferSize:     2,
+			OverflowPolicy: OverflowDropNewest,
+			Queued:         2,
+			Received:       5,
+			Dropped:        3,
+		}, sub.Stats())
+		require.Equal(t, []result{1, 2}, drain(sub))
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		sub := newTestSubscription(WithBufferSize(2), WithOverflowPolicy(OverflowDropOldest))
+		for i := 1; i <= 5; i++ {
+			require.True(t, sub.deliver(i))
+		}
+		require.Equal(t, uint64(3), sub.Stats().Dropped)
+		require.Equal(t, []result{4, 5}, drain(sub))
+	})
+
+	t.Run("block", func(t *testing.T) {
+		sub := newTestSubscription(WithBufferSize(1), WithOverflowPolicy(OverflowBlock))
+		require.True(t, sub.deliver(1))
+
+		delivered := make(chan bool)
+		go func() {
+			delivered <- sub.deliver(2)
+		}()
+		select {
+		case <-delivered:
+			t.Fatal("delivery should block while the buffer is full")
+		case <-time.After(20 * time.Millisecond):
+		}
+		require.Equal(t, 1, <-sub.stream)
+		require.True(t, <-delivered)
+		require.Equal(t, 2, <-sub.stream)
+
+		// Unsubscribing unblocks a pending delivery.
+		require.True(t, sub.deliver(3))
+		go func() {
+			delivered <- sub.deliver(4)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		sub.Unsubscribe()
+		require.True(t, <-delivered)
+		require.True(t, sub.deliver(5))
+	})
+}
+
+type mockWSRequest struct {
+	ID     uint64        `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// newMockWSServer starts a websocket server that accepts the subscriptions
+// (except voteSubscribe, which is rejected as by the nodes where it is not enabled),
+// and returns the received requests, and a function to send notifications.
+func newMockWSServer(t *testing.T) (url string, requests chan mockWSRequest, notify func(subID uint64, result string)) {
+	requests = make(chan mockWSRequest, 100)
+	conns := make(chan *websocket.Conn, 1)
+	var nextSubID uint64
+	// Guards the writes to the connection.
+	var writeMu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- conn
+		for {
+			var req mockWSRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			requests <- req
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true}
+			switch {
+			case req.Method == "voteSubscribe":
+				delete(response, "result")
+				response["error"] = map[string]interface{}{"code": -32601, "message": "Method not found"}
+			case strings.HasSuffix(req.Method, "Subscribe"):
+				nextSubID++
+				response["result"] = nextSubID
+			}
+			writeMu.Lock()
+			conn.WriteJSON(response)
+			writeMu.Unlock()
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	var conn *websocket.Conn
+	notify = func(subID uint64, result string) {
+		if conn == nil {
+			conn = <-conns
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+			`{"jsonrpc":"2.0","method":"notification","params":{"result":%s,"subscription":%d}}`, result, subID,
+		))))
+	}
+	return "ws" + strings.TrimPrefix(server.URL, "http"), requests, notify
+}
+
+func TestClient_SharedSubscriptions(t *testing.T) {
+	url, requests, notify := newMockWSServer(t)
+	client, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer client.Close()
+
+	first, err := client.SlotSubscribe()
+	require.NoError(t, err)
+	second, err := client.SlotSubscribe(WithBufferSize(10))
+	require.NoError(t, err)
+	root, err := client.RootSubscribe()
+	require.NoError(t, err)
+
+	require.Equal(t, "slotSubscribe", (<-requests).Method)
+	require.Equal(t, "rootSubscribe", (<-requests).Method)
+	server, local := client.SubscriptionCount()
+	require.Equal(t, 2, server)
+	require.Equal(t, 3, local)
+
+	notify(1, `{"parent":1,"root":0,"slot":2}`)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, sub := range []*SlotSubscription{first, second} {
+		got, err := sub.Recv(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), got.Slot)
+	}
+
+	// The subscription on the node is closed with the last local one.
+	first.Unsubscribe()
+	server, local = client.SubscriptionCount()
+	require.Equal(t, 2, server)
+	require.Equal(t, 2, local)
+
+	notify(1, `{"parent":2,"root":0,"slot":3}`)
+	got, err := second.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), got.Slot)
+
+	second.Unsubscribe()
+	req := <-requests
+	require.Equal(t, "slotUnsubscribe", req.Method)
+	require.Equal(t, []interface{}{float64(1)}, req.Params)
+	server, local = client.SubscriptionCount()
+	require.Equal(t, 1, server)
+	require.Equal(t, 1, local)
+
+	// A new subscription is a new one on the node.
+	third, err := client.SlotSubscribe()
+	require.NoError(t, err)
+	defer third.Unsubscribe()
+	require.Equal(t, "slotSubscribe", (<-requests).Method)
+	root.Unsubscribe()
+	require.Equal(t, "rootUnsubscribe", (<-requests).Method)
+}
+
+func TestClient_SharedSubscriptionsWithDifferentResultTypes(t *testing.T) {
+	url, requests, notify := newMockWSServer(t)
+	client, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer client.Close()
+
+	opts := &BlockSubscribeOpts{Encoding: solana.EncodingJSONParsed}
+	blocks, err := client.BlockSubscribe(NewBlockSubscribeFilterAll(), opts)
+	require.NoError(t, err)
+	defer blocks.Unsubscribe()
+	otherBlocks, err := client.BlockSubscribe(NewBlockSubscribeFilterAll(), opts)
+	require.NoError(t, err)
+	defer otherBlocks.Unsubscribe()
+	parsedBlocks, err := client.ParsedBlockSubscribe(NewBlockSubscribeFilterAll(), opts)
+	require.NoError(t, err)
+	defer parsedBlocks.Unsubscribe()
+
+	// The requests are identical: they share the subscription on the node.
+	require.Equal(t, "blockSubscribe", (<-requests).Method)
+	server, local := client.SubscriptionCount()
+	require.Equal(t, 1, server)
+	require.Equal(t, 3, local)
+
+	notify(1, `{"context":{"slot":7},"value":{"slot":7,"err":null,"block":{"blockhash":"9fJDCEyBpSaEkD8VbDTmXCe9UadN1jyP8AvkExoj1XGd","previousBlockhash":"CHqSbR6m1jNpYfxtFv3JZ9gF9orAHAzhKQmVEAvr9bjw","parentSlot":6,"blockHeight":5}}}`)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	block, err := blocks.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), block.Value.Slot)
+	require.Equal(t, uint64(6), block.Value.Block.ParentSlot)
+
+	otherBlock, err := otherBlocks.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, block, otherBlock)
+	// Each subscriber gets its own copy.
+	require.NotSame(t, block, otherBlock)
+
+	parsedBlock, err := parsedBlocks.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), parsedBlock.Value.Slot)
+	require.Equal(t, uint64(6), parsedBlock.Value.Block.ParentSlot)
+}
+
+func TestClient_RejectedSubscription(t *testing.T) {
+	url, requests, _ := newMockWSServer(t)
+	client, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer client.Close()
+
+	sub, err := client.VoteSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	require.Equal(t, "voteSubscribe", (<-requests).Method)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = sub.Recv(ctx)
+	var rpcErr *json2.Error
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, json2.ErrorCode(-32601), rpcErr.Code)
+	require.Equal(t, "Method not found", rpcErr.Message)
+
+	server, local := client.SubscriptionCount()
+	require.Equal(t, 0, server)
+	require.Equal(t, 0, local)
+
+	// The other subscriptions are not affected.
+	slots, err := client.SlotSubscribe()
+	require.NoError(t, err)
+	defer slots.Unsubscribe()
+	require.Equal(t, "slotSubscribe", (<-requests).Method)
+}
@@ -73,6 +73,12 @@ type Options struct {
 	HttpHeader       http.Header
 	HandshakeTimeout time.Duration
 	ShortID          bool // some RPC do not support int63/uint64 id, so need to enable it to rand a int31/uint32 id
+	// Default number of messages buffered for each subscription;
+	// defaults to DefaultSubscriptionBufferSize.
+	SubscriptionBufferSize int
+	// Default policy when the buffer of a subscription is full;
+	// defaults to OverflowClose.
+	OverflowPolicy OverflowPolicy
 }
 
 var DefaultHandshakeTimeout = 45 * time.Second
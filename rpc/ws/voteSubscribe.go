@@ -37,7 +37,7 @@ type VoteResult struct {
 // This subscription is unstable and only available if the validator
 // was started with the --rpc-pubsub-enable-vote-subscription flag.
 // The format of this subscription may change in the future.
-func (cl *Client) VoteSubscribe() (*VoteSubscription, error) {
+func (cl *Client) VoteSubscribe(subOpts ...SubscriptionOption) (*VoteSubscription, error) {
 	genSub, err := cl.subscribe(
 		nil,
 		nil,
@@ -48,6 +48,7 @@ func (cl *Client) VoteSubscribe() (*VoteSubscription, error) {
 			err := decodeResponseFromMessage(msg, &res)
 			return &res, err
 		},
+		subOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -95,3 +96,7 @@ func (sw *VoteSubscription) Response() <-chan *VoteResult {
 func (sw *VoteSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+func (sw *VoteSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
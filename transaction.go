@@ -164,8 +164,9 @@ type TransactionOption interface {
 }
 
 type transactionOptions struct {
-	payer         PublicKey
-	addressTables map[PublicKey]PublicKeySlice // [tablePubkey]addresses
+	payer           PublicKey
+	addressTables   map[PublicKey]PublicKeySlice // [tablePubkey]addresses
+	blockhashSource BlockhashSource
 }
 
 type transactionOptionFunc func(opts *transactionOptions)
@@ -182,6 +183,19 @@ func TransactionAddressTables(tables map[PublicKey]PublicKeySlice) TransactionOp
 	return transactionOptionFunc(func(opts *transactionOptions) { opts.addressTables = tables })
 }
 
+// BlockhashSource provides recent blockhashes to build transactions with
+// (e.g. a cache that is refreshed in the background, like the BlockhashProvider
+// of the rpc/blockhashProvider package).
+type BlockhashSource interface {
+	RecentBlockhash() (Hash, error)
+}
+
+// TransactionBlockhashSource sets the source of the recent blockhash
+// used when the transaction is built without one.
+func TransactionBlockhashSource(source BlockhashSource) TransactionOption {
+	return transactionOptionFunc(func(opts *transactionOptions) { opts.blockhashSource = source })
+}
+
 var debugNewTransaction = false
 
 type TransactionBuilder struct {
@@ -207,6 +221,13 @@ func (builder *TransactionBuilder) SetRecentBlockHash(recentBlockHash Hash) *Tra
 	return builder
 }
 
+// SetBlockhashSource sets the source of the recent blockhash,
+// used if no recent blockhash is set with SetRecentBlockHash.
+func (builder *TransactionBuilder) SetBlockhashSource(source BlockhashSource) *TransactionBuilder {
+	builder.opts = append(builder.opts, TransactionBlockhashSource(source))
+	return builder
+}
+
 // WithOpt adds a TransactionOption.
 func (builder *TransactionBuilder) WithOpt(opt TransactionOption) *TransactionBuilder {
 	builder.opts = append(builder.opts, opt)
@@ -244,6 +265,14 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 		opt.apply(&options)
 	}
 
+	if recentBlockHash.IsZero() && options.blockhashSource != nil {
+		var err error
+		recentBlockHash, err = options.blockhashSource.RecentBlockhash()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get recent blockhash: %w", err)
+		}
+	}
+
 	feePayer := options.payer
 	if feePayer.IsZero() {
 		found := false
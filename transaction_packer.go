@@ -0,0 +1,212 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+const (
+	// PACKET_DATA_SIZE is the maximum size of a serialized transaction:
+	// the IPv6 minimum MTU (1280) minus the IPv6 header (40) and the UDP header (8).
+	PACKET_DATA_SIZE int = 1280 - 40 - 8
+
+	// MAX_COMPUTE_UNIT_LIMIT is the maximum number of compute units
+	// a single transaction can request.
+	MAX_COMPUTE_UNIT_LIMIT uint64 = 1400000
+
+	// DEFAULT_INSTRUCTION_COMPUTE_UNIT_LIMIT is the number of compute units
+	// the runtime allocates to each instruction when the transaction
+	// does not request a compute unit limit.
+	DEFAULT_INSTRUCTION_COMPUTE_UNIT_LIMIT uint64 = 200000
+)
+
+// InstructionGroup is a list of instructions that must be
+// placed in the same transaction (i.e. they are executed atomically).
+type InstructionGroup []Instruction
+
+// ComputeUnitEstimator returns the number of compute units
+// an instruction is expected to consume.
+type ComputeUnitEstimator func(instruction Instruction) uint64
+
+// TransactionPacker splits an ordered list of instructions into
+// the minimum number of transactions that each fit in a packet
+// and within a compute unit budget.
+// The order of the instructions is preserved across (and within) the transactions.
+type TransactionPacker struct {
+	recentBlockHash Hash
+	feePayer        PublicKey
+	addressTables   map[PublicKey]PublicKeySlice
+	prefix          []Instruction
+
+	maxSize int
+
+	computeUnitLimit uint64
+	computeUnits     ComputeUnitEstimator
+}
+
+// NewTransactionPacker creates a new transaction packer
+// for transactions paid by the provided fee payer.
+func NewTransactionPacker(feePayer PublicKey) *TransactionPacker {
+	return &TransactionPacker{
+		feePayer: feePayer,
+		maxSize:  PACKET_DATA_SIZE,
+	}
+}
+
+// SetRecentBlockHash sets the recent blockhash used for all the packed transactions.
+func (packer *TransactionPacker) SetRecentBlockHash(recentBlockHash Hash) *TransactionPacker {
+	packer.recentBlockHash = recentBlockHash
+	return packer
+}
+
+// SetAddressTables sets the address lookup tables that can be used
+// to compress the account keys of the packed transactions.
+// When set, the packed transactions are versioned (v0) transactions.
+func (packer *TransactionPacker) SetAddressTables(tables map[PublicKey]PublicKeySlice) *TransactionPacker {
+	packer.addressTables = tables
+	return packer
+}
+
+// SetPrefixInstructions sets instructions that are prepended to every packed transaction
+// (e.g. compute budget instructions); they count towards the size and compute budget.
+func (packer *TransactionPacker) SetPrefixInstructions(instructions ...Instruction) *TransactionPacker {
+	packer.prefix = instructions
+	return packer
+}
+
+// SetMaxTransactionSize overrides the maximum size (in bytes) of a serialized,
+// signed transaction. Defaults to PACKET_DATA_SIZE.
+func (packer *TransactionPacker) SetMaxTransactionSize(size int) *TransactionPacker {
+	packer.maxSize = size
+	return packer
+}
+
+// SetComputeUnitLimit sets the maximum number of compute units each
+// packed transaction can consume, as estimated by the provided estimator.
+// If the estimator is nil, every instruction is assumed to consume
+// DEFAULT_INSTRUCTION_COMPUTE_UNIT_LIMIT compute units.
+// By default, there is no compute unit limit.
+func (packer *TransactionPacker) SetComputeUnitLimit(limit uint64, estimator ComputeUnitEstimator) *TransactionPacker {
+	packer.computeUnitLimit = limit
+	packer.computeUnits = estimator
+	return packer
+}
+
+// Pack packs the provided instructions into transactions;
+// each instruction can end up in any transaction.
+func (packer *TransactionPacker) Pack(instructions ...Instruction) ([]*Transaction, error) {
+	groups := make([]InstructionGroup, len(instructions))
+	for i := range instructions {
+		groups[i] = InstructionGroup{instructions[i]}
+	}
+	return packer.PackGroups(groups...)
+}
+
+// PackGroups packs the provided instruction groups into transactions;
+// the instructions of a group always end up in the same transaction.
+func (packer *TransactionPacker) PackGroups(groups ...InstructionGroup) ([]*Transaction, error) {
+	if packer.feePayer.IsZero() {
+		return nil, errors.New("fee payer is not set")
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("no instructions to pack")
+	}
+
+	var out []*Transaction
+	var current *Transaction
+	instructions := append([]Instruction{}, packer.prefix...)
+	for groupIndex, group := range groups {
+		if len(group) == 0 {
+			return nil, fmt.Errorf("instruction group %d is empty", groupIndex)
+		}
+		candidate := append(instructions[:len(instructions):len(instructions)], group...)
+		tx, err := packer.tryBuild(candidate)
+		if err != nil {
+			return nil, fmt.Errorf("instruction group %d: %w", groupIndex, err)
+		}
+		if tx != nil {
+			current = tx
+			instructions = candidate
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("instruction group %d does not fit in a single transaction", groupIndex)
+		}
+		// The group doesn't fit in the current transaction: close it and start a new one.
+		out = append(out, current)
+		instructions = append(append([]Instruction{}, packer.prefix...), group...)
+		current, err = packer.tryBuild(instructions)
+		if err != nil {
+			return nil, fmt.Errorf("instruction group %d: %w", groupIndex, err)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("instruction group %d does not fit in a single transaction", groupIndex)
+		}
+	}
+	return append(out, current), nil
+}
+
+// tryBuild builds a transaction with the provided instructions;
+// it returns a nil transaction (and no error) if the transaction
+// exceeds the size or the compute budget.
+func (packer *TransactionPacker) tryBuild(instructions []Instruction) (*Transaction, error) {
+	if packer.computeUnitLimit > 0 && packer.estimateComputeUnits(instructions) > packer.computeUnitLimit {
+		return nil, nil
+	}
+	opts := []TransactionOption{TransactionPayer(packer.feePayer)}
+	if len(packer.addressTables) > 0 {
+		opts = append(opts, TransactionAddressTables(packer.addressTables))
+	}
+	tx, err := NewTransaction(instructions, packer.recentBlockHash, opts...)
+	if err != nil {
+		return nil, err
+	}
+	size, err := signedTransactionSize(tx)
+	if err != nil {
+		return nil, err
+	}
+	if size > packer.maxSize {
+		return nil, nil
+	}
+	return tx, nil
+}
+
+func (packer *TransactionPacker) estimateComputeUnits(instructions []Instruction) (total uint64) {
+	for _, instruction := range instructions {
+		if packer.computeUnits == nil {
+			total += DEFAULT_INSTRUCTION_COMPUTE_UNIT_LIMIT
+		} else {
+			total += packer.computeUnits(instruction)
+		}
+	}
+	return total
+}
+
+// signedTransactionSize returns the size of the serialized transaction
+// once all the required signatures are present.
+func signedTransactionSize(tx *Transaction) (int, error) {
+	messageContent, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	numSignatures := int(tx.Message.Header.NumRequiredSignatures)
+	var signatureCount []byte
+	bin.EncodeCompactU16Length(&signatureCount, numSignatures)
+	return len(signatureCount) + numSignatures*SignatureLength + len(messageContent), nil
+}
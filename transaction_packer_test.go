@@ -0,0 +1,134 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTransfer(from, to PublicKey) Instruction {
+	return &testTransactionInstructions{
+		accounts: []*AccountMeta{
+			{PublicKey: from, IsSigner: true, IsWritable: true},
+			{PublicKey: to, IsSigner: false, IsWritable: true},
+		},
+		data:      []byte{2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},
+		programID: SystemProgramID,
+	}
+}
+
+func TestTransactionPacker(t *testing.T) {
+	payer := newUniqueKey()
+	blockhash := Hash(newUniqueKey())
+
+	var instructions []Instruction
+	for i := 0; i < 100; i++ {
+		instructions = append(instructions, newTestTransfer(payer, newUniqueKey()))
+	}
+
+	t.Run("size", func(t *testing.T) {
+		txs, err := NewTransactionPacker(payer).
+			SetRecentBlockHash(blockhash).
+			Pack(instructions...)
+		require.NoError(t, err)
+		require.True(t, len(txs) > 1)
+
+		var packed []CompiledInstruction
+		for i, tx := range txs {
+			size, err := signedTransactionSize(tx)
+			require.NoError(t, err)
+			require.LessOrEqual(t, size, PACKET_DATA_SIZE)
+			require.Equal(t, payer, tx.Message.AccountKeys[0])
+			require.Equal(t, blockhash, tx.Message.RecentBlockhash)
+			if i < len(txs)-1 {
+				// Greedy packing: the next instruction would not have fit.
+				next := append(decompileForTest(t, tx), instructions[len(packed)+len(tx.Message.Instructions)])
+				bigger, err := NewTransaction(next, blockhash, TransactionPayer(payer))
+				require.NoError(t, err)
+				size, err := signedTransactionSize(bigger)
+				require.NoError(t, err)
+				require.Greater(t, size, PACKET_DATA_SIZE)
+			}
+			packed = append(packed, tx.Message.Instructions...)
+		}
+		require.Len(t, packed, len(instructions))
+	})
+
+	t.Run("compute units", func(t *testing.T) {
+		txs, err := NewTransactionPacker(payer).
+			SetRecentBlockHash(blockhash).
+			SetComputeUnitLimit(1000, func(Instruction) uint64 { return 300 }).
+			Pack(instructions[:10]...)
+		require.NoError(t, err)
+		require.Len(t, txs, 4)
+		require.Len(t, txs[0].Message.Instructions, 3)
+		require.Len(t, txs[3].Message.Instructions, 1)
+	})
+
+	t.Run("groups and prefix", func(t *testing.T) {
+		prefix := &testTransactionInstructions{
+			data:      []byte{3, 0x40, 0x42, 0x0f, 0x00},
+			programID: MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111"),
+		}
+		groups := []InstructionGroup{
+			instructions[0:3],
+			instructions[3:6],
+			instructions[6:9],
+		}
+		txs, err := NewTransactionPacker(payer).
+			SetRecentBlockHash(blockhash).
+			SetPrefixInstructions(prefix).
+			SetComputeUnitLimit(7, func(Instruction) uint64 { return 1 }).
+			PackGroups(groups...)
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+		for _, tx := range txs {
+			programID, err := tx.ResolveProgramIDIndex(tx.Message.Instructions[0].ProgramIDIndex)
+			require.NoError(t, err)
+			require.Equal(t, prefix.programID, programID)
+		}
+		require.Len(t, txs[0].Message.Instructions, 7)
+		require.Len(t, txs[1].Message.Instructions, 4)
+	})
+
+	t.Run("group too large", func(t *testing.T) {
+		_, err := NewTransactionPacker(payer).
+			SetRecentBlockHash(blockhash).
+			PackGroups(instructions)
+		require.Error(t, err)
+	})
+}
+
+func decompileForTest(t *testing.T, tx *Transaction) []Instruction {
+	out := make([]Instruction, 0, len(tx.Message.Instructions))
+	for _, inst := range tx.Message.Instructions {
+		programID, err := tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
+		require.NoError(t, err)
+		accounts, err := inst.ResolveInstructionAccounts(&tx.Message)
+		require.NoError(t, err)
+		out = append(out, &testTransactionInstructions{
+			accounts:  accounts,
+			data:      inst.Data,
+			programID: programID,
+		})
+	}
+	return out
+}
+
+func newUniqueKey() PublicKey {
+	return NewWallet().PublicKey()
+}